@@ -22,6 +22,31 @@ type Destination struct {
 	// Type of Kubernetes Secret. Requires Create to be set to true.
 	// Defaults to Opaque.
 	Type v1.SecretType `json:"type,omitempty"`
+	// Transformation of the secret data before it is synced to the Secret.
+	Transformation *Transformation `json:"transformation,omitempty"`
+}
+
+// Transformation renders keys of the destination Secret from Go templates. The Vault secret's data
+// is available to the templates as .Secrets, e.g. {{ .Secrets.username }}, along with the Sprig
+// template functions, minus the ones that access the environment or the network, or that can render
+// output of an unbounded size.
+type Transformation struct {
+	// Templates maps a key of the destination Secret to the Go template that renders its value.
+	// They take precedence over the templates of the TransformationRefs, and over the Vault secret's
+	// data that has the same key.
+	Templates map[string]string `json:"templates,omitempty"`
+	// TransformationRefs to SecretTransformation resources whose templates are included, in order.
+	// A key that is provided by more than one of them takes the template of the last one.
+	TransformationRefs []TransformationRef `json:"transformationRefs,omitempty"`
+	// Excludes the keys of the Vault secret's data that match any of the regular expressions from the
+	// destination Secret, e.g. "^_raw$", or ".*" to only include the keys rendered from templates.
+	Excludes []string `json:"excludes,omitempty"`
+}
+
+// TransformationRef to a SecretTransformation resource in the namespace of the referring resource.
+type TransformationRef struct {
+	// Name of the SecretTransformation.
+	Name string `json:"name"`
 }
 
 // RolloutRestartTarget provides the configuration required to perform a
@@ -37,3 +62,12 @@ type RolloutRestartTarget struct {
 	Kind string `json:"kind"`
 	Name string `json:"name"`
 }
+
+// RolloutRestartTargetStatus of a RolloutRestartTarget that was last rollout-restarted.
+type RolloutRestartTargetStatus struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// Generation of the target after it was patched for the rollout-restart,
+	// the rollout is complete once the target's status has observed it.
+	Generation int64 `json:"generation"`
+}
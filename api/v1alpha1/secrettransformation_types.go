@@ -0,0 +1,45 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SecretTransformationSpec defines the desired state of SecretTransformation
+type SecretTransformationSpec struct {
+	// Templates maps a key of the destination Secret to the Go template that renders its value.
+	// See Transformation for the data and functions that are available to the templates.
+	Templates map[string]string `json:"templates"`
+}
+
+//+kubebuilder:object:root=true
+
+// SecretTransformation is the Schema for the secrettransformations API.
+// It holds templates that can be shared by the Destination.Transformation of any number of
+// syncable-secret resources in its namespace.
+//
+// The templates are held in a dedicated resource, rather than in a ConfigMap, so that they are
+// validated against a schema, so that access to them can be granted separately from access to
+// ConfigMaps, and so that the Operator only needs to watch this type, rather than every ConfigMap in
+// the cluster, in order to sync its dependents again when the templates change.
+type SecretTransformation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec SecretTransformationSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// SecretTransformationList contains a list of SecretTransformation
+type SecretTransformationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SecretTransformation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SecretTransformation{}, &SecretTransformationList{})
+}
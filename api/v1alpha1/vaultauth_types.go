@@ -25,6 +25,67 @@ type VaultAuthConfigKubernetes struct {
 	TokenExpirationSeconds int64 `json:"tokenExpirationSeconds,omitempty"`
 }
 
+// VaultAuthConfigJWT provides VaultAuth configuration options needed for authenticating to Vault.
+type VaultAuthConfigJWT struct {
+	// Role to use for authenticating to Vault.
+	Role string `json:"role"`
+	// SecretRef is the name of a Kubernetes Secret in the consumer's (VDS/VSS/PKI) namespace which
+	// provides the JWT token to authenticate to Vault's JWT authentication backend. The Secret must
+	// have a key named `jwt` which holds the JWT token.
+	// Mutually exclusive with ServiceAccount.
+	SecretRef string `json:"secretRef,omitempty"`
+	// ServiceAccount to use when creating a ServiceAccount token to authenticate to Vault's
+	// JWT authentication backend.
+	// Mutually exclusive with SecretRef.
+	ServiceAccount string `json:"serviceAccount,omitempty"`
+	// TokenAudiences to include in the ServiceAccount token.
+	TokenAudiences []string `json:"audiences,omitempty"`
+	// TokenExpirationSeconds to set the ServiceAccount token.
+	// +kubebuilder:default=600
+	// +kubebuilder:validation:Minimum=600
+	TokenExpirationSeconds int64 `json:"tokenExpirationSeconds,omitempty"`
+}
+
+// VaultAuthConfigAppRole provides VaultAuth configuration options needed for authenticating to
+// Vault via an AppRole auth method.
+type VaultAuthConfigAppRole struct {
+	// RoleID of the AppRole Role to use for authenticating to Vault.
+	RoleID string `json:"roleId"`
+	// SecretRef is the name of a Kubernetes Secret in the consumer's (VDS/VSS/PKI) namespace which
+	// provides the AppRole Role's SecretID. The Secret must have a key named `id` which holds the
+	// AppRole Role's SecretID.
+	SecretRef string `json:"secretRef"`
+}
+
+// VaultAuthConfigAWS provides VaultAuth configuration options needed for authenticating to
+// Vault via an AWS IAM auth method. The AWS credentials are taken from the Operator's environment,
+// e.g. the environment variables, or the web identity token file configured by IRSA.
+type VaultAuthConfigAWS struct {
+	// Role to use for authenticating to Vault.
+	Role string `json:"role"`
+	// Region to use for signing the sts:GetCallerIdentity request.
+	// Defaults to us-east-1, and the global STS endpoint.
+	Region string `json:"region,omitempty"`
+	// HeaderValue for the X-Vault-AWS-IAM-Server-ID header, to be included in the signed request.
+	// Must match the iam_server_id_header_value configured on Vault's AWS auth backend.
+	HeaderValue string `json:"headerValue,omitempty"`
+	// STSEndpoint to use when signing the sts:GetCallerIdentity request.
+	// If not set, the endpoint will be derived from the Region.
+	STSEndpoint string `json:"stsEndpoint,omitempty"`
+}
+
+// VaultAuthConfigCert provides VaultAuth configuration options needed for authenticating to Vault
+// with a TLS client certificate.
+type VaultAuthConfigCert struct {
+	// Role is the name of the certificate role to authenticate against.
+	// If not set, Vault will try all certificate roles that match the presented certificate.
+	Role string `json:"role,omitempty"`
+	// SecretRef is the name of a Kubernetes secret of type kubernetes.io/tls in the consumer's
+	// (VDS/VSS/PKI) namespace, that provides the client certificate and key in the "tls.crt"
+	// and "tls.key" data keys.
+	SecretRef string `json:"secretRef"`
+}
+
 // VaultAuthSpec defines the desired state of VaultAuth
 type VaultAuthSpec struct {
 	// VaultConnectionRef of the corresponding VaultConnection CustomResource.
@@ -34,16 +95,25 @@ type VaultAuthSpec struct {
 	// Namespace to auth to in Vault
 	Namespace string `json:"namespace,omitempty"`
 	// Method to use when authenticating to Vault.
-	// +kubebuilder:validation:Enum=kubernetes
+	// +kubebuilder:validation:Enum={kubernetes,jwt,appRole,aws,cert}
 	Method string `json:"method"`
 	// Mount to use when authenticating to auth method.
 	Mount string `json:"mount"`
-	// Params to use when authenticating to Vault
+	// Params to use when authenticating to Vault. They are merged into the login request of every auth method.
+	// Params that are set by the auth method itself, e.g. role and jwt for the kubernetes method, are not allowed.
 	Params map[string]string `json:"params,omitempty"`
 	// Headers to be included in all Vault requests.
 	Headers map[string]string `json:"headers,omitempty"`
 	// Kubernetes specific auth configuration, requires that the Method be set to kubernetes.
 	Kubernetes *VaultAuthConfigKubernetes `json:"kubernetes,omitempty"`
+	// JWT specific auth configuration, requires that the Method be set to jwt.
+	JWT *VaultAuthConfigJWT `json:"jwt,omitempty"`
+	// AppRole specific auth configuration, requires that the Method be set to appRole.
+	AppRole *VaultAuthConfigAppRole `json:"appRole,omitempty"`
+	// AWS specific auth configuration, requires that the Method be set to aws.
+	AWS *VaultAuthConfigAWS `json:"aws,omitempty"`
+	// Cert specific auth configuration, requires that the Method be set to cert.
+	Cert *VaultAuthConfigCert `json:"cert,omitempty"`
 	// StorageEncryption provides the necessary configuration to encrypt the client storage cache.
 	// This should only be configured when client cache persistence with encryption is enabled.
 	// This is done by passing setting the manager's commandline argument --client-cache-persistence-model=direct-encrypted
@@ -57,6 +127,12 @@ type VaultAuthStatus struct {
 	// Valid auth mechanism.
 	Valid bool   `json:"valid"`
 	Error string `json:"error"`
+	// ObservedGeneration is the generation of the VaultAuth that was last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the VaultAuth.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -14,20 +14,58 @@ import (
 type VaultConnectionSpec struct {
 	// Address of the Vault server
 	Address string `json:"address"`
+	// FallbackAddresses of Vault servers to fail over to, in order, whenever the Vault server at Address
+	// is unreachable, sealed, or in standby.
+	FallbackAddresses []string `json:"fallbackAddresses,omitempty"`
 	// Headers to be included in all Vault requests.
 	Headers map[string]string `json:"headers,omitempty"`
 	// TLSServerName to use as the SNI host for TLS connections.
 	TLSServerName string `json:"tlsServerName,omitempty"`
 	// CACertSecretRef containing the trusted PEM encoded CA certificate chain.
 	CACertSecretRef string `json:"caCertSecretRef,omitempty"`
+	// ClientCertSecretRef is the name of a Secret of type kubernetes.io/tls, containing the client certificate and key
+	// that will be presented to the Vault server for all connections. It must be in the same namespace as the
+	// VaultConnection. Updating the Secret will result in all Vault clients for this connection being recreated.
+	ClientCertSecretRef string `json:"clientCertSecretRef,omitempty"`
 	// SkipTLSVerify for TLS connections.
 	SkipTLSVerify bool `json:"skipTLSVerify,omitempty"`
 }
 
+// VaultConnectionHealth is the health of the Vault server, as reported by its sys/health endpoint.
+type VaultConnectionHealth struct {
+	// Initialized is true if the Vault server is initialized.
+	Initialized bool `json:"initialized"`
+	// Sealed is true if the Vault server is sealed.
+	Sealed bool `json:"sealed"`
+	// Standby is true if the Vault server is a standby node.
+	Standby bool `json:"standby"`
+	// PerformanceStandby is true if the Vault server is a performance standby node.
+	PerformanceStandby bool `json:"performanceStandby"`
+	// Version of the Vault server.
+	Version string `json:"version,omitempty"`
+	// ClusterName of the Vault server.
+	ClusterName string `json:"clusterName,omitempty"`
+}
+
 // VaultConnectionStatus defines the observed state of VaultConnection
 type VaultConnectionStatus struct {
 	// Valid auth mechanism.
 	Valid bool `json:"valid"`
+	// ActiveAddress of the Vault server that is currently in use.
+	ActiveAddress string `json:"activeAddress,omitempty"`
+	// ClientCertSecretVersion is the resource version of the ClientCertSecretRef Secret
+	// that is in use by the Vault clients for this connection.
+	ClientCertSecretVersion string `json:"clientCertSecretVersion,omitempty"`
+	// Health of the Vault server at the ActiveAddress, as of the LastProbeTime.
+	Health *VaultConnectionHealth `json:"health,omitempty"`
+	// LastProbeTime is the last time that the health of the Vault server was probed.
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+	// ObservedGeneration is the generation of the VaultConnection that was last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the VaultConnection.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -18,8 +18,35 @@ type VaultDynamicSecretSpec struct {
 	Namespace string `json:"namespace,omitempty"`
 	// Mount path of the secret's engine in Vault.
 	Mount string `json:"mount"`
-	// Role in Vault to get the credentials for.
-	Role string `json:"role"`
+	// Role in Vault to get the credentials for. This is shorthand for setting Path to "creds/<role>".
+	// One of Role or Path must be set, Path takes precedence.
+	Role string `json:"role,omitempty"`
+	// Path in Vault to request the credentials from, relative to Mount. e.g. "sts/my-role" for the
+	// AWS secrets engine, or "static-creds/my-role" for the database secrets engine.
+	// Static credentials are read again shortly after Vault rotates them, and the RolloutRestartTargets
+	// are restarted whenever they have changed.
+	Path string `json:"path,omitempty"`
+	// RequestHTTPMethod to use when requesting the credentials from Vault. If unset, GET is used, or PUT
+	// when Params are set. Params can only be sent with the POST or PUT methods.
+	// +kubebuilder:validation:Enum={GET,POST,PUT}
+	RequestHTTPMethod string `json:"requestHTTPMethod,omitempty"`
+	// Params to include in the request to Vault, e.g. "ttl" or "role_arn" for the AWS secrets engine.
+	Params map[string]string `json:"params,omitempty"`
+	// RotationPercent of a lease's duration after which the secret will be rotated, rather than renewed.
+	// This applies to non-renewable leases, and to leases whose renewal was truncated by the role's max TTL.
+	// In the latter case it is the percentage of the lease's remaining duration. Defaults to 67.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=90
+	RotationPercent int `json:"rotationPercent,omitempty"`
+	// RotationGracePeriod enables overlapping rotation of the secret. Upon rotation, the previous lease is kept,
+	// and renewed for the grace period, so that the consumers of the previous secret have time to roll over
+	// to the new one. The previous lease is revoked once the grace period has elapsed, or once the rollouts of all
+	// RolloutRestartTargets have completed, whichever comes first. Specified as a duration string, e.g. "5m".
+	RotationGracePeriod string `json:"rotationGracePeriod,omitempty"`
+	// Revoke the secret's lease in Vault when the resource is deleted.
+	Revoke bool `json:"revoke,omitempty"`
+	// Clear the Kubernetes secret when the resource is deleted.
+	Clear bool `json:"clear,omitempty"`
 	// RolloutRestartTargets should be configured whenever the application(s) consuming the Vault secret does
 	// not support dynamically reloading a rotated secret.
 	// In that case one, or more RolloutRestartTarget(s) can be configured here. The Operator will
@@ -36,9 +63,35 @@ type VaultDynamicSecretStatus struct {
 	LastRenewalTime int64 `json:"lastRenewalTime"`
 	// SecretLease for the Vault secret.
 	SecretLease VaultSecretLease `json:"secretLease"`
+	// PreviousSecretLease that was replaced by the last rotation. It is only kept for the RotationGracePeriod,
+	// after which it is revoked.
+	PreviousSecretLease *VaultSecretLease `json:"previousSecretLease,omitempty"`
+	// LastRotationTime of the secret, in seconds since the epoch.
+	LastRotationTime int64 `json:"lastRotationTime,omitempty"`
+	// RolloutRestartTargets that were rollout-restarted by the last rotation.
+	RolloutRestartTargets []RolloutRestartTargetStatus `json:"rolloutRestartTargets,omitempty"`
+	// StaticCredsMetaData of the last static credential read from Vault, e.g. from a database secrets engine's
+	// "static-creds" endpoint. Static credentials have no lease, they are rotated by Vault instead.
+	StaticCredsMetaData *VaultStaticCredsMetaData `json:"staticCredsMetaData,omitempty"`
+	// SecretMAC of the data that was last synced to the Destination Secret. It is used to detect drift
+	// in the Destination Secret's Data, in which case a new secret is synced from Vault.
+	SecretMAC string `json:"secretMAC,omitempty"`
 	// LastRuntimePodUID used for tracking the transition from one Pod to the next.
 	// It is used to mitigate the effects of a Vault lease renewal storm.
 	LastRuntimePodUID types.UID `json:"lastRuntimePodUID,omitempty"`
+	// VaultAuthGeneration is the generation of the VaultAuth that was used for the last sync.
+	VaultAuthGeneration int64 `json:"vaultAuthGeneration,omitempty"`
+	// VaultConnectionGeneration is the generation of the VaultConnection that was used for the last sync.
+	VaultConnectionGeneration int64 `json:"vaultConnectionGeneration,omitempty"`
+	// TransformationHash is the hash of the templates of the Destination's Transformation, including the
+	// ones of the referenced SecretTransformations, that were used for the last sync.
+	TransformationHash string `json:"transformationHash,omitempty"`
+	// ObservedGeneration is the generation of the VaultDynamicSecret that was last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the VaultDynamicSecret.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 type VaultSecretLease struct {
@@ -52,6 +105,16 @@ type VaultSecretLease struct {
 	RequestID string `json:"requestID"`
 }
 
+// VaultStaticCredsMetaData of a static credential that is rotated by Vault.
+type VaultStaticCredsMetaData struct {
+	// LastVaultRotation of the credential, in seconds since the epoch.
+	LastVaultRotation int64 `json:"lastVaultRotation"`
+	// RotationPeriod of the credential, in seconds.
+	RotationPeriod int64 `json:"rotationPeriod"`
+	// TTL of the credential, in seconds, until its next rotation, as of the time it was read.
+	TTL int64 `json:"ttl"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 
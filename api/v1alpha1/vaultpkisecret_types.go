@@ -115,6 +115,22 @@ type VaultPKISecretStatus struct {
 	Expiration   int64  `json:"expiration,omitempty"`
 	Valid        bool   `json:"valid"`
 	Error        string `json:"error"`
+	// SecretMAC of the data that was last synced to the Destination Secret. It is used to detect drift
+	// in the Destination Secret's Data, in which case a new certificate is issued.
+	SecretMAC string `json:"secretMAC,omitempty"`
+	// VaultAuthGeneration is the generation of the VaultAuth that was used for the last sync.
+	VaultAuthGeneration int64 `json:"vaultAuthGeneration,omitempty"`
+	// VaultConnectionGeneration is the generation of the VaultConnection that was used for the last sync.
+	VaultConnectionGeneration int64 `json:"vaultConnectionGeneration,omitempty"`
+	// TransformationHash is the hash of the templates of the Destination's Transformation, including the
+	// ones of the referenced SecretTransformations, that were used for the last sync.
+	TransformationHash string `json:"transformationHash,omitempty"`
+	// ObservedGeneration is the generation of the VaultPKISecret that was last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the VaultPKISecret.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
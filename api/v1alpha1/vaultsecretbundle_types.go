@@ -0,0 +1,217 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VaultSecretBundleSpec defines the desired state of VaultSecretBundle
+type VaultSecretBundleSpec struct {
+	// VaultAuthRef to the VaultAuth resource
+	// If no value is specified the Operator will default to the `default` VaultAuth,
+	// configured in its own Kubernetes namespace.
+	VaultAuthRef string `json:"vaultAuthRef,omitempty"`
+	// Sources of the secret data that is merged into the destination Secret.
+	// +kubebuilder:validation:MinItems=1
+	Sources []VaultSecretBundleSource `json:"sources"`
+	// ConflictPolicy for a key that is provided by more than one source. Error fails the sync, First keeps
+	// the value of the first source that provides the key, and Last the value of the last one, in the order
+	// of Sources. Defaults to Error.
+	// +kubebuilder:validation:Enum={Error,First,Last}
+	// +kubebuilder:default=Error
+	ConflictPolicy string `json:"conflictPolicy,omitempty"`
+	// RefreshAfter a period of time, in duration notation, after which the Static sources are read again.
+	RefreshAfter string `json:"refreshAfter,omitempty"`
+	// Revoke the leases of the Dynamic sources, and the certificates of the PKI sources, in Vault when the
+	// resource is deleted. The certificate of a PKI source is also revoked once it has been replaced by a
+	// rotation, whereas the lease of a Dynamic source is always revoked once it has been replaced.
+	Revoke bool `json:"revoke,omitempty"`
+	// RolloutRestartTargets should be configured whenever the application(s) consuming the Vault secret does
+	// not support dynamically reloading a rotated secret.
+	// In that case one, or more RolloutRestartTarget(s) can be configured here. The Operator will
+	// trigger a single "rollout-restart" for each target whenever the data of any of the sources changes.
+	// See RolloutRestartTarget for more details.
+	RolloutRestartTargets []RolloutRestartTarget `json:"rolloutRestartTargets,omitempty"`
+	// Destination provides configuration necessary for syncing the Vault secrets to Kubernetes.
+	// Its Transformation can neither exclude, nor replace, any of the keys that the sources provide.
+	Destination Destination `json:"destination"`
+}
+
+// VaultSecretBundleSource of the secret data, exactly one of Static, Dynamic, or PKI must be set.
+// The "_raw" key is not included in the source's data, since it would be provided by every source.
+type VaultSecretBundleSource struct {
+	// KeyPrefix to prepend to each of the source's keys in the destination Secret, e.g. "db_".
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+	// Static source from a KV secrets engine.
+	Static *VaultSecretBundleStaticSource `json:"static,omitempty"`
+	// Dynamic source of credentials, e.g. from the database secrets engine.
+	Dynamic *VaultSecretBundleDynamicSource `json:"dynamic,omitempty"`
+	// PKI source of a certificate, issued by the PKI secrets engine.
+	PKI *VaultSecretBundlePKISource `json:"pki,omitempty"`
+}
+
+// VaultSecretBundleStaticSource is read again after the bundle's RefreshAfter period.
+type VaultSecretBundleStaticSource struct {
+	// Mount for the secret in Vault
+	Mount string `json:"mount"`
+	// Name of the secret in Vault
+	Name string `json:"name"`
+	// Type of the Vault static secret
+	// +kubebuilder:validation:Enum={kv-v1,kv-v2}
+	Type string `json:"type"`
+	// Version of the secret to sync, only supported by the kv-v2 Type.
+	// If unset, the latest version of the secret is synced.
+	// +kubebuilder:validation:Minimum=1
+	Version int `json:"version,omitempty"`
+}
+
+// VaultSecretBundleDynamicSource's leases are not renewed, new credentials are requested
+// once RotationPercent of the lease's duration has elapsed.
+type VaultSecretBundleDynamicSource struct {
+	// Mount path of the secret's engine in Vault.
+	Mount string `json:"mount"`
+	// Role in Vault to get the credentials for. This is shorthand for setting Path to "creds/<role>".
+	// One of Role or Path must be set, Path takes precedence.
+	Role string `json:"role,omitempty"`
+	// Path in Vault to request the credentials from, relative to Mount.
+	Path string `json:"path,omitempty"`
+	// RequestHTTPMethod to use when requesting the credentials from Vault. If unset, GET is used, or PUT
+	// when Params are set. Params can only be sent with the POST or PUT methods.
+	// +kubebuilder:validation:Enum={GET,POST,PUT}
+	RequestHTTPMethod string `json:"requestHTTPMethod,omitempty"`
+	// Params to include in the request to Vault.
+	Params map[string]string `json:"params,omitempty"`
+	// RotationPercent of a lease's duration after which new credentials are requested. Defaults to 67.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=90
+	RotationPercent int `json:"rotationPercent,omitempty"`
+}
+
+// VaultSecretBundlePKISource is issued a new certificate once it is within ExpiryOffset of its expiration.
+type VaultSecretBundlePKISource struct {
+	// Mount for the secret in Vault
+	Mount string `json:"mount"`
+	// Name of the PKI role in Vault
+	Name string `json:"name"`
+	// IssuerRef reference to an existing PKI issuer, either by Vault-generated
+	// identifier, the literal string default to refer to the currently
+	// configured default issuer, or the name assigned to an issuer.
+	IssuerRef string `json:"issuerRef,omitempty"`
+	// ExpiryOffset to use for computing when the certificate should be renewed.
+	// Should be in duration notation e.g. 30s, 120s, etc.
+	// Set to empty string "" to prevent certificate rotation.
+	ExpiryOffset string `json:"expiryOffset,omitempty"`
+	// CommonName to include in the request.
+	CommonName string `json:"commonName"`
+	// AltNames to include in the request
+	// May contain both DNS names and email addresses.
+	AltNames []string `json:"altNames,omitempty"`
+	// IPSans to include in the request.
+	IPSans []string `json:"ipSans,omitempty"`
+	// The requested URI SANs.
+	URISans []string `json:"uriSans,omitempty"`
+	// Requested other SANs, in an array with the format
+	// oid;type:value for each entry.
+	OtherSans string `json:"otherSans,omitempty"`
+	// TTL for the certificate; sets the expiration date.
+	// Should be in duration notation e.g. 120s, 2h, etc.
+	TTL string `json:"ttl,omitempty"`
+	// Format for the certificate. Choices: "pem", "der", "pem_bundle".
+	// Default: pem
+	Format string `json:"format,omitempty"`
+	// PrivateKeyFormat, can be set to "pkcs8" to have the returned
+	// private key contain base64-encoded pkcs8 or PEM-encoded pkcs8.
+	// Default: der
+	PrivateKeyFormat string `json:"privateKeyFormat,omitempty"`
+	// NotAfter field of the certificate with specified date value.
+	// The value format should be given in UTC format YYYY-MM-ddTHH:MM:SSZ
+	NotAfter string `json:"notAfter,omitempty"`
+	// ExcludeCNFromSans from DNS or Email Subject Alternate Names.
+	// Default: false
+	ExcludeCNFromSans bool `json:"excludeCNFromSans,omitempty"`
+}
+
+// GetIssuerAPIData returns the request data for issuing the source's certificate, which is the same as that
+// of a VaultPKISecret with the same certificate fields.
+func (v *VaultSecretBundlePKISource) GetIssuerAPIData() map[string]interface{} {
+	p := &VaultPKISecret{
+		Spec: VaultPKISecretSpec{
+			CommonName:        v.CommonName,
+			AltNames:          v.AltNames,
+			IPSans:            v.IPSans,
+			URISans:           v.URISans,
+			OtherSans:         v.OtherSans,
+			TTL:               v.TTL,
+			Format:            v.Format,
+			PrivateKeyFormat:  v.PrivateKeyFormat,
+			NotAfter:          v.NotAfter,
+			ExcludeCNFromSans: v.ExcludeCNFromSans,
+		},
+	}
+
+	return p.GetIssuerAPIData()
+}
+
+// VaultSecretBundleStatus defines the observed state of VaultSecretBundle
+type VaultSecretBundleStatus struct {
+	// Sources status, in the same order as Spec.Sources.
+	Sources []VaultSecretBundleSourceStatus `json:"sources,omitempty"`
+	// LastSyncTime of the destination Secret, in Unix time.
+	LastSyncTime int64 `json:"lastSyncTime,omitempty"`
+	// SecretMAC of the data that was last synced to the Destination Secret. It is used to detect drift
+	// in the Destination Secret's Data, in which case every source is synced again.
+	SecretMAC string `json:"secretMAC,omitempty"`
+	// VaultAuthGeneration is the generation of the VaultAuth that was used for the last sync.
+	VaultAuthGeneration int64 `json:"vaultAuthGeneration,omitempty"`
+	// VaultConnectionGeneration is the generation of the VaultConnection that was used for the last sync.
+	VaultConnectionGeneration int64 `json:"vaultConnectionGeneration,omitempty"`
+	// ObservedGeneration is the generation of the VaultSecretBundle that was last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the VaultSecretBundle.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// VaultSecretBundleSourceStatus of a single source.
+type VaultSecretBundleSourceStatus struct {
+	// Keys that the source contributes to the destination Secret, including its KeyPrefix.
+	Keys []string `json:"keys,omitempty"`
+	// LastSyncTime of the source, in Unix time.
+	LastSyncTime int64 `json:"lastSyncTime,omitempty"`
+	// SecretLease of a Dynamic source.
+	SecretLease *VaultSecretLease `json:"secretLease,omitempty"`
+	// SerialNumber of a PKI source's certificate.
+	SerialNumber string `json:"serialNumber,omitempty"`
+	// Mount of the PKI secrets engine that issued a PKI source's certificate, it is revoked from this mount.
+	Mount string `json:"mount,omitempty"`
+	// Expiration of a PKI source's certificate, in Unix time.
+	Expiration int64 `json:"expiration,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+
+// VaultSecretBundle is the Schema for the vaultsecretbundles API
+type VaultSecretBundle struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VaultSecretBundleSpec   `json:"spec,omitempty"`
+	Status VaultSecretBundleStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// VaultSecretBundleList contains a list of VaultSecretBundle
+type VaultSecretBundleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []VaultSecretBundle `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&VaultSecretBundle{}, &VaultSecretBundleList{})
+}
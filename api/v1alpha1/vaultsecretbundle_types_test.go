@@ -0,0 +1,40 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package v1alpha1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVaultSecretBundlePKISource_GetIssuerAPIData(t *testing.T) {
+	src := &VaultSecretBundlePKISource{
+		Mount:             "pki",
+		Name:              "default",
+		CommonName:        "qux",
+		AltNames:          []string{"foo", "baz"},
+		IPSans:            []string{"buz", "qux"},
+		URISans:           []string{"*.foo.net", "*.baz.net"},
+		OtherSans:         "other",
+		TTL:               "30s",
+		NotAfter:          "2026-05-01T00:00:00Z",
+		Format:            "pem",
+		PrivateKeyFormat:  "rsa",
+		ExcludeCNFromSans: true,
+	}
+
+	assert.Equal(t, map[string]interface{}{
+		"common_name":          "qux",
+		"alt_names":            "foo,baz",
+		"ip_sans":              "buz,qux",
+		"uri_sans":             "*.foo.net,*.baz.net",
+		"other_sans":           "other",
+		"ttl":                  "30s",
+		"not_after":            "2026-05-01T00:00:00Z",
+		"exclude_cn_from_sans": true,
+		"format":               "pem",
+		"private_key_format":   "rsa",
+	}, src.GetIssuerAPIData())
+}
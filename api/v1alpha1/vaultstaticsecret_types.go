@@ -25,6 +25,10 @@ type VaultStaticSecretSpec struct {
 	// Type of the Vault static secret
 	// +kubebuilder:validation:Enum={kv-v1,kv-v2}
 	Type string `json:"type"`
+	// Version of the secret to sync, only supported by the kv-v2 Type.
+	// If unset, the latest version of the secret is synced.
+	// +kubebuilder:validation:Minimum=1
+	Version int `json:"version,omitempty"`
 	// RefreshAfter a period of time, in duration notation
 	RefreshAfter string `json:"refreshAfter,omitempty"`
 	// HMACSecretData determines whether the Operator computes the
@@ -41,10 +45,25 @@ type VaultStaticSecretSpec struct {
 	// All configured targets wil be ignored if HMACSecretData is set to false.
 	// See RolloutRestartTarget for more details.
 	RolloutRestartTargets []RolloutRestartTarget `json:"rolloutRestartTargets,omitempty"`
+	// CustomMetadata configures which keys of the kv-v2 secret's custom_metadata are added to the
+	// destination Secret's labels and annotations. Requires Destination.Create to be set to true.
+	CustomMetadata *CustomMetadataMapping `json:"customMetadata,omitempty"`
 	// Destination provides configuration necessary for syncing the Vault secret to Kubernetes.
 	Destination Destination `json:"destination"`
 }
 
+// CustomMetadataMapping of a kv-v2 secret's custom_metadata keys to the destination Secret's
+// labels and annotations. Keys that are not present in the custom_metadata are ignored.
+// Labels and annotations configured in the Destination take precedence.
+type CustomMetadataMapping struct {
+	// Labels lists the custom_metadata keys to add as labels.
+	Labels []string `json:"labels,omitempty"`
+	// Annotations lists the custom_metadata keys to add as annotations.
+	Annotations []string `json:"annotations,omitempty"`
+	// Prefix to prepend to each key, e.g. "vault.example.com/".
+	Prefix string `json:"prefix,omitempty"`
+}
+
 // VaultStaticSecretStatus defines the observed state of VaultStaticSecret
 type VaultStaticSecretStatus struct {
 	// SecretMAC used when deciding whether new Vault secret data should be synced.
@@ -55,6 +74,32 @@ type VaultStaticSecretStatus struct {
 	// The SecretMac is also used to detect drift in the Destination Secret's Data.
 	// If drift is detected the data will be synced to the Destination.
 	SecretMAC string `json:"secretMAC,omitempty"`
+	// SecretVersion of the kv-v2 secret that was last read from Vault.
+	SecretVersion *VaultSecretVersion `json:"secretVersion,omitempty"`
+	// SyncInputsHash is the hash of the inputs, other than the Vault secret itself, that the destination
+	// Secret was last synced from. The inputs are the spec, the VaultConnection's spec, and the templates of
+	// the referenced SecretTransformations.
+	SyncInputsHash string `json:"syncInputsHash,omitempty"`
+	// VaultAuthGeneration is the generation of the VaultAuth that was used for the last sync.
+	VaultAuthGeneration int64 `json:"vaultAuthGeneration,omitempty"`
+	// ObservedGeneration is the generation of the VaultStaticSecret that was last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions of the VaultStaticSecret.
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// VaultSecretVersion is the metadata of a kv-v2 secret version.
+type VaultSecretVersion struct {
+	// Version number of the secret.
+	Version int `json:"version"`
+	// CreatedTime of the version.
+	CreatedTime *metav1.Time `json:"createdTime,omitempty"`
+	// DeletionTime of the version, only set if the version was deleted.
+	DeletionTime *metav1.Time `json:"deletionTime,omitempty"`
+	// Destroyed is true if the version was permanently destroyed.
+	Destroyed bool `json:"destroyed,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -9,9 +9,35 @@
 package v1alpha1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CustomMetadataMapping) DeepCopyInto(out *CustomMetadataMapping) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CustomMetadataMapping.
+func (in *CustomMetadataMapping) DeepCopy() *CustomMetadataMapping {
+	if in == nil {
+		return nil
+	}
+	out := new(CustomMetadataMapping)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Destination) DeepCopyInto(out *Destination) {
 	*out = *in
@@ -29,6 +55,11 @@ func (in *Destination) DeepCopyInto(out *Destination) {
 			(*out)[key] = val
 		}
 	}
+	if in.Transformation != nil {
+		in, out := &in.Transformation, &out.Transformation
+		*out = new(Transformation)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Destination.
@@ -56,6 +87,101 @@ func (in *RolloutRestartTarget) DeepCopy() *RolloutRestartTarget {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RolloutRestartTargetStatus) DeepCopyInto(out *RolloutRestartTargetStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RolloutRestartTargetStatus.
+func (in *RolloutRestartTargetStatus) DeepCopy() *RolloutRestartTargetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RolloutRestartTargetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTransformation) DeepCopyInto(out *SecretTransformation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTransformation.
+func (in *SecretTransformation) DeepCopy() *SecretTransformation {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTransformation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecretTransformation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTransformationList) DeepCopyInto(out *SecretTransformationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SecretTransformation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTransformationList.
+func (in *SecretTransformationList) DeepCopy() *SecretTransformationList {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTransformationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SecretTransformationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecretTransformationSpec) DeepCopyInto(out *SecretTransformationSpec) {
+	*out = *in
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecretTransformationSpec.
+func (in *SecretTransformationSpec) DeepCopy() *SecretTransformationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecretTransformationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StorageEncryption) DeepCopyInto(out *StorageEncryption) {
 	*out = *in
@@ -71,13 +197,60 @@ func (in *StorageEncryption) DeepCopy() *StorageEncryption {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Transformation) DeepCopyInto(out *Transformation) {
+	*out = *in
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.TransformationRefs != nil {
+		in, out := &in.TransformationRefs, &out.TransformationRefs
+		*out = make([]TransformationRef, len(*in))
+		copy(*out, *in)
+	}
+	if in.Excludes != nil {
+		in, out := &in.Excludes, &out.Excludes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Transformation.
+func (in *Transformation) DeepCopy() *Transformation {
+	if in == nil {
+		return nil
+	}
+	out := new(Transformation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransformationRef) DeepCopyInto(out *TransformationRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransformationRef.
+func (in *TransformationRef) DeepCopy() *TransformationRef {
+	if in == nil {
+		return nil
+	}
+	out := new(TransformationRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultAuth) DeepCopyInto(out *VaultAuth) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuth.
@@ -98,6 +271,71 @@ func (in *VaultAuth) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuthConfigAWS) DeepCopyInto(out *VaultAuthConfigAWS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthConfigAWS.
+func (in *VaultAuthConfigAWS) DeepCopy() *VaultAuthConfigAWS {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuthConfigAWS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuthConfigAppRole) DeepCopyInto(out *VaultAuthConfigAppRole) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthConfigAppRole.
+func (in *VaultAuthConfigAppRole) DeepCopy() *VaultAuthConfigAppRole {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuthConfigAppRole)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuthConfigCert) DeepCopyInto(out *VaultAuthConfigCert) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthConfigCert.
+func (in *VaultAuthConfigCert) DeepCopy() *VaultAuthConfigCert {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuthConfigCert)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultAuthConfigJWT) DeepCopyInto(out *VaultAuthConfigJWT) {
+	*out = *in
+	if in.TokenAudiences != nil {
+		in, out := &in.TokenAudiences, &out.TokenAudiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthConfigJWT.
+func (in *VaultAuthConfigJWT) DeepCopy() *VaultAuthConfigJWT {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultAuthConfigJWT)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultAuthConfigKubernetes) DeepCopyInto(out *VaultAuthConfigKubernetes) {
 	*out = *in
@@ -172,6 +410,26 @@ func (in *VaultAuthSpec) DeepCopyInto(out *VaultAuthSpec) {
 		*out = new(VaultAuthConfigKubernetes)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.JWT != nil {
+		in, out := &in.JWT, &out.JWT
+		*out = new(VaultAuthConfigJWT)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AppRole != nil {
+		in, out := &in.AppRole, &out.AppRole
+		*out = new(VaultAuthConfigAppRole)
+		**out = **in
+	}
+	if in.AWS != nil {
+		in, out := &in.AWS, &out.AWS
+		*out = new(VaultAuthConfigAWS)
+		**out = **in
+	}
+	if in.Cert != nil {
+		in, out := &in.Cert, &out.Cert
+		*out = new(VaultAuthConfigCert)
+		**out = **in
+	}
 	if in.StorageEncryption != nil {
 		in, out := &in.StorageEncryption, &out.StorageEncryption
 		*out = new(StorageEncryption)
@@ -192,6 +450,13 @@ func (in *VaultAuthSpec) DeepCopy() *VaultAuthSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultAuthStatus) DeepCopyInto(out *VaultAuthStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultAuthStatus.
@@ -210,7 +475,7 @@ func (in *VaultConnection) DeepCopyInto(out *VaultConnection) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConnection.
@@ -231,6 +496,21 @@ func (in *VaultConnection) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultConnectionHealth) DeepCopyInto(out *VaultConnectionHealth) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConnectionHealth.
+func (in *VaultConnectionHealth) DeepCopy() *VaultConnectionHealth {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultConnectionHealth)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultConnectionList) DeepCopyInto(out *VaultConnectionList) {
 	*out = *in
@@ -266,6 +546,11 @@ func (in *VaultConnectionList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultConnectionSpec) DeepCopyInto(out *VaultConnectionSpec) {
 	*out = *in
+	if in.FallbackAddresses != nil {
+		in, out := &in.FallbackAddresses, &out.FallbackAddresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Headers != nil {
 		in, out := &in.Headers, &out.Headers
 		*out = make(map[string]string, len(*in))
@@ -288,6 +573,22 @@ func (in *VaultConnectionSpec) DeepCopy() *VaultConnectionSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultConnectionStatus) DeepCopyInto(out *VaultConnectionStatus) {
 	*out = *in
+	if in.Health != nil {
+		in, out := &in.Health, &out.Health
+		*out = new(VaultConnectionHealth)
+		**out = **in
+	}
+	if in.LastProbeTime != nil {
+		in, out := &in.LastProbeTime, &out.LastProbeTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultConnectionStatus.
@@ -306,7 +607,7 @@ func (in *VaultDynamicSecret) DeepCopyInto(out *VaultDynamicSecret) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultDynamicSecret.
@@ -362,6 +663,13 @@ func (in *VaultDynamicSecretList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultDynamicSecretSpec) DeepCopyInto(out *VaultDynamicSecretSpec) {
 	*out = *in
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.RolloutRestartTargets != nil {
 		in, out := &in.RolloutRestartTargets, &out.RolloutRestartTargets
 		*out = make([]RolloutRestartTarget, len(*in))
@@ -384,6 +692,28 @@ func (in *VaultDynamicSecretSpec) DeepCopy() *VaultDynamicSecretSpec {
 func (in *VaultDynamicSecretStatus) DeepCopyInto(out *VaultDynamicSecretStatus) {
 	*out = *in
 	out.SecretLease = in.SecretLease
+	if in.PreviousSecretLease != nil {
+		in, out := &in.PreviousSecretLease, &out.PreviousSecretLease
+		*out = new(VaultSecretLease)
+		**out = **in
+	}
+	if in.RolloutRestartTargets != nil {
+		in, out := &in.RolloutRestartTargets, &out.RolloutRestartTargets
+		*out = make([]RolloutRestartTargetStatus, len(*in))
+		copy(*out, *in)
+	}
+	if in.StaticCredsMetaData != nil {
+		in, out := &in.StaticCredsMetaData, &out.StaticCredsMetaData
+		*out = new(VaultStaticCredsMetaData)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultDynamicSecretStatus.
@@ -402,7 +732,7 @@ func (in *VaultPKISecret) DeepCopyInto(out *VaultPKISecret) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultPKISecret.
@@ -494,6 +824,13 @@ func (in *VaultPKISecretSpec) DeepCopy() *VaultPKISecretSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultPKISecretStatus) DeepCopyInto(out *VaultPKISecretStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultPKISecretStatus.
@@ -506,6 +843,244 @@ func (in *VaultPKISecretStatus) DeepCopy() *VaultPKISecretStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBundle) DeepCopyInto(out *VaultSecretBundle) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBundle.
+func (in *VaultSecretBundle) DeepCopy() *VaultSecretBundle {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultSecretBundle) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBundleDynamicSource) DeepCopyInto(out *VaultSecretBundleDynamicSource) {
+	*out = *in
+	if in.Params != nil {
+		in, out := &in.Params, &out.Params
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBundleDynamicSource.
+func (in *VaultSecretBundleDynamicSource) DeepCopy() *VaultSecretBundleDynamicSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBundleDynamicSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBundleList) DeepCopyInto(out *VaultSecretBundleList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]VaultSecretBundle, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBundleList.
+func (in *VaultSecretBundleList) DeepCopy() *VaultSecretBundleList {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBundleList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VaultSecretBundleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBundlePKISource) DeepCopyInto(out *VaultSecretBundlePKISource) {
+	*out = *in
+	if in.AltNames != nil {
+		in, out := &in.AltNames, &out.AltNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPSans != nil {
+		in, out := &in.IPSans, &out.IPSans
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.URISans != nil {
+		in, out := &in.URISans, &out.URISans
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBundlePKISource.
+func (in *VaultSecretBundlePKISource) DeepCopy() *VaultSecretBundlePKISource {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBundlePKISource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBundleSource) DeepCopyInto(out *VaultSecretBundleSource) {
+	*out = *in
+	if in.Static != nil {
+		in, out := &in.Static, &out.Static
+		*out = new(VaultSecretBundleStaticSource)
+		**out = **in
+	}
+	if in.Dynamic != nil {
+		in, out := &in.Dynamic, &out.Dynamic
+		*out = new(VaultSecretBundleDynamicSource)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PKI != nil {
+		in, out := &in.PKI, &out.PKI
+		*out = new(VaultSecretBundlePKISource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBundleSource.
+func (in *VaultSecretBundleSource) DeepCopy() *VaultSecretBundleSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBundleSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBundleSourceStatus) DeepCopyInto(out *VaultSecretBundleSourceStatus) {
+	*out = *in
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SecretLease != nil {
+		in, out := &in.SecretLease, &out.SecretLease
+		*out = new(VaultSecretLease)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBundleSourceStatus.
+func (in *VaultSecretBundleSourceStatus) DeepCopy() *VaultSecretBundleSourceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBundleSourceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBundleSpec) DeepCopyInto(out *VaultSecretBundleSpec) {
+	*out = *in
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]VaultSecretBundleSource, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RolloutRestartTargets != nil {
+		in, out := &in.RolloutRestartTargets, &out.RolloutRestartTargets
+		*out = make([]RolloutRestartTarget, len(*in))
+		copy(*out, *in)
+	}
+	in.Destination.DeepCopyInto(&out.Destination)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBundleSpec.
+func (in *VaultSecretBundleSpec) DeepCopy() *VaultSecretBundleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBundleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBundleStaticSource) DeepCopyInto(out *VaultSecretBundleStaticSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBundleStaticSource.
+func (in *VaultSecretBundleStaticSource) DeepCopy() *VaultSecretBundleStaticSource {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBundleStaticSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretBundleStatus) DeepCopyInto(out *VaultSecretBundleStatus) {
+	*out = *in
+	if in.Sources != nil {
+		in, out := &in.Sources, &out.Sources
+		*out = make([]VaultSecretBundleSourceStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretBundleStatus.
+func (in *VaultSecretBundleStatus) DeepCopy() *VaultSecretBundleStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretBundleStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultSecretLease) DeepCopyInto(out *VaultSecretLease) {
 	*out = *in
@@ -521,13 +1096,51 @@ func (in *VaultSecretLease) DeepCopy() *VaultSecretLease {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultSecretVersion) DeepCopyInto(out *VaultSecretVersion) {
+	*out = *in
+	if in.CreatedTime != nil {
+		in, out := &in.CreatedTime, &out.CreatedTime
+		*out = (*in).DeepCopy()
+	}
+	if in.DeletionTime != nil {
+		in, out := &in.DeletionTime, &out.DeletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultSecretVersion.
+func (in *VaultSecretVersion) DeepCopy() *VaultSecretVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultSecretVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VaultStaticCredsMetaData) DeepCopyInto(out *VaultStaticCredsMetaData) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultStaticCredsMetaData.
+func (in *VaultStaticCredsMetaData) DeepCopy() *VaultStaticCredsMetaData {
+	if in == nil {
+		return nil
+	}
+	out := new(VaultStaticCredsMetaData)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultStaticSecret) DeepCopyInto(out *VaultStaticSecret) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultStaticSecret.
@@ -588,6 +1201,11 @@ func (in *VaultStaticSecretSpec) DeepCopyInto(out *VaultStaticSecretSpec) {
 		*out = make([]RolloutRestartTarget, len(*in))
 		copy(*out, *in)
 	}
+	if in.CustomMetadata != nil {
+		in, out := &in.CustomMetadata, &out.CustomMetadata
+		*out = new(CustomMetadataMapping)
+		(*in).DeepCopyInto(*out)
+	}
 	in.Destination.DeepCopyInto(&out.Destination)
 }
 
@@ -604,6 +1222,18 @@ func (in *VaultStaticSecretSpec) DeepCopy() *VaultStaticSecretSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *VaultStaticSecretStatus) DeepCopyInto(out *VaultStaticSecretStatus) {
 	*out = *in
+	if in.SecretVersion != nil {
+		in, out := &in.SecretVersion, &out.SecretVersion
+		*out = new(VaultSecretVersion)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VaultStaticSecretStatus.
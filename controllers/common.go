@@ -5,16 +5,36 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"math/rand"
 	"time"
 
 	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
 	"github.com/hashicorp/vault-secrets-operator/internal/common"
+	"github.com/hashicorp/vault-secrets-operator/internal/helpers"
+	"github.com/hashicorp/vault-secrets-operator/internal/vault"
 
 	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 var random = rand.New(rand.NewSource(int64(time.Now().Nanosecond())))
@@ -48,6 +68,7 @@ func RemoveAllFinalizers(ctx context.Context, c client.Client, log logr.Logger)
 	// * VaultDynamicSecret
 	// * VaultStaticSecret <- not currently implemented
 	// * VaultPKISecret
+	// * VaultSecretBundle
 
 	vamList := &secretsv1alpha1.VaultAuthList{}
 	err := c.List(ctx, vamList, opts...)
@@ -76,6 +97,13 @@ func RemoveAllFinalizers(ctx context.Context, c client.Client, log logr.Logger)
 		log.Error(err, "Unable to list VaultPKISecret resources")
 	}
 	removeFinalizers(ctx, c, log, vpkiList)
+
+	vsbList := &secretsv1alpha1.VaultSecretBundleList{}
+	err = c.List(ctx, vsbList, opts...)
+	if err != nil {
+		log.Error(err, "Unable to list VaultSecretBundle resources")
+	}
+	removeFinalizers(ctx, c, log, vsbList)
 	return nil
 }
 
@@ -125,6 +153,365 @@ func removeFinalizers(ctx context.Context, c client.Client, log logr.Logger, obj
 				}
 			}
 		}
+	case *secretsv1alpha1.VaultSecretBundleList:
+		for _, x := range t.Items {
+			cnt++
+			if controllerutil.RemoveFinalizer(&x, vaultSecretBundleFinalizer) {
+				log.Info(fmt.Sprintf("Updating finalizer for SecretBundle %s", x.Name))
+				if err := c.Update(ctx, &x, &client.UpdateOptions{}); err != nil {
+					log.Error(err, fmt.Sprintf("Unable to update finalizer for %s: %s", vaultSecretBundleFinalizer, x.Name))
+				}
+			}
+		}
 	}
 	log.Info(fmt.Sprintf("Removed %d finalizers", cnt))
 }
+
+// NewManagerCache is the cache.NewCacheFunc of the Manager. Its cache only holds the Secrets that have the
+// helpers.OwnerLabels, i.e. the destination Secrets that were created by the Operator. All other Secrets must be
+// read from the API server, so the Manager's client must be configured to bypass the cache for Secrets.
+func NewManagerCache(config *rest.Config, opts cache.Options) (cache.Cache, error) {
+	if opts.SelectorsByObject == nil {
+		opts.SelectorsByObject = cache.SelectorsByObject{}
+	}
+	opts.SelectorsByObject[&corev1.Secret{}] = cache.ObjectSelector{
+		Label: labels.SelectorFromSet(helpers.OwnerLabels),
+	}
+
+	return cache.New(config, opts)
+}
+
+// NewClientCertSecretsCache returns a cache.Cache that only holds the Secrets of type kubernetes.io/tls, i.e. the
+// Secrets that can be referenced as a VaultConnection's client certificate. It must be added to mgr.
+func NewClientCertSecretsCache(mgr ctrl.Manager) (cache.Cache, error) {
+	return cache.New(mgr.GetConfig(), cache.Options{
+		Scheme: mgr.GetScheme(),
+		Mapper: mgr.GetRESTMapper(),
+		SelectorsByObject: cache.SelectorsByObject{
+			&corev1.Secret{}: {
+				Field: fields.OneTermEqualSelector("type", string(corev1.SecretTypeTLS)),
+			},
+		},
+	})
+}
+
+// watchDestinationSecrets watches the destination Secrets in secretsCache, any change to one of them enqueues
+// the object of ownerType that owns it. Nothing is watched if secretsCache is nil.
+func watchDestinationSecrets(b *builder.Builder, secretsCache cache.Cache, ownerType client.Object) *builder.Builder {
+	if secretsCache == nil {
+		return b
+	}
+
+	// Secrets do not have a generation, so they must not be filtered by the GenerationChangedPredicate.
+	return b.Watches(source.NewKindWithCache(&corev1.Secret{}, secretsCache),
+		&handler.EnqueueRequestForOwner{OwnerType: ownerType},
+		builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}))
+}
+
+// destinationSecretsReader returns the reader of the destination Secret of obj that is checked for drift. A Secret
+// that is created by the Operator is read from secretsCache, the same cache that its watch events come from. A
+// Secret that is not created by the Operator does not have the helpers.OwnerLabels that the cache is restricted
+// to, so it is read from c, as it is when secretsCache is nil.
+func destinationSecretsReader(c client.Client, secretsCache cache.Cache, obj client.Object) client.Reader {
+	if secretsCache == nil {
+		return c
+	}
+
+	meta, err := helpers.NewSyncableSecretMetaData(obj)
+	if err != nil || !meta.Destination.Create {
+		return c
+	}
+
+	return secretsCache
+}
+
+// computeSecretMAC returns the base64 encoded HMAC of the destination Secret's data.
+func computeSecretMAC(ctx context.Context, c client.Client, hmacFunc vault.HMACFromSecretFunc, data map[string][]byte) (string, error) {
+	message, err := json.Marshal(data)
+	if err != nil {
+		return "", err
+	}
+
+	mac, err := hmacFunc(ctx, c, message)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(mac), nil
+}
+
+// isDestinationDrifted returns true if the destination Secret of obj, read from secrets, no longer exists, or if
+// the HMAC of its data no longer matches lastMAC. The Secret's data is not validated if validateMACFunc is nil,
+// or if lastMAC is empty.
+func isDestinationDrifted(ctx context.Context, c client.Client, secrets client.Reader, obj client.Object,
+	validateMACFunc vault.ValidateMACFromSecretFunc, lastMAC string,
+) (bool, error) {
+	cur, ok, err := helpers.GetSecret(ctx, secrets, obj)
+	if err != nil {
+		return false, err
+	}
+	if !ok {
+		return true, nil
+	}
+
+	if validateMACFunc == nil || lastMAC == "" {
+		return false, nil
+	}
+
+	message, err := json.Marshal(cur.Data)
+	if err != nil {
+		return false, err
+	}
+
+	mac, err := base64.StdEncoding.DecodeString(lastMAC)
+	if err != nil {
+		return false, err
+	}
+
+	valid, _, err := validateMACFunc(ctx, c, message, mac)
+	if err != nil {
+		return false, err
+	}
+
+	return !valid, nil
+}
+
+const (
+	// vaultAuthRefIndex indexes the VaultStaticSecret, VaultDynamicSecret, VaultPKISecret, and VaultSecretBundle
+	// resources by the namespaced name of the VaultAuth that they reference.
+	vaultAuthRefIndex = "spec.vaultAuthRef"
+	// vaultConnectionRefIndex indexes the VaultAuth resources by the namespaced name of the VaultConnection
+	// that they reference.
+	vaultConnectionRefIndex = "spec.vaultConnectionRef"
+	// clientCertSecretRefIndex indexes the VaultConnection resources by the namespaced name of the
+	// Secret that their ClientCertSecretRef references.
+	clientCertSecretRefIndex = "spec.clientCertSecretRef"
+	// transformationRefIndex indexes the VaultStaticSecret, VaultDynamicSecret, VaultPKISecret, and
+	// VaultSecretBundle resources by the namespaced names of the SecretTransformations that they reference.
+	transformationRefIndex = "spec.destination.transformation.transformationRefs"
+)
+
+// SetupFieldIndexes registers the field indexes that are needed to find the resources that depend on
+// a VaultAuth, VaultConnection, or SecretTransformation, and the VaultConnections that depend on a client
+// certificate Secret. It must be called before any of the controllers are set up.
+func SetupFieldIndexes(ctx context.Context, indexer client.FieldIndexer) error {
+	for _, obj := range []client.Object{
+		&secretsv1alpha1.VaultStaticSecret{},
+		&secretsv1alpha1.VaultDynamicSecret{},
+		&secretsv1alpha1.VaultPKISecret{},
+		&secretsv1alpha1.VaultSecretBundle{},
+	} {
+		if err := indexer.IndexField(ctx, obj, vaultAuthRefIndex, indexVaultAuthRef); err != nil {
+			return err
+		}
+		if err := indexer.IndexField(ctx, obj, transformationRefIndex, indexTransformationRefs); err != nil {
+			return err
+		}
+	}
+
+	if err := indexer.IndexField(ctx, &secretsv1alpha1.VaultAuth{}, vaultConnectionRefIndex, indexVaultConnectionRef); err != nil {
+		return err
+	}
+
+	return indexer.IndexField(ctx, &secretsv1alpha1.VaultConnection{}, clientCertSecretRefIndex, indexClientCertSecretRef)
+}
+
+func indexVaultAuthRef(obj client.Object) []string {
+	authName, err := common.GetVaultAuthNamespacedName(obj)
+	if err != nil {
+		return nil
+	}
+	return []string{authName.String()}
+}
+
+func indexTransformationRefs(obj client.Object) []string {
+	meta, err := helpers.NewSyncableSecretMetaData(obj)
+	if err != nil || meta.Destination.Transformation == nil {
+		return nil
+	}
+
+	var keys []string
+	for _, ref := range meta.Destination.Transformation.TransformationRefs {
+		keys = append(keys, client.ObjectKey{Namespace: obj.GetNamespace(), Name: ref.Name}.String())
+	}
+	return keys
+}
+
+func indexVaultConnectionRef(obj client.Object) []string {
+	a, ok := obj.(*secretsv1alpha1.VaultAuth)
+	if !ok {
+		return nil
+	}
+
+	connName, err := common.GetConnectionNamespacedName(a)
+	if err != nil {
+		return nil
+	}
+	return []string{connName.String()}
+}
+
+func indexClientCertSecretRef(obj client.Object) []string {
+	c, ok := obj.(*secretsv1alpha1.VaultConnection)
+	if !ok || c.Spec.ClientCertSecretRef == "" {
+		return nil
+	}
+
+	return []string{client.ObjectKey{Namespace: c.Namespace, Name: c.Spec.ClientCertSecretRef}.String()}
+}
+
+// watchDependencies watches the VaultAuth, VaultConnection, and SecretTransformation resources, any change
+// to their spec enqueues every object in the list returned by newList that depends on them.
+func watchDependencies(b *builder.Builder, c client.Client, newList func() client.ObjectList) *builder.Builder {
+	return b.
+		Watches(&source.Kind{Type: &secretsv1alpha1.VaultAuth{}},
+			handler.EnqueueRequestsFromMapFunc(mapVaultAuthToDependents(c, newList)),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &secretsv1alpha1.VaultConnection{}},
+			handler.EnqueueRequestsFromMapFunc(mapVaultConnectionToDependents(c, newList)),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &secretsv1alpha1.SecretTransformation{}},
+			handler.EnqueueRequestsFromMapFunc(mapSecretTransformationToDependents(c, newList)),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+}
+
+// mapVaultAuthToDependents returns a handler.MapFunc that maps a VaultAuth to the objects that reference it.
+func mapVaultAuthToDependents(c client.Client, newList func() client.ObjectList) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		return findDependents(context.Background(), c, newList, vaultAuthRefIndex, client.ObjectKeyFromObject(obj))
+	}
+}
+
+// mapSecretTransformationToDependents returns a handler.MapFunc that maps a SecretTransformation to the
+// objects that reference it.
+func mapSecretTransformationToDependents(c client.Client, newList func() client.ObjectList) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		return findDependents(context.Background(), c, newList, transformationRefIndex, client.ObjectKeyFromObject(obj))
+	}
+}
+
+// mapVaultConnectionToDependents returns a handler.MapFunc that maps a VaultConnection to the objects that
+// reference any of the VaultAuths which reference it.
+func mapVaultConnectionToDependents(c client.Client, newList func() client.ObjectList) handler.MapFunc {
+	return func(obj client.Object) []reconcile.Request {
+		ctx := context.Background()
+		logger := log.FromContext(ctx).WithValues("vaultConnection", client.ObjectKeyFromObject(obj))
+
+		var auths secretsv1alpha1.VaultAuthList
+		if err := c.List(ctx, &auths, client.MatchingFields{
+			vaultConnectionRefIndex: client.ObjectKeyFromObject(obj).String(),
+		}); err != nil {
+			logger.Error(err, "Failed to list the VaultAuth resources")
+			return nil
+		}
+
+		var requests []reconcile.Request
+		for _, a := range auths.Items {
+			requests = append(requests, findDependents(ctx, c, newList, vaultAuthRefIndex, client.ObjectKeyFromObject(&a))...)
+		}
+		return requests
+	}
+}
+
+// findDependents returns a reconcile.Request for every object in the list returned by newList whose index
+// field matches key.
+func findDependents(ctx context.Context, c client.Client, newList func() client.ObjectList, index string, key client.ObjectKey) []reconcile.Request {
+	logger := log.FromContext(ctx).WithValues("index", index, "key", key)
+
+	list := newList()
+	if err := c.List(ctx, list, client.MatchingFields{index: key.String()}); err != nil {
+		logger.Error(err, "Failed to list the dependents")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	if err := meta.EachListItem(list, func(o runtime.Object) error {
+		obj, ok := o.(client.Object)
+		if !ok {
+			return fmt.Errorf("unsupported type %T", o)
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(obj)})
+		return nil
+	}); err != nil {
+		logger.Error(err, "Failed to enqueue the dependents")
+		return nil
+	}
+	return requests
+}
+
+// isVaultAuthChanged returns true if the generation of the VaultAuth that obj references differs from
+// lastAuthGeneration, or if the generation of that VaultAuth's VaultConnection differs from lastConnGeneration,
+// i.e. the ones that were used for the last sync. Each generation is not compared when its last value is unset,
+// and it is always false when the VaultAuth or VaultConnection cannot be found.
+func isVaultAuthChanged(ctx context.Context, c client.Client, obj client.Object, lastAuthGeneration, lastConnGeneration int64) bool {
+	if lastAuthGeneration == 0 && lastConnGeneration == 0 {
+		return false
+	}
+
+	authName, err := common.GetVaultAuthNamespacedName(obj)
+	if err != nil {
+		return false
+	}
+
+	a, err := common.GetVaultAuth(ctx, c, authName)
+	if err != nil {
+		return false
+	}
+
+	if lastAuthGeneration != 0 && a.Generation != lastAuthGeneration {
+		return true
+	}
+
+	if lastConnGeneration == 0 {
+		return false
+	}
+
+	connName, err := common.GetConnectionNamespacedName(a)
+	if err != nil {
+		return false
+	}
+
+	conn, err := common.GetVaultConnection(ctx, c, connName)
+	if err != nil {
+		return false
+	}
+
+	return conn.Generation != lastConnGeneration
+}
+
+// computeTransformationHash returns the hex encoded SHA-256 of the templates of the Destination.Transformation
+// of obj, including the ones of the referenced SecretTransformations. It is empty if obj has no Transformation.
+func computeTransformationHash(ctx context.Context, c client.Client, obj client.Object) (string, error) {
+	meta, err := helpers.NewSyncableSecretMetaData(obj)
+	if err != nil {
+		return "", err
+	}
+
+	t := meta.Destination.Transformation
+	if t == nil {
+		return "", nil
+	}
+
+	templates, err := helpers.GetTransformationTemplates(ctx, c, obj.GetNamespace(), t)
+	if err != nil {
+		return "", err
+	}
+
+	b, err := json.Marshal(templates)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// isTransformationChanged returns true if the templates of the Destination.Transformation of obj differ from
+// the ones that lastHash was computed from, e.g. one of the referenced SecretTransformations was updated. It is
+// always false when the hash cannot be computed, the error is then surfaced by the sync.
+func isTransformationChanged(ctx context.Context, c client.Client, obj client.Object, lastHash string) bool {
+	hash, err := computeTransformationHash(ctx, c, obj)
+	if err != nil {
+		return false
+	}
+	return hash != lastHash
+}
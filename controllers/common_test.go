@@ -0,0 +1,248 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+	"github.com/hashicorp/vault-secrets-operator/internal/common"
+	"github.com/hashicorp/vault-secrets-operator/internal/consts"
+	"github.com/hashicorp/vault-secrets-operator/internal/helpers"
+)
+
+func Test_mapDependents(t *testing.T) {
+	objs := []client.Object{
+		&secretsv1alpha1.VaultAuth{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "auth"},
+			Spec:       secretsv1alpha1.VaultAuthSpec{VaultConnectionRef: "conn"},
+		},
+		&secretsv1alpha1.VaultAuth{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "other"},
+			Spec:       secretsv1alpha1.VaultAuthSpec{VaultConnectionRef: "conn"},
+		},
+		&secretsv1alpha1.VaultAuth{
+			ObjectMeta: metav1.ObjectMeta{Namespace: common.OperatorNamespace, Name: consts.NameDefault},
+		},
+		&secretsv1alpha1.VaultStaticSecret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "vss-auth"},
+			Spec:       secretsv1alpha1.VaultStaticSecretSpec{VaultAuthRef: "auth"},
+		},
+		&secretsv1alpha1.VaultStaticSecret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "vss-other"},
+			Spec:       secretsv1alpha1.VaultStaticSecretSpec{VaultAuthRef: "other"},
+		},
+		&secretsv1alpha1.VaultStaticSecret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "vss-default"},
+		},
+		&secretsv1alpha1.VaultStaticSecret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "elsewhere", Name: "vss-auth"},
+			Spec:       secretsv1alpha1.VaultStaticSecretSpec{VaultAuthRef: "auth"},
+		},
+		&secretsv1alpha1.VaultStaticSecret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "vss-transformed"},
+			Spec: secretsv1alpha1.VaultStaticSecretSpec{
+				VaultAuthRef: "unused",
+				Destination: secretsv1alpha1.Destination{
+					Name: "dest",
+					Transformation: &secretsv1alpha1.Transformation{
+						TransformationRefs: []secretsv1alpha1.TransformationRef{
+							{Name: "jdbc"},
+							{Name: "upper"},
+						},
+					},
+				},
+			},
+		},
+		&secretsv1alpha1.VaultStaticSecret{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "elsewhere", Name: "vss-transformed"},
+			Spec: secretsv1alpha1.VaultStaticSecretSpec{
+				VaultAuthRef: "unused",
+				Destination: secretsv1alpha1.Destination{
+					Name: "dest",
+					Transformation: &secretsv1alpha1.Transformation{
+						TransformationRefs: []secretsv1alpha1.TransformationRef{{Name: "jdbc"}},
+					},
+				},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).
+		WithIndex(&secretsv1alpha1.VaultStaticSecret{}, vaultAuthRefIndex, indexVaultAuthRef).
+		WithIndex(&secretsv1alpha1.VaultAuth{}, vaultConnectionRefIndex, indexVaultConnectionRef).
+		WithIndex(&secretsv1alpha1.VaultStaticSecret{}, transformationRefIndex, indexTransformationRefs).
+		WithObjects(objs...).
+		Build()
+	newList := func() client.ObjectList {
+		return &secretsv1alpha1.VaultStaticSecretList{}
+	}
+
+	tests := []struct {
+		name string
+		obj  client.Object
+		want []reconcile.Request
+	}{
+		{
+			name: "vault-auth",
+			obj:  objs[0],
+			want: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "tenant", Name: "vss-auth"}},
+			},
+		},
+		{
+			name: "default-vault-auth",
+			obj:  objs[2],
+			want: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "tenant", Name: "vss-default"}},
+			},
+		},
+		{
+			name: "vault-connection",
+			obj: &secretsv1alpha1.VaultConnection{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "conn"},
+			},
+			want: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "tenant", Name: "vss-auth"}},
+				{NamespacedName: types.NamespacedName{Namespace: "tenant", Name: "vss-other"}},
+			},
+		},
+		{
+			name: "default-vault-connection",
+			obj: &secretsv1alpha1.VaultConnection{
+				ObjectMeta: metav1.ObjectMeta{Namespace: common.OperatorNamespace, Name: consts.NameDefault},
+			},
+			want: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "tenant", Name: "vss-default"}},
+			},
+		},
+		{
+			name: "unreferenced",
+			obj: &secretsv1alpha1.VaultConnection{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "unused"},
+			},
+		},
+		{
+			name: "secret-transformation",
+			obj: &secretsv1alpha1.SecretTransformation{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "tenant", Name: "upper"},
+			},
+			want: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "tenant", Name: "vss-transformed"}},
+			},
+		},
+		{
+			name: "secret-transformation-per-namespace",
+			obj: &secretsv1alpha1.SecretTransformation{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "elsewhere", Name: "jdbc"},
+			},
+			want: []reconcile.Request{
+				{NamespacedName: types.NamespacedName{Namespace: "elsewhere", Name: "vss-transformed"}},
+			},
+		},
+		{
+			name: "unreferenced-secret-transformation",
+			obj: &secretsv1alpha1.SecretTransformation{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "elsewhere", Name: "upper"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []reconcile.Request
+			switch tt.obj.(type) {
+			case *secretsv1alpha1.VaultAuth:
+				got = mapVaultAuthToDependents(c, newList)(tt.obj)
+			case *secretsv1alpha1.VaultConnection:
+				got = mapVaultConnectionToDependents(c, newList)(tt.obj)
+			case *secretsv1alpha1.SecretTransformation:
+				got = mapSecretTransformationToDependents(c, newList)(tt.obj)
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
+
+// ownedSecretsCache is a cache.Cache that only holds the Secrets of its reader that have the helpers.OwnerLabels,
+// like the Manager's cache.
+type ownedSecretsCache struct {
+	cache.Cache
+	reader client.Reader
+}
+
+func (c *ownedSecretsCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	if err := c.reader.Get(ctx, key, obj, opts...); err != nil {
+		return err
+	}
+	if !labels.SelectorFromSet(helpers.OwnerLabels).Matches(labels.Set(obj.GetLabels())) {
+		return apierrors.NewNotFound(corev1.Resource("secrets"), key.Name)
+	}
+	return nil
+}
+
+func Test_isDestinationDrifted_secretsReader(t *testing.T) {
+	ctx := context.Background()
+	newObj := func(create bool) *secretsv1alpha1.VaultDynamicSecret {
+		return &secretsv1alpha1.VaultDynamicSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "vds",
+				Namespace: "default",
+			},
+			Spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Destination: secretsv1alpha1.Destination{
+					Name:   "dest",
+					Create: create,
+				},
+			},
+		}
+	}
+
+	o := newObj(true)
+	withDest := fake.NewClientBuilder().WithObjects(newTestDestSecret(o)).Build()
+	withoutDest := fake.NewClientBuilder().Build()
+
+	// the destination Secret is read from the secrets reader, never from the client.
+	drifted, err := isDestinationDrifted(ctx, withDest, withoutDest, o, nil, "")
+	require.NoError(t, err)
+	assert.True(t, drifted)
+
+	drifted, err = isDestinationDrifted(ctx, withoutDest, withDest, o, nil, "")
+	require.NoError(t, err)
+	assert.False(t, drifted)
+
+	assert.Equal(t, withDest, destinationSecretsReader(withDest, nil, o))
+	secretsCache := &ownedSecretsCache{reader: withDest}
+	assert.Equal(t, secretsCache, destinationSecretsReader(withDest, secretsCache, o))
+
+	// a destination Secret that is not created by the Operator does not have the owner labels, so it is not in
+	// the cache, and must be read from the client.
+	o = newObj(false)
+	dest := newTestDestSecret(o)
+	dest.Labels = nil
+	c := fake.NewClientBuilder().WithObjects(dest).Build()
+	secretsCache = &ownedSecretsCache{reader: c}
+
+	drifted, err = isDestinationDrifted(ctx, c, secretsCache, o, nil, "")
+	require.NoError(t, err)
+	assert.True(t, drifted, "the unlabelled Secret must not be found in the cache")
+
+	reader := destinationSecretsReader(c, secretsCache, o)
+	assert.Equal(t, c, reader)
+	drifted, err = isDestinationDrifted(ctx, c, reader, o, nil, "")
+	require.NoError(t, err)
+	assert.False(t, drifted)
+}
@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/vault-secrets-operator/internal/consts"
+)
+
+// setCondition of condType on conditions for the observed generation. The condition's status is True if ok is true.
+func setCondition(conditions *[]metav1.Condition, generation int64, condType string, ok bool, reason, message string) {
+	status := metav1.ConditionFalse
+	if ok {
+		status = metav1.ConditionTrue
+	}
+
+	meta.SetStatusCondition(conditions, metav1.Condition{
+		Type:               condType,
+		Status:             status,
+		ObservedGeneration: generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// setReadyCondition on conditions for the observed generation. The Ready condition is True only when all
+// conditions of condTypes are True for the same generation, otherwise it takes on the reason and message
+// of the first condition that is False.
+func setReadyCondition(conditions *[]metav1.Condition, generation int64, condTypes ...string) {
+	var reason, message, unobserved string
+	for _, condType := range condTypes {
+		cond := meta.FindStatusCondition(*conditions, condType)
+		if cond == nil || cond.ObservedGeneration != generation {
+			if unobserved == "" {
+				unobserved = condType
+			}
+			continue
+		}
+
+		if cond.Status != metav1.ConditionTrue {
+			setCondition(conditions, generation, consts.ConditionTypeReady, false, cond.Reason, cond.Message)
+			return
+		}
+		reason, message = cond.Reason, cond.Message
+	}
+
+	if unobserved != "" {
+		setCondition(conditions, generation, consts.ConditionTypeReady, false,
+			consts.ReasonReconciling, unobserved+" condition not yet observed")
+		return
+	}
+
+	setCondition(conditions, generation, consts.ConditionTypeReady, true, reason, message)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/hashicorp/vault-secrets-operator/internal/consts"
+)
+
+func Test_setReadyCondition(t *testing.T) {
+	newCond := func(condType string, status metav1.ConditionStatus, generation int64, reason string) metav1.Condition {
+		return metav1.Condition{
+			Type:               condType,
+			Status:             status,
+			ObservedGeneration: generation,
+			Reason:             reason,
+			Message:            reason + " message",
+		}
+	}
+
+	tests := []struct {
+		name        string
+		conditions  []metav1.Condition
+		wantStatus  metav1.ConditionStatus
+		wantReason  string
+		wantMessage string
+	}{
+		{
+			name: "all-true",
+			conditions: []metav1.Condition{
+				newCond(consts.ConditionTypeAuthValid, metav1.ConditionTrue, 2, consts.ReasonAccepted),
+				newCond(consts.ConditionTypeSynced, metav1.ConditionTrue, 2, consts.ReasonSecretSynced),
+			},
+			wantStatus:  metav1.ConditionTrue,
+			wantReason:  consts.ReasonSecretSynced,
+			wantMessage: "SecretSynced message",
+		},
+		{
+			name: "one-false",
+			conditions: []metav1.Condition{
+				newCond(consts.ConditionTypeAuthValid, metav1.ConditionTrue, 2, consts.ReasonAccepted),
+				newCond(consts.ConditionTypeSynced, metav1.ConditionFalse, 2, consts.ReasonSecretSyncError),
+			},
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  consts.ReasonSecretSyncError,
+			wantMessage: "SecretSyncError message",
+		},
+		{
+			name: "false-before-unobserved",
+			conditions: []metav1.Condition{
+				newCond(consts.ConditionTypeSynced, metav1.ConditionFalse, 2, consts.ReasonInvalidConfiguration),
+			},
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  consts.ReasonInvalidConfiguration,
+			wantMessage: "InvalidConfiguration message",
+		},
+		{
+			name: "unobserved",
+			conditions: []metav1.Condition{
+				newCond(consts.ConditionTypeAuthValid, metav1.ConditionTrue, 2, consts.ReasonAccepted),
+			},
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  consts.ReasonReconciling,
+			wantMessage: "Synced condition not yet observed",
+		},
+		{
+			name: "older-generation",
+			conditions: []metav1.Condition{
+				newCond(consts.ConditionTypeAuthValid, metav1.ConditionTrue, 2, consts.ReasonAccepted),
+				newCond(consts.ConditionTypeSynced, metav1.ConditionTrue, 1, consts.ReasonSecretSynced),
+			},
+			wantStatus:  metav1.ConditionFalse,
+			wantReason:  consts.ReasonReconciling,
+			wantMessage: "Synced condition not yet observed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setReadyCondition(&tt.conditions, 2, consts.ConditionTypeAuthValid, consts.ConditionTypeSynced)
+
+			cond := meta.FindStatusCondition(tt.conditions, consts.ConditionTypeReady)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantStatus, cond.Status)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+			assert.Equal(t, tt.wantMessage, cond.Message)
+			assert.Equal(t, int64(2), cond.ObservedGeneration)
+		})
+	}
+}
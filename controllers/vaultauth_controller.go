@@ -109,9 +109,15 @@ func (r *VaultAuthReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 
 	if errs == nil {
 		o.Status.Valid = true
+		o.Status.Error = ""
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, true,
+			consts.ReasonAccepted, "VaultAuth configuration is valid")
 	} else {
 		o.Status.Error = errs.Error()
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, false,
+			consts.ReasonInvalidConfiguration, errs.Error())
 	}
+	setReadyCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid)
 
 	if err := r.updateStatus(ctx, o); err != nil {
 		return ctrl.Result{}, err
@@ -132,6 +138,7 @@ func (r *VaultAuthReconciler) recordEvent(a *secretsv1alpha1.VaultAuth, reason,
 
 func (r *VaultAuthReconciler) updateStatus(ctx context.Context, a *secretsv1alpha1.VaultAuth) error {
 	logger := log.FromContext(ctx)
+	a.Status.ObservedGeneration = a.Generation
 	metrics.SetResourceStatus("vaultauth", a, a.Status.Valid)
 	if err := r.Status().Update(ctx, a); err != nil {
 		logger.Error(err, "Failed to update the resource's status")
@@ -6,18 +6,28 @@ package controllers
 import (
 	"context"
 	"errors"
+	"fmt"
+	"time"
 
 	"github.com/hashicorp/vault-secrets-operator/internal/metrics"
 
+	"github.com/hashicorp/vault/api"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
 	"github.com/hashicorp/vault-secrets-operator/internal/consts"
@@ -32,6 +42,12 @@ type VaultConnectionReconciler struct {
 	Scheme        *runtime.Scheme
 	Recorder      record.EventRecorder
 	ClientFactory vault.CachingClientFactory
+	// HealthProbeInterval is the interval at which the Vault server's health is probed.
+	// Periodic probing is disabled if it is zero.
+	HealthProbeInterval time.Duration
+	// ClientCertSecretsCache holds the Secrets that are watched for client certificate rotation.
+	// Client certificate Secrets are not watched if it is nil.
+	ClientCertSecretsCache cache.Cache
 }
 
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultconnections,verbs=get;list;watch;create;update;patch;delete
@@ -43,7 +59,8 @@ type VaultConnectionReconciler struct {
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;delete;update;patch;deletecollection
 
 // Reconcile reconciles the secretsv1alpha1.VaultConnection resource.
-// Upon a reconciliation it will verify that the configured Vault connection is valid.
+// Upon a reconciliation it will verify that the configured Vault connection is valid,
+// and probe the health of the Vault server. The health is re-probed every HealthProbeInterval.
 //
 // Upon deletion of the resource, it will prune all referent Vault Client(s).
 func (r *VaultConnectionReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
@@ -68,35 +85,51 @@ func (r *VaultConnectionReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return r.handleFinalizer(ctx, o)
 	}
 
+	// the VaultConnection is accepted once per generation.
+	accepted := o.Status.ObservedGeneration != o.Generation
+
 	// assume that status is always invalid
 	o.Status.Valid = false
 
 	vaultConfig := &vault.ClientConfig{
-		CACertSecretRef: o.Spec.CACertSecretRef,
-		K8sNamespace:    o.ObjectMeta.Namespace,
-		Address:         o.Spec.Address,
-		SkipTLSVerify:   o.Spec.SkipTLSVerify,
-		TLSServerName:   o.Spec.TLSServerName,
+		CACertSecretRef:     o.Spec.CACertSecretRef,
+		ClientCertSecretRef: o.Spec.ClientCertSecretRef,
+		K8sNamespace:        o.ObjectMeta.Namespace,
+		Address:             o.Spec.Address,
+		FallbackAddresses:   o.Spec.FallbackAddresses,
+		SkipTLSVerify:       o.Spec.SkipTLSVerify,
+		TLSServerName:       o.Spec.TLSServerName,
 	}
 
 	var errs error
+	if err := r.handleClientCertRotation(ctx, o); err != nil {
+		errs = errors.Join(errs, err)
+	}
+
 	vaultClient, err := vault.MakeVaultClient(ctx, vaultConfig, r.Client)
 	if err != nil {
 		logger.Error(err, "Failed to construct Vault client")
 		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientError, "Failed to construct Vault client: %s", err)
 
+		msg := fmt.Sprintf("Failed to construct Vault client: %s", err)
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeVaultReachable, false,
+			consts.ReasonVaultClientError, msg)
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeHealthy, false,
+			consts.ReasonVaultClientError, msg)
 		errs = errors.Join(errs, err)
 	}
 
 	if vaultClient != nil {
-		if _, err := vaultClient.Sys().SealStatusWithContext(ctx); err != nil {
-			logger.Error(err, "Failed to check Vault seal status, requeuing")
-			r.Recorder.Eventf(o, corev1.EventTypeWarning, "VaultClientError", "Failed to check Vault seal status: %s", err)
-			errs = errors.Join(errs, err)
-		} else {
-			o.Status.Valid = true
+		activeAddress := vaultClient.Address()
+		if activeAddress != o.Spec.Address && activeAddress != o.Status.ActiveAddress {
+			r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientError,
+				"Vault server at %s unavailable, using fallback address %s", o.Spec.Address, activeAddress)
 		}
+		o.Status.ActiveAddress = activeAddress
+		r.probeHealth(ctx, o, vaultClient)
 	}
+	setReadyCondition(&o.Status.Conditions, o.Generation,
+		consts.ConditionTypeVaultReachable, consts.ConditionTypeHealthy)
 
 	// prune old referent Client from the ClientFactory's cache for all older generations of self.
 	// this is a bit of a sledgehammer, not all updated attributes of VaultConnection
@@ -120,10 +153,161 @@ func (r *VaultConnectionReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, errs
 	}
 
-	r.Recorder.Event(o, corev1.EventTypeNormal, consts.ReasonAccepted, "VaultConnection accepted")
-	return ctrl.Result{}, nil
+	if accepted {
+		r.Recorder.Event(o, corev1.EventTypeNormal, consts.ReasonAccepted, "VaultConnection accepted")
+	}
+
+	// periodically re-probe the Vault server's health.
+	return ctrl.Result{RequeueAfter: r.HealthProbeInterval}, nil
+}
+
+// probeHealth of the Vault server, updating the VaultConnection's status and its VaultReachable and
+// Healthy conditions accordingly. The Vault server is considered to be healthy if it is able to
+// service requests, as defined by vault.CheckHealthResponse.
+// An Event is recorded whenever the health of the Vault server changes.
+func (r *VaultConnectionReconciler) probeHealth(ctx context.Context, o *secretsv1alpha1.VaultConnection, vaultClient *api.Client) {
+	logger := log.FromContext(ctx)
+
+	now := metav1.Now()
+	o.Status.LastProbeTime = &now
+	o.Status.Health = nil
+
+	cond := metav1.Condition{
+		Type:               consts.ConditionTypeHealthy,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: o.Generation,
+	}
+
+	// the condition message must not contain the error, since it is likely to differ between probes,
+	// which would result in a new Event being recorded for every probe.
+	resp, err := vaultClient.Sys().HealthWithContext(ctx)
+	if err != nil {
+		logger.Error(err, "Failed to probe Vault health")
+		cond.Reason = consts.ReasonVaultUnreachable
+		cond.Message = fmt.Sprintf("Failed to probe Vault health at %s", vaultClient.Address())
+	} else {
+		o.Status.Health = &secretsv1alpha1.VaultConnectionHealth{
+			Initialized:        resp.Initialized,
+			Sealed:             resp.Sealed,
+			Standby:            resp.Standby,
+			PerformanceStandby: resp.PerformanceStandby,
+			Version:            resp.Version,
+			ClusterName:        resp.ClusterName,
+		}
+
+		healthErr := vault.CheckHealthResponse(resp)
+		switch {
+		case errors.Is(healthErr, vault.ErrVaultUninitialized):
+			cond.Reason = consts.ReasonVaultUninitialized
+			cond.Message = "Vault server is not initialized"
+		case errors.Is(healthErr, vault.ErrVaultSealed):
+			cond.Reason = consts.ReasonVaultSealed
+			cond.Message = "Vault server is sealed"
+		case errors.Is(healthErr, vault.ErrVaultStandby):
+			cond.Reason = consts.ReasonVaultStandby
+			cond.Message = "Vault server is a standby, and unable to service requests"
+		default:
+			cond.Status = metav1.ConditionTrue
+			cond.Reason = consts.ReasonVaultHealthy
+			if resp.PerformanceStandby {
+				cond.Message = "Vault server is a performance standby"
+			} else {
+				cond.Message = "Vault server is active"
+			}
+		}
+	}
+
+	if err != nil {
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeVaultReachable, false,
+			cond.Reason, cond.Message)
+	} else {
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeVaultReachable, true,
+			consts.ReasonVaultHealthy, fmt.Sprintf("Vault server at %s is reachable", vaultClient.Address()))
+	}
+
+	o.Status.Valid = cond.Status == metav1.ConditionTrue
+
+	prev := meta.FindStatusCondition(o.Status.Conditions, consts.ConditionTypeHealthy)
+	if prev == nil || prev.Status != cond.Status || prev.Reason != cond.Reason {
+		eventType := corev1.EventTypeNormal
+		msg := cond.Message
+		if !o.Status.Valid {
+			eventType = corev1.EventTypeWarning
+			if err != nil {
+				msg = fmt.Sprintf("%s: %s", msg, err)
+			}
+		}
+		r.Recorder.Event(o, eventType, cond.Reason, msg)
+	}
+
+	meta.SetStatusCondition(&o.Status.Conditions, cond)
 }
 
+// handleClientCertRotation prunes all referent Vault Client(s) whenever the ClientCertSecretRef Secret
+// has been updated since it was last observed, so that they are recreated with the new client certificate.
+func (r *VaultConnectionReconciler) handleClientCertRotation(ctx context.Context, o *secretsv1alpha1.VaultConnection) error {
+	logger := log.FromContext(ctx)
+
+	var version string
+	if o.Spec.ClientCertSecretRef != "" {
+		s := &corev1.Secret{}
+		if err := r.Client.Get(ctx, client.ObjectKey{
+			Namespace: o.Namespace,
+			Name:      o.Spec.ClientCertSecretRef,
+		}, s); err != nil {
+			logger.Error(err, "Failed to get the client certificate Secret")
+			return err
+		}
+		version = s.ResourceVersion
+	}
+
+	if o.Status.ClientCertSecretVersion != "" && o.Status.ClientCertSecretVersion != version {
+		logger.Info("Client certificate updated, pruning referent Vault clients")
+		if _, err := r.ClientFactory.Prune(ctx, r.Client, o, vault.CachingClientFactoryPruneRequest{
+			FilterFunc:   filterAllCacheRefs,
+			PruneStorage: true,
+		}); err != nil {
+			logger.Error(err, "Failed to prune the Client cache")
+			return err
+		}
+		r.Recorder.Event(o, corev1.EventTypeNormal, consts.ReasonClientCertRotated,
+			"Client certificate updated, Vault clients will be recreated")
+	}
+	o.Status.ClientCertSecretVersion = version
+
+	return nil
+}
+
+// findConnectionsForClientCert returns a reconcile.Request for every VaultConnection
+// whose ClientCertSecretRef references the Secret o.
+func (r *VaultConnectionReconciler) findConnectionsForClientCert(o client.Object) []reconcile.Request {
+	ctx := context.Background()
+	logger := log.FromContext(ctx)
+
+	var list secretsv1alpha1.VaultConnectionList
+	if err := r.Client.List(ctx, &list, client.MatchingFields{
+		clientCertSecretRefIndex: client.ObjectKeyFromObject(o).String(),
+	}); err != nil {
+		logger.Error(err, "Failed to list VaultConnections", "secret", client.ObjectKeyFromObject(o))
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, item := range list.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: client.ObjectKeyFromObject(&item),
+		})
+	}
+
+	return requests
+}
+
+// isTLSSecret filters out all Secrets that are not of type kubernetes.io/tls.
+var isTLSSecret = predicate.NewPredicateFuncs(func(o client.Object) bool {
+	s, ok := o.(*corev1.Secret)
+	return ok && s.Type == corev1.SecretTypeTLS
+})
+
 func (r *VaultConnectionReconciler) addFinalizer(ctx context.Context, o *secretsv1alpha1.VaultConnection) error {
 	if !controllerutil.ContainsFinalizer(o, vaultConnectionFinalizer) {
 		controllerutil.AddFinalizer(o, vaultConnectionFinalizer)
@@ -137,6 +321,7 @@ func (r *VaultConnectionReconciler) addFinalizer(ctx context.Context, o *secrets
 
 func (r *VaultConnectionReconciler) updateStatus(ctx context.Context, o *secretsv1alpha1.VaultConnection) error {
 	logger := log.FromContext(ctx)
+	o.Status.ObservedGeneration = o.Generation
 	metrics.SetResourceStatus("vaultconnection", o, o.Status.Valid)
 	if err := r.Status().Update(ctx, o); err != nil {
 		logger.Error(err, "Failed to update the resource's status")
@@ -165,8 +350,17 @@ func (r *VaultConnectionReconciler) handleFinalizer(ctx context.Context, o *secr
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *VaultConnectionReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&secretsv1alpha1.VaultConnection{}).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
-		Complete(r)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&secretsv1alpha1.VaultConnection{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	if r.ClientCertSecretsCache != nil {
+		// reconcile on client certificate rotation, Secrets do not have a generation,
+		// so they must not be filtered by the GenerationChangedPredicate.
+		// Client certificates are always of type kubernetes.io/tls, all other Secrets are ignored.
+		b = b.Watches(source.NewKindWithCache(&corev1.Secret{}, r.ClientCertSecretsCache),
+			handler.EnqueueRequestsFromMapFunc(r.findConnectionsForClientCert),
+			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}, isTLSSecret))
+	}
+
+	return b.Complete(r)
 }
@@ -0,0 +1,320 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+	"github.com/hashicorp/vault-secrets-operator/internal/consts"
+	"github.com/hashicorp/vault-secrets-operator/internal/vault"
+)
+
+var _ vault.CachingClientFactory = (*stubClientFactory)(nil)
+
+// stubClientFactory records all prune requests, Get always returns client.
+type stubClientFactory struct {
+	pruned []client.Object
+	client vault.Client
+}
+
+func (f *stubClientFactory) Get(_ context.Context, _ client.Client, _ client.Object) (vault.Client, error) {
+	return f.client, nil
+}
+
+func (f *stubClientFactory) Restore(_ context.Context, _ client.Client, _ client.Object) (vault.Client, error) {
+	return nil, nil
+}
+
+func (f *stubClientFactory) RestoreAll(_ context.Context, _ client.Client) error {
+	return nil
+}
+
+func (f *stubClientFactory) Prune(_ context.Context, _ client.Client, obj client.Object, _ vault.CachingClientFactoryPruneRequest) (int, error) {
+	f.pruned = append(f.pruned, obj)
+	return 0, nil
+}
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, secretsv1alpha1.AddToScheme(scheme))
+	return scheme
+}
+
+func TestVaultConnectionReconciler_handleClientCertRotation(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "client-cert",
+			Namespace: "vso",
+		},
+		Type: corev1.SecretTypeTLS,
+	}
+	conn := &secretsv1alpha1.VaultConnection{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default",
+			Namespace: "vso",
+		},
+		Spec: secretsv1alpha1.VaultConnectionSpec{
+			ClientCertSecretRef: "client-cert",
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(secret, conn).Build()
+	factory := &stubClientFactory{}
+	r := &VaultConnectionReconciler{
+		Client:        c,
+		Recorder:      record.NewFakeRecorder(10),
+		ClientFactory: factory,
+	}
+
+	// first observation, nothing to prune
+	require.NoError(t, r.handleClientCertRotation(ctx, conn))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(secret), secret))
+	assert.Equal(t, secret.ResourceVersion, conn.Status.ClientCertSecretVersion)
+	assert.Empty(t, factory.pruned)
+
+	// unchanged Secret
+	require.NoError(t, r.handleClientCertRotation(ctx, conn))
+	assert.Empty(t, factory.pruned)
+
+	// rotated Secret
+	secret.Data = map[string][]byte{corev1.TLSCertKey: []byte("rotated")}
+	require.NoError(t, c.Update(ctx, secret))
+	require.NoError(t, r.handleClientCertRotation(ctx, conn))
+	assert.Equal(t, secret.ResourceVersion, conn.Status.ClientCertSecretVersion)
+	assert.Equal(t, []client.Object{conn}, factory.pruned)
+
+	// missing Secret
+	conn.Spec.ClientCertSecretRef = "other"
+	assert.EqualError(t, r.handleClientCertRotation(ctx, conn), `secrets "other" not found`)
+	assert.Equal(t, secret.ResourceVersion, conn.Status.ClientCertSecretVersion)
+}
+
+func TestVaultConnectionReconciler_findConnectionsForClientCert(t *testing.T) {
+	newConn := func(name, namespace, secretRef string) *secretsv1alpha1.VaultConnection {
+		return &secretsv1alpha1.VaultConnection{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: secretsv1alpha1.VaultConnectionSpec{
+				ClientCertSecretRef: secretRef,
+			},
+		}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(
+		newConn("conn1", "vso", "client-cert"),
+		newConn("conn2", "vso", "other"),
+		newConn("conn3", "vso", ""),
+		newConn("conn4", "tenant", "client-cert"),
+		newConn("conn5", "vso", "client-cert"),
+	).WithIndex(&secretsv1alpha1.VaultConnection{}, clientCertSecretRefIndex, indexClientCertSecretRef).Build()
+	r := &VaultConnectionReconciler{
+		Client: c,
+	}
+
+	got := r.findConnectionsForClientCert(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "client-cert",
+			Namespace: "vso",
+		},
+	})
+	assert.ElementsMatch(t, []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "vso", Name: "conn1"}},
+		{NamespacedName: types.NamespacedName{Namespace: "vso", Name: "conn5"}},
+	}, got)
+}
+
+func Test_isTLSSecret(t *testing.T) {
+	tests := []struct {
+		name string
+		obj  client.Object
+		want bool
+	}{
+		{
+			name: "tls",
+			obj:  &corev1.Secret{Type: corev1.SecretTypeTLS},
+			want: true,
+		},
+		{
+			name: "opaque",
+			obj:  &corev1.Secret{Type: corev1.SecretTypeOpaque},
+		},
+		{
+			name: "not-a-secret",
+			obj:  &corev1.ConfigMap{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, isTLSSecret.Generic(event.GenericEvent{Object: tt.obj}))
+		})
+	}
+}
+
+func TestVaultConnectionReconciler_probeHealth(t *testing.T) {
+	var health string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(health))
+	}))
+	t.Cleanup(server.Close)
+
+	config := api.DefaultConfig()
+	config.Address = server.URL
+	vaultClient, err := api.NewClient(config)
+	require.NoError(t, err)
+
+	recorder := record.NewFakeRecorder(10)
+	r := &VaultConnectionReconciler{
+		Recorder: recorder,
+	}
+	o := &secretsv1alpha1.VaultConnection{
+		ObjectMeta: metav1.ObjectMeta{
+			Generation: 2,
+		},
+	}
+
+	tests := []struct {
+		name       string
+		health     string
+		wantValid  bool
+		wantHealth *secretsv1alpha1.VaultConnectionHealth
+		wantReason string
+		wantEvent  string
+	}{
+		{
+			name:      "active",
+			health:    `{"initialized": true, "sealed": false, "standby": false, "version": "1.13.1", "cluster_name": "vault-cluster"}`,
+			wantValid: true,
+			wantHealth: &secretsv1alpha1.VaultConnectionHealth{
+				Initialized: true,
+				Version:     "1.13.1",
+				ClusterName: "vault-cluster",
+			},
+			wantReason: consts.ReasonVaultHealthy,
+			wantEvent:  "Normal VaultHealthy Vault server is active",
+		},
+		{
+			name:      "unchanged",
+			health:    `{"initialized": true, "sealed": false, "standby": false, "version": "1.13.1", "cluster_name": "vault-cluster"}`,
+			wantValid: true,
+			wantHealth: &secretsv1alpha1.VaultConnectionHealth{
+				Initialized: true,
+				Version:     "1.13.1",
+				ClusterName: "vault-cluster",
+			},
+			wantReason: consts.ReasonVaultHealthy,
+		},
+		{
+			name:      "sealed",
+			health:    `{"initialized": true, "sealed": true, "standby": true, "version": "1.13.1", "cluster_name": "vault-cluster"}`,
+			wantValid: false,
+			wantHealth: &secretsv1alpha1.VaultConnectionHealth{
+				Initialized: true,
+				Sealed:      true,
+				Standby:     true,
+				Version:     "1.13.1",
+				ClusterName: "vault-cluster",
+			},
+			wantReason: consts.ReasonVaultSealed,
+			wantEvent:  "Warning VaultSealed Vault server is sealed",
+		},
+		{
+			name:      "performance-standby",
+			health:    `{"initialized": true, "sealed": false, "standby": true, "performance_standby": true, "version": "1.13.1"}`,
+			wantValid: true,
+			wantHealth: &secretsv1alpha1.VaultConnectionHealth{
+				Initialized:        true,
+				Standby:            true,
+				PerformanceStandby: true,
+				Version:            "1.13.1",
+			},
+			wantReason: consts.ReasonVaultHealthy,
+			wantEvent:  "Normal VaultHealthy Vault server is a performance standby",
+		},
+		{
+			name:      "standby",
+			health:    `{"initialized": true, "sealed": false, "standby": true, "performance_standby": false, "version": "1.13.1"}`,
+			wantValid: false,
+			wantHealth: &secretsv1alpha1.VaultConnectionHealth{
+				Initialized: true,
+				Standby:     true,
+				Version:     "1.13.1",
+			},
+			wantReason: consts.ReasonVaultStandby,
+			wantEvent:  "Warning VaultStandby Vault server is a standby, and unable to service requests",
+		},
+		{
+			name:       "unreachable",
+			health:     `not-json`,
+			wantValid:  false,
+			wantReason: consts.ReasonVaultUnreachable,
+			wantEvent:  "Warning VaultUnreachable Failed to probe Vault health",
+		},
+		{
+			name:       "still-unreachable",
+			health:     `not-json-either`,
+			wantValid:  false,
+			wantReason: consts.ReasonVaultUnreachable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health = tt.health
+			r.probeHealth(context.Background(), o, vaultClient)
+
+			assert.Equal(t, tt.wantValid, o.Status.Valid)
+			assert.Equal(t, tt.wantHealth, o.Status.Health)
+			assert.NotNil(t, o.Status.LastProbeTime)
+
+			cond := meta.FindStatusCondition(o.Status.Conditions, consts.ConditionTypeHealthy)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+			if tt.wantReason == consts.ReasonVaultUnreachable {
+				assert.Equal(t, "Failed to probe Vault health at "+server.URL, cond.Message)
+			}
+			assert.Equal(t, int64(2), cond.ObservedGeneration)
+			if tt.wantValid {
+				assert.Equal(t, metav1.ConditionTrue, cond.Status)
+			} else {
+				assert.Equal(t, metav1.ConditionFalse, cond.Status)
+			}
+
+			reachable := meta.FindStatusCondition(o.Status.Conditions, consts.ConditionTypeVaultReachable)
+			require.NotNil(t, reachable)
+			assert.Equal(t, tt.wantReason != consts.ReasonVaultUnreachable, reachable.Status == metav1.ConditionTrue)
+
+			if tt.wantEvent != "" {
+				require.Len(t, recorder.Events, 1)
+				assert.Contains(t, <-recorder.Events, tt.wantEvent)
+			} else {
+				assert.Len(t, recorder.Events, 0)
+			}
+		})
+	}
+}
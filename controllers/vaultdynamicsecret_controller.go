@@ -5,23 +5,32 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/vault/api"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/retry"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
 	"github.com/hashicorp/vault-secrets-operator/internal/consts"
@@ -31,14 +40,39 @@ import (
 
 const (
 	vaultDynamicSecretFinalizer = "vaultdynamicsecret.secrets.hashicorp.com/finalizer"
+	// defaultRotationPercent is used whenever the VaultDynamicSecret's RotationPercent is not set.
+	defaultRotationPercent = 67
+	// rolloutStatusInterval is the interval at which the rollout status of the RolloutRestartTargets is checked,
+	// while the previous lease is retained after a rotation.
+	rolloutStatusInterval = time.Second * 10
+	// staticCredsRotationDelay is added to a static credential's TTL, so that it is read again shortly after
+	// Vault has rotated it.
+	staticCredsRotationDelay = time.Second * 5
 )
 
+var _ vault.LeaseRenewalHandler = (*VaultDynamicSecretReconciler)(nil)
+
+// errLeaseRotated is returned from within OnRenewal when the renewed lease is no longer the resource's lease.
+var errLeaseRotated = errors.New("lease was rotated")
+
 // VaultDynamicSecretReconciler reconciles a VaultDynamicSecret object
 type VaultDynamicSecretReconciler struct {
 	client.Client
 	Scheme        *runtime.Scheme
 	Recorder      record.EventRecorder
 	ClientFactory vault.ClientFactory
+	// LeaseManager renews the renewable leases when set, in which case the resource is only
+	// reconciled again once its lease can no longer be renewed.
+	LeaseManager vault.LeaseManager
+	// HMACFunc and ValidateMACFunc are used to detect drift in the destination Secret's data,
+	// only its deletion is detected if they are not set.
+	HMACFunc        vault.HMACFromSecretFunc
+	ValidateMACFunc vault.ValidateMACFromSecretFunc
+	// SecretsCache holds the destination Secrets that are watched for drift, in which case a new
+	// secret is synced right away. The destination Secrets are not watched if it is nil.
+	SecretsCache cache.Cache
+	// leaseRotationCh receives the resources whose lease could not be renewed by the LeaseManager.
+	leaseRotationCh chan event.GenericEvent
 	// runtimePodUID should always be set when updating resource's Status.
 	// This is done via the downwardAPI. We get the current Pod's UID from either the
 	// OPERATOR_POD_UID environment variable, or the /var/run/podinfo/uid file; in that order.
@@ -48,6 +82,7 @@ type VaultDynamicSecretReconciler struct {
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultdynamicsecrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultdynamicsecrets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultdynamicsecrets/finalizers,verbs=update
+//+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=secrettransformations,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
 //
@@ -65,6 +100,24 @@ type VaultDynamicSecretReconciler struct {
 // will be re-synced from Vault aka. rotated. If a secret rotation occurs and the resource has
 // RolloutRestartTargets configured, then a request to "rollout restart"
 // the configured Deployment, StatefulSet, ReplicaSet will be made to Kubernetes.
+//
+// Renewable leases are handed off to the LeaseManager if one is configured.
+//
+// Leases that cannot be renewed, either because they are not renewable, or because their
+// renewal was truncated by the role's max TTL, are proactively rotated once RotationPercent
+// of their duration has elapsed.
+//
+// Static credentials, e.g. from the database secrets engine's static roles, have no lease. They are read again
+// shortly after Vault has rotated them, and only trigger a rollout restart if Vault has rotated them since.
+//
+// If RotationGracePeriod is set, then the previous lease is kept after a rotation, and revoked once
+// the grace period has elapsed, or once the rollouts of all RolloutRestartTargets have completed.
+//
+// A new secret is synced whenever the destination Secret is deleted, or its data is changed out-of-band,
+// and whenever the VaultAuth has changed since the last sync.
+//
+// Upon deletion of the resource, the secret's leases will be revoked if Revoke is set,
+// and the destination Secret's data will be cleared if Clear is set.
 func (r *VaultDynamicSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	logger := log.FromContext(ctx)
 
@@ -82,6 +135,7 @@ func (r *VaultDynamicSecretReconciler) Reconcile(ctx context.Context, req ctrl.R
 	o := &secretsv1alpha1.VaultDynamicSecret{}
 	if err := r.Client.Get(ctx, req.NamespacedName, o); err != nil {
 		if apierrors.IsNotFound(err) {
+			r.untrackLease(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 
@@ -89,10 +143,96 @@ func (r *VaultDynamicSecretReconciler) Reconcile(ctx context.Context, req ctrl.R
 		return ctrl.Result{}, err
 	}
 
+	if o.GetDeletionTimestamp() != nil {
+		logger.Info("Got deletion timestamp", "obj", o)
+		return ctrl.Result{}, r.handleDeletion(ctx, o)
+	}
+
+	if err := r.addFinalizer(ctx, o); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	gracePeriod, err := r.getRotationGracePeriod(o)
+	if err != nil {
+		msg := fmt.Sprintf("Failed to parse o.Spec.RotationGracePeriod %s", o.Spec.RotationGracePeriod)
+		logger.Error(err, msg)
+		r.Recorder.Event(o, corev1.EventTypeWarning, consts.ReasonInvalidConfiguration, msg)
+		r.setSyncedCondition(o, false, consts.ReasonInvalidConfiguration, msg)
+		_ = r.updateStatus(ctx, o)
+		return ctrl.Result{}, err
+	}
+
+	result, restarted, err := r.syncOrRenewLease(ctx, o, gracePeriod)
+	if err != nil {
+		return result, err
+	}
+
+	// the previous lease is handled last, since it may have just been replaced by a rotation.
+	horizon, err := r.handlePreviousLease(ctx, o, gracePeriod, restarted)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if horizon > 0 && (result.RequeueAfter == 0 || horizon < result.RequeueAfter) {
+		result.RequeueAfter = horizon
+	}
+
+	return result, nil
+}
+
+// syncOrRenewLease renews the secret's lease, or syncs a new secret from Vault whenever
+// the lease cannot be renewed. The previous lease is retained on rotation if gracePeriod is set.
+// Otherwise, a lease that is replaced because of drift or a VaultAuth change is revoked once the new
+// secret has been synced. It returns true if the RolloutRestartTargets were restarted.
+func (r *VaultDynamicSecretReconciler) syncOrRenewLease(ctx context.Context, o *secretsv1alpha1.VaultDynamicSecret, gracePeriod time.Duration) (ctrl.Result, bool, error) {
 	var doRolloutRestart bool
+	// revokePrevious is set when the lease is replaced while it is still valid.
+	var revokePrevious bool
+	// replacedPreviousID is the PreviousSecretLease that is replaced by a retained lease.
+	var replacedPreviousID string
 	leaseID := o.Status.SecretLease.ID
 	// logger.Info("Last secret lease", "secretLease", o.Status.SecretLease, "epoch", r.epoch)
-	if leaseID != "" {
+	if o.Status.LastRenewalTime > 0 && r.isDestinationDrifted(ctx, o) {
+		// the destination Secret was deleted, or its data was changed, so a new secret is synced from Vault.
+		r.untrackLease(client.ObjectKeyFromObject(o))
+		doRolloutRestart = leaseID != ""
+		revokePrevious = leaseID != ""
+		r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretDrifted,
+			"Destination secret %s drifted, syncing a new secret, lease_id=%s", o.Spec.Destination.Name, leaseID)
+	} else if isVaultAuthChanged(ctx, r.Client, o, o.Status.VaultAuthGeneration, o.Status.VaultConnectionGeneration) {
+		// the secret is synced with the new VaultAuth or VaultConnection, rather than renewing a lease that
+		// may have been revoked along with the previous Vault token, or that belongs to another Vault server.
+		r.untrackLease(client.ObjectKeyFromObject(o))
+		doRolloutRestart = leaseID != ""
+		revokePrevious = leaseID != ""
+		r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonVaultAuthChanged,
+			"VaultAuth or VaultConnection changed since the last sync, syncing a new secret, lease_id=%s", leaseID)
+	} else if isTransformationChanged(ctx, r.Client, o, o.Status.TransformationHash) {
+		// the destination Secret is rendered from the templates of a SecretTransformation that has changed
+		// since the last sync, the secret data is not retained, so a new secret is synced from Vault.
+		r.untrackLease(client.ObjectKeyFromObject(o))
+		doRolloutRestart = leaseID != ""
+		revokePrevious = leaseID != ""
+		r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonTransformationChanged,
+			"Transformation changed since the last sync, syncing a new secret, lease_id=%s", leaseID)
+	} else if leaseID != "" && o.Status.ObservedGeneration == o.Generation && r.isLeaseTracked(o) {
+		// the lease is renewed by the LeaseManager, e.g. the resource was enqueued by an update to its
+		// destination Secret.
+		return ctrl.Result{}, false, nil
+	} else if leaseID == "" && o.Status.StaticCredsMetaData != nil && o.Status.ObservedGeneration == o.Generation {
+		// the static credential is only read again once Vault has rotated it.
+		if horizon := r.getStaticCredsHorizon(o); horizon > 0 {
+			return ctrl.Result{RequeueAfter: horizon}, false, nil
+		}
+	} else if leaseID != "" && !o.Status.SecretLease.Renewable {
+		// the lease cannot be renewed, so the secret is rotated before the lease expires.
+		r.untrackLease(client.ObjectKeyFromObject(o))
+		if horizon := r.getRotationHorizon(o); horizon > 0 {
+			return ctrl.Result{RequeueAfter: horizon}, false, nil
+		}
+		doRolloutRestart = true
+		r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretLeaseRenewal,
+			"Rotating non-renewable lease, lease_id=%s", leaseID)
+	} else if leaseID != "" {
 		if r.runtimePodUID != "" && r.runtimePodUID != o.Status.LastRuntimePodUID {
 			// don't take part in the thundering herd on start up,
 			// and the lease is still within the renewal window.
@@ -103,12 +243,12 @@ func (r *VaultDynamicSecretReconciler) Reconcile(ctx context.Context, req ctrl.R
 			if diff > 0 {
 				horizon := computeHorizonWithJitter(time.Duration(diff) * time.Second)
 				if err := r.updateStatus(ctx, o); err != nil {
-					return ctrl.Result{}, err
+					return ctrl.Result{}, false, err
 				}
 				r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretLeaseRenewal,
 					"Not in renewal window after transitioning to a new leader/pod, lease_id=%s, horizon=%s",
 					leaseID, horizon)
-				return ctrl.Result{RequeueAfter: horizon}, nil
+				return r.requeueForRenewal(o, horizon), false, nil
 
 			}
 		}
@@ -117,25 +257,50 @@ func (r *VaultDynamicSecretReconciler) Reconcile(ctx context.Context, req ctrl.R
 		if err != nil {
 			r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientConfigError,
 				"Failed to get Vault client: %s, lease_id=%s", err, leaseID)
-			return ctrl.Result{}, err
+			r.setAuthValidCondition(o, err)
+			_ = r.updateStatus(ctx, o)
+			return ctrl.Result{}, false, err
 		}
+		r.setAuthValidCondition(o, nil)
 
 		if secretLease, err := r.renewLease(ctx, vClient, o); err == nil {
-			if !r.isRenewableLease(secretLease, o) {
-				return ctrl.Result{}, nil
-			}
-
 			if secretLease.ID != leaseID {
 				// the new lease ID does not match, this should never happen.
 				err := fmt.Errorf("lease ID changed after renewal, expected=%s, actual=%s", leaseID, secretLease.ID)
 				r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonSecretLeaseRenewal, err.Error())
-				return ctrl.Result{}, err
+				r.setSyncedCondition(o, false, consts.ReasonSecretLeaseRenewalError, err.Error())
+				_ = r.updateStatus(ctx, o)
+				return ctrl.Result{}, false, err
+			}
+
+			// the lease has reached the role's max TTL when the renewal is truncated, since it cannot be
+			// extended any further, it is treated as non-renewable, and will be rotated instead.
+			requested := o.Status.SecretLease.LeaseDuration
+			truncated := secretLease.LeaseDuration < requested
+			if truncated {
+				secretLease.Renewable = false
 			}
 
 			o.Status.SecretLease = *secretLease
 			o.Status.LastRenewalTime = time.Now().Unix()
+			r.setSyncedCondition(o, true, consts.ReasonSecretLeaseRenewal,
+				fmt.Sprintf("Renewed lease, lease_id=%s", leaseID))
 			if err := r.updateStatus(ctx, o); err != nil {
-				return ctrl.Result{}, err
+				return ctrl.Result{}, false, err
+			}
+
+			if !secretLease.Renewable {
+				r.untrackLease(client.ObjectKeyFromObject(o))
+				horizon := r.getRotationHorizon(o)
+				if truncated {
+					r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretLeaseRenewal,
+						"Lease renewal truncated from %ds to %ds, lease_id=%s, rotation_horizon=%s",
+						requested, secretLease.LeaseDuration, leaseID, horizon)
+				} else {
+					r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretLeaseRenewal,
+						"Lease is no longer renewable, lease_id=%s, rotation_horizon=%s", leaseID, horizon)
+				}
+				return ctrl.Result{RequeueAfter: horizon}, false, nil
 			}
 
 			leaseDuration := time.Duration(secretLease.LeaseDuration) * time.Second
@@ -148,7 +313,7 @@ func (r *VaultDynamicSecretReconciler) Reconcile(ctx context.Context, req ctrl.R
 			r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretLeaseRenewal,
 				"Renewed lease, lease_id=%s, horizon=%s", leaseID, horizon)
 
-			return ctrl.Result{RequeueAfter: horizon}, nil
+			return r.requeueForRenewal(o, horizon), false, nil
 		} else {
 			doRolloutRestart = true
 			if !isLeaseNotfoundError(err) {
@@ -162,30 +327,87 @@ func (r *VaultDynamicSecretReconciler) Reconcile(ctx context.Context, req ctrl.R
 	if err != nil {
 		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientConfigError,
 			"Failed to get Vault client: %s, lease_id=%s", err, leaseID)
-		return ctrl.Result{}, err
+		r.setAuthValidCondition(o, err)
+		_ = r.updateStatus(ctx, o)
+		return ctrl.Result{}, false, err
 	}
+	r.setAuthValidCondition(o, nil)
 
-	secretLease, err := r.syncSecret(ctx, vClient, o)
+	secretLease, staticCredsMetaData, err := r.syncSecret(ctx, vClient, o)
 	if err != nil {
-		return ctrl.Result{}, err
+		r.setSyncedCondition(o, false, consts.ReasonSecretSyncError,
+			fmt.Sprintf("Failed to sync the secret: %s", err))
+		_ = r.updateStatus(ctx, o)
+		return ctrl.Result{}, false, err
 	}
 
-	o.Status.SecretLease = *secretLease
-	o.Status.LastRenewalTime = time.Now().Unix()
-	if err := r.updateStatus(ctx, o); err != nil {
-		return ctrl.Result{}, err
+	if prev := o.Status.StaticCredsMetaData; prev != nil && staticCredsMetaData != nil &&
+		prev.LastVaultRotation != staticCredsMetaData.LastVaultRotation {
+		doRolloutRestart = true
 	}
+	o.Status.StaticCredsMetaData = staticCredsMetaData
 
 	reason := consts.ReasonSecretSynced
 	if doRolloutRestart {
 		reason = consts.ReasonSecretRotated
+		if gracePeriod > 0 && leaseID != "" {
+			replacedPreviousID = r.retainPreviousLease(ctx, vClient, o, gracePeriod)
+			revokePrevious = false
+		}
+	}
+
+	if doRolloutRestart {
 		// rollout-restart errors are not retryable
 		// all error reporting is handled by helpers.HandleRolloutRestarts
-		_ = helpers.HandleRolloutRestarts(ctx, r.Client, o, r.Recorder)
+		// the restarted targets are recorded, so that the previous lease is only revoked once their
+		// rollouts have completed.
+		o.Status.RolloutRestartTargets, _ = helpers.HandleRolloutRestarts(ctx, r.Client, o, r.Recorder)
+	}
+
+	o.Status.SecretLease = *secretLease
+	o.Status.LastRenewalTime = time.Now().Unix()
+	o.Status.VaultAuthGeneration = vClient.GetVaultAuthObj().Generation
+	o.Status.VaultConnectionGeneration = vClient.GetVaultConnectionObj().Generation
+	r.setSyncedCondition(o, true, reason, fmt.Sprintf("Secret synced, lease_id=%s", secretLease.ID))
+	if err := r.updateStatus(ctx, o); err != nil {
+		return ctrl.Result{}, doRolloutRestart, err
+	}
+
+	// the replaced leases are only revoked once the status no longer references them, and references the
+	// new lease instead, otherwise the new lease would be leaked, and the status would reference a revoked lease.
+	if revokePrevious && leaseID != secretLease.ID {
+		if err := r.revokeLease(ctx, vClient, o, leaseID); err != nil {
+			// the lease will expire on its own, so there is no need to fail the sync.
+			log.FromContext(ctx).Info("Abandoning replaced lease", "lease_id", leaseID)
+		}
+	}
+	if replacedPreviousID != "" {
+		if err := r.revokeLease(ctx, vClient, o, replacedPreviousID); err != nil {
+			// the lease will expire on its own, so there is no need to fail the sync.
+			log.FromContext(ctx).Info("Abandoning previous lease", "lease_id", replacedPreviousID)
+		}
 	}
 
-	if !r.isRenewableLease(secretLease, o) {
-		return ctrl.Result{}, nil
+	if staticCredsMetaData != nil {
+		horizon := r.getStaticCredsHorizon(o)
+		r.Recorder.Eventf(o, corev1.EventTypeNormal, reason,
+			"Secret synced, static credential last rotated at %s, horizon=%s",
+			time.Unix(staticCredsMetaData.LastVaultRotation, 0).UTC().Format(time.RFC3339), horizon)
+		return ctrl.Result{RequeueAfter: horizon}, doRolloutRestart, nil
+	}
+
+	if !secretLease.Renewable {
+		r.untrackLease(client.ObjectKeyFromObject(o))
+		if secretLease.LeaseDuration <= 0 {
+			r.Recorder.Eventf(o, corev1.EventTypeNormal, reason,
+				"Secret synced, lease_id=%s, lease is not renewable and has no duration", secretLease.ID)
+			return ctrl.Result{}, doRolloutRestart, nil
+		}
+
+		horizon := r.getRotationHorizon(o)
+		r.Recorder.Eventf(o, corev1.EventTypeNormal, reason,
+			"Secret synced, lease_id=%s, lease is not renewable, rotation_horizon=%s", secretLease.ID, horizon)
+		return ctrl.Result{RequeueAfter: horizon}, doRolloutRestart, nil
 	}
 
 	leaseDuration := time.Duration(secretLease.LeaseDuration) * time.Second
@@ -193,53 +415,311 @@ func (r *VaultDynamicSecretReconciler) Reconcile(ctx context.Context, req ctrl.R
 	r.Recorder.Eventf(o, corev1.EventTypeNormal, reason,
 		"Secret synced, lease_id=%s, horizon=%s", secretLease.ID, horizon)
 
-	return ctrl.Result{RequeueAfter: horizon}, nil
+	return r.requeueForRenewal(o, horizon), doRolloutRestart, nil
 }
 
-func (r *VaultDynamicSecretReconciler) isRenewableLease(resp *secretsv1alpha1.VaultSecretLease, o *secretsv1alpha1.VaultDynamicSecret) bool {
-	if !resp.Renewable {
-		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonSecretLeaseRenewal,
-			"Lease is not renewable, info=%#v", resp)
-		return false
+// requeueForRenewal returns the result for a resource with a renewable lease. The lease is tracked by the
+// LeaseManager if one is configured, otherwise the resource is requeued to renew its lease after horizon.
+func (r *VaultDynamicSecretReconciler) requeueForRenewal(o *secretsv1alpha1.VaultDynamicSecret, horizon time.Duration) ctrl.Result {
+	if r.LeaseManager == nil {
+		return ctrl.Result{RequeueAfter: horizon}
 	}
-	return true
+
+	r.LeaseManager.Track(client.ObjectKeyFromObject(o), o.Status.SecretLease, time.Unix(o.Status.LastRenewalTime, 0))
+	return ctrl.Result{}
+}
+
+// isLeaseTracked returns true if the LeaseManager renews the lease of o.
+func (r *VaultDynamicSecretReconciler) isLeaseTracked(o *secretsv1alpha1.VaultDynamicSecret) bool {
+	return r.LeaseManager != nil && r.LeaseManager.IsTracked(client.ObjectKeyFromObject(o))
 }
 
-func (r *VaultDynamicSecretReconciler) syncSecret(ctx context.Context, vClient vault.Client, o *secretsv1alpha1.VaultDynamicSecret) (*secretsv1alpha1.VaultSecretLease, error) {
-	path := fmt.Sprintf("%s/creds/%s", o.Spec.Mount, o.Spec.Role)
-	resp, err := vClient.Read(ctx, path)
+// isDestinationDrifted returns true if the destination Secret of o was deleted, or if its data has changed
+// since it was last synced. Errors are logged and treated as no drift.
+func (r *VaultDynamicSecretReconciler) isDestinationDrifted(ctx context.Context, o *secretsv1alpha1.VaultDynamicSecret) bool {
+	drifted, err := isDestinationDrifted(ctx, r.Client, destinationSecretsReader(r.Client, r.SecretsCache, o), o,
+		r.ValidateMACFunc, o.Status.SecretMAC)
 	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to check the destination secret for drift")
+		return false
+	}
+
+	return drifted
+}
+
+// untrackLease stops the LeaseManager from renewing the lease of the resource identified by key.
+func (r *VaultDynamicSecretReconciler) untrackLease(key client.ObjectKey) {
+	if r.LeaseManager != nil {
+		r.LeaseManager.Untrack(key)
+	}
+}
+
+// GetClient implements vault.LeaseRenewalHandler.
+func (r *VaultDynamicSecretReconciler) GetClient(ctx context.Context, key client.ObjectKey) (vault.Client, error) {
+	o := &secretsv1alpha1.VaultDynamicSecret{}
+	if err := r.Client.Get(ctx, key, o); err != nil {
 		return nil, err
 	}
 
-	if resp == nil {
-		return nil, fmt.Errorf("nil response from vault for path %s", path)
+	return r.ClientFactory.Get(ctx, r.Client, o)
+}
+
+// OnRenewal implements vault.LeaseRenewalHandler. It records the renewed lease in the resource's status,
+// unless the secret was rotated in the meantime.
+func (r *VaultDynamicSecretReconciler) OnRenewal(ctx context.Context, key client.ObjectKey, lease secretsv1alpha1.VaultSecretLease) error {
+	o := &secretsv1alpha1.VaultDynamicSecret{}
+	// the renewal is recorded on the latest version of the resource, retrying on conflict, so that it is
+	// not lost to a concurrent reconciliation. The ObservedGeneration is left as is, since the resource's
+	// spec has not necessarily been reconciled.
+	if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := r.Client.Get(ctx, key, o); err != nil {
+			return err
+		}
+
+		if o.Status.SecretLease.ID != lease.ID {
+			return errLeaseRotated
+		}
+
+		o.Status.SecretLease = lease
+		o.Status.LastRenewalTime = time.Now().Unix()
+		if r.runtimePodUID != "" {
+			o.Status.LastRuntimePodUID = r.runtimePodUID
+		}
+		r.setSyncedCondition(o, true, consts.ReasonSecretLeaseRenewal,
+			fmt.Sprintf("Renewed lease, lease_id=%s", lease.ID))
+		return r.Status().Update(ctx, o)
+	}); err != nil {
+		if errors.Is(err, errLeaseRotated) {
+			return nil
+		}
+		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonStatusUpdateError,
+			"Failed to record the lease renewal, lease_id=%s, err=%s", lease.ID, err)
+		return err
+	}
+
+	r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretLeaseRenewal,
+		"Renewed lease, lease_id=%s", lease.ID)
+	return nil
+}
+
+// OnRotationNeeded implements vault.LeaseRenewalHandler. It enqueues the resource for reconciliation,
+// which renews or rotates its secret.
+func (r *VaultDynamicSecretReconciler) OnRotationNeeded(ctx context.Context, key client.ObjectKey) {
+	o := &secretsv1alpha1.VaultDynamicSecret{}
+	o.SetNamespace(key.Namespace)
+	o.SetName(key.Name)
+	select {
+	case r.leaseRotationCh <- event.GenericEvent{Object: o}:
+	case <-ctx.Done():
+	}
+}
+
+// getRotationHorizon returns the duration until the secret should be rotated, that being RotationPercent
+// of the lease's duration since it was last renewed. The rotation is due if the result is not positive.
+func (r *VaultDynamicSecretReconciler) getRotationHorizon(o *secretsv1alpha1.VaultDynamicSecret) time.Duration {
+	percent := o.Spec.RotationPercent
+	if percent <= 0 {
+		percent = defaultRotationPercent
+	}
+
+	leaseDuration := time.Duration(o.Status.SecretLease.LeaseDuration) * time.Second
+	rotateAt := time.Unix(o.Status.LastRenewalTime, 0).Add(leaseDuration * time.Duration(percent) / 100)
+	return time.Until(rotateAt)
+}
+
+// getStaticCredsHorizon returns the duration until the static credential should be read again,
+// that being shortly after its next rotation by Vault.
+func (r *VaultDynamicSecretReconciler) getStaticCredsHorizon(o *secretsv1alpha1.VaultDynamicSecret) time.Duration {
+	ttl := time.Duration(o.Status.StaticCredsMetaData.TTL) * time.Second
+	return time.Until(time.Unix(o.Status.LastRenewalTime, 0).Add(ttl + staticCredsRotationDelay))
+}
+
+// syncSecret from Vault to the destination Secret. It returns the secret's lease, along with its
+// VaultStaticCredsMetaData if it is a static credential.
+func (r *VaultDynamicSecretReconciler) syncSecret(ctx context.Context, vClient vault.Client, o *secretsv1alpha1.VaultDynamicSecret) (*secretsv1alpha1.VaultSecretLease, *secretsv1alpha1.VaultStaticCredsMetaData, error) {
+	path, err := getDynamicSecretPath(o.Spec.Mount, o.Spec.Path, o.Spec.Role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := requestDynamicSecret(ctx, vClient, path,
+		getDynamicSecretRequestMethod(o.Spec.RequestHTTPMethod, o.Spec.Params), o.Spec.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	staticCredsMetaData, err := r.getStaticCredsMetaData(resp)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	data, err := vault.MarshalSecretData(resp)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+
+	transformationHash, err := computeTransformationHash(ctx, r.Client, o)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err = helpers.TransformSecretData(ctx, r.Client, o, data, resp.Data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var secretMAC string
+	if r.HMACFunc != nil {
+		if secretMAC, err = computeSecretMAC(ctx, r.Client, r.HMACFunc, data); err != nil {
+			return nil, nil, err
+		}
 	}
 
 	if err := helpers.SyncSecret(ctx, r.Client, o, data); err != nil {
+		return nil, nil, err
+	}
+	o.Status.SecretMAC = secretMAC
+	o.Status.TransformationHash = transformationHash
+
+	return getVaultSecretLease(resp), staticCredsMetaData, nil
+}
+
+// getDynamicSecretPath in Vault to request the credentials from, relative to mount. The path takes precedence
+// over the role shorthand.
+func getDynamicSecretPath(mount, path, role string) (string, error) {
+	switch {
+	case path != "":
+		return fmt.Sprintf("%s/%s", mount, strings.TrimPrefix(path, "/")), nil
+	case role != "":
+		return fmt.Sprintf("%s/creds/%s", mount, role), nil
+	default:
+		return "", errors.New("one of path or role must be set")
+	}
+}
+
+// getDynamicSecretRequestMethod for requesting the credentials from Vault. It defaults to GET, or PUT when
+// params are set.
+func getDynamicSecretRequestMethod(method string, params map[string]string) string {
+	switch {
+	case method != "":
+		return method
+	case len(params) > 0:
+		return http.MethodPut
+	default:
+		return http.MethodGet
+	}
+}
+
+// requestDynamicSecret requests the credentials at path from Vault, sending params with the POST and PUT methods.
+func requestDynamicSecret(ctx context.Context, c vault.Client, path, method string, params map[string]string) (*api.Secret, error) {
+	var resp *api.Secret
+	var err error
+	switch method {
+	case http.MethodGet:
+		if len(params) > 0 {
+			return nil, fmt.Errorf("params are not supported with the %s request method", method)
+		}
+		resp, err = c.Read(ctx, path)
+	case http.MethodPost, http.MethodPut:
+		data := make(map[string]any, len(params))
+		for k, v := range params {
+			data[k] = v
+		}
+		resp, err = c.Write(ctx, path, data)
+	default:
+		return nil, fmt.Errorf("unsupported request method %q", method)
+	}
+	if err != nil {
 		return nil, err
 	}
 
-	return r.getVaultSecretLease(resp), nil
+	if resp == nil {
+		return nil, fmt.Errorf("nil response from vault for path %s", path)
+	}
+
+	return resp, nil
+}
+
+// setAuthValidCondition sets the AuthValid condition from the error returned when getting the Vault client,
+// along with the Ready condition which depends on it.
+func (r *VaultDynamicSecretReconciler) setAuthValidCondition(o *secretsv1alpha1.VaultDynamicSecret, err error) {
+	if err != nil {
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, false,
+			consts.ReasonVaultClientConfigError, fmt.Sprintf("Failed to get Vault client: %s", err))
+	} else {
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, true,
+			consts.ReasonAccepted, "Vault auth login succeeded")
+	}
+	setReadyCondition(&o.Status.Conditions, o.Generation,
+		consts.ConditionTypeAuthValid, consts.ConditionTypeSynced)
+}
+
+// setSyncedCondition sets the Synced condition, along with the Ready condition which depends on it.
+func (r *VaultDynamicSecretReconciler) setSyncedCondition(o *secretsv1alpha1.VaultDynamicSecret, ok bool, reason, message string) {
+	setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeSynced, ok, reason, message)
+	setReadyCondition(&o.Status.Conditions, o.Generation,
+		consts.ConditionTypeAuthValid, consts.ConditionTypeSynced)
 }
 
 func (r *VaultDynamicSecretReconciler) updateStatus(ctx context.Context, o *secretsv1alpha1.VaultDynamicSecret) error {
+	o.Status.ObservedGeneration = o.Generation
 	if r.runtimePodUID != "" {
 		o.Status.LastRuntimePodUID = r.runtimePodUID
 	}
 	if err := r.Status().Update(ctx, o); err != nil {
 		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonStatusUpdateError,
 			"Failed to update the resource's status, err=%s", err)
+		return err
 	}
 	return nil
 }
 
-func (r *VaultDynamicSecretReconciler) getVaultSecretLease(resp *api.Secret) *secretsv1alpha1.VaultSecretLease {
+// getStaticCredsMetaData from the response of a static credential, which has no lease, but the time of its last
+// rotation by Vault, and the TTL until its next one. It returns nil if the response is not a static credential.
+func (r *VaultDynamicSecretReconciler) getStaticCredsMetaData(resp *api.Secret) (*secretsv1alpha1.VaultStaticCredsMetaData, error) {
+	if resp.LeaseID != "" || resp.Data == nil {
+		return nil, nil
+	}
+
+	lastVaultRotation, ok := resp.Data["last_vault_rotation"]
+	if !ok {
+		return nil, nil
+	}
+	ttl, ok := resp.Data["ttl"]
+	if !ok {
+		return nil, nil
+	}
+
+	ts, ok := lastVaultRotation.(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid last_vault_rotation %v", lastVaultRotation)
+	}
+	lastRotation, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid last_vault_rotation: %w", err)
+	}
+
+	ttlSeconds, err := parseutil.ParseInt(ttl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ttl: %w", err)
+	}
+
+	var rotationPeriod int64
+	if v, ok := resp.Data["rotation_period"]; ok {
+		if rotationPeriod, err = parseutil.ParseInt(v); err != nil {
+			return nil, fmt.Errorf("invalid rotation_period: %w", err)
+		}
+	}
+
+	return &secretsv1alpha1.VaultStaticCredsMetaData{
+		LastVaultRotation: lastRotation.Unix(),
+		RotationPeriod:    rotationPeriod,
+		TTL:               ttlSeconds,
+	}, nil
+}
+
+// getVaultSecretLease returns the lease of the secret in resp.
+func getVaultSecretLease(resp *api.Secret) *secretsv1alpha1.VaultSecretLease {
 	return &secretsv1alpha1.VaultSecretLease{
 		ID:            resp.LeaseID,
 		LeaseDuration: resp.LeaseDuration,
@@ -257,7 +737,198 @@ func (r *VaultDynamicSecretReconciler) renewLease(ctx context.Context, c vault.C
 		return nil, err
 	}
 
-	return r.getVaultSecretLease(resp), nil
+	return getVaultSecretLease(resp), nil
+}
+
+// handleDeletion revokes the secret's lease and clears the destination Secret, if the resource is configured
+// to do so, before removing the finalizer. The finalizer is kept if either fails, so that it can be retried.
+func (r *VaultDynamicSecretReconciler) handleDeletion(ctx context.Context, o *secretsv1alpha1.VaultDynamicSecret) error {
+	logger := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(o, vaultDynamicSecretFinalizer) {
+		return nil
+	}
+
+	r.untrackLease(client.ObjectKeyFromObject(o))
+
+	if o.Spec.Revoke {
+		var leaseIDs []string
+		if o.Status.SecretLease.ID != "" {
+			leaseIDs = append(leaseIDs, o.Status.SecretLease.ID)
+		}
+		if o.Status.PreviousSecretLease != nil {
+			leaseIDs = append(leaseIDs, o.Status.PreviousSecretLease.ID)
+		}
+
+		if len(leaseIDs) > 0 {
+			c, err := r.ClientFactory.Get(ctx, r.Client, o)
+			if err != nil {
+				logger.Error(err, "Failed to get Vault client")
+				r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientConfigError,
+					"Failed to get Vault client: %s", err)
+				return err
+			}
+
+			for _, leaseID := range leaseIDs {
+				if err := r.revokeLease(ctx, c, o, leaseID); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// the destination Secret is owned by the resource when it was created by the Operator,
+	// in which case it will be garbage collected along with the resource.
+	if o.Spec.Clear && !o.Spec.Destination.Create {
+		if err := r.clearSecretData(ctx, o); err != nil {
+			logger.Error(err, "Failed to clear the destination secret")
+			r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonSecretSyncError,
+				"Failed to clear the destination secret: %s", err)
+			return err
+		}
+	}
+
+	if controllerutil.RemoveFinalizer(o, vaultDynamicSecretFinalizer) {
+		if err := r.Update(ctx, o); err != nil {
+			logger.Error(err, "Failed to remove the finalizer")
+			return err
+		}
+	}
+
+	return nil
+}
+
+// clearSecretData of the destination Secret, there is nothing to clear if it no longer exists.
+func (r *VaultDynamicSecretReconciler) clearSecretData(ctx context.Context, o *secretsv1alpha1.VaultDynamicSecret) error {
+	exists, err := helpers.CheckSecretExists(ctx, r.Client, o)
+	if err != nil || !exists {
+		return err
+	}
+
+	return helpers.SyncSecret(ctx, r.Client, o, nil)
+}
+
+// revokeLease in Vault, recording an Event for the outcome. A lease that is no longer known to Vault
+// is considered revoked.
+func (r *VaultDynamicSecretReconciler) revokeLease(ctx context.Context, c vault.Client, o *secretsv1alpha1.VaultDynamicSecret, leaseID string) error {
+	logger := log.FromContext(ctx)
+	if err := revokeVaultLease(ctx, c, leaseID); err != nil {
+		logger.Error(err, "Failed to revoke lease", "lease_id", leaseID)
+		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonSecretLeaseRevokeError,
+			"Failed to revoke lease, lease_id=%s, err=%s", leaseID, err)
+		return err
+	}
+
+	r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretLeaseRevoke,
+		"Revoked lease, lease_id=%s", leaseID)
+	return nil
+}
+
+// revokeVaultLease in Vault. A lease that is no longer known to Vault is considered revoked.
+func revokeVaultLease(ctx context.Context, c vault.Client, leaseID string) error {
+	if _, err := c.Write(ctx, "/sys/leases/revoke", map[string]interface{}{
+		"lease_id": leaseID,
+	}); err != nil && !isLeaseNotfoundError(err) {
+		return err
+	}
+
+	return nil
+}
+
+// getRotationGracePeriod parses the RotationGracePeriod, overlapping rotation is disabled if it is zero.
+func (r *VaultDynamicSecretReconciler) getRotationGracePeriod(o *secretsv1alpha1.VaultDynamicSecret) (time.Duration, error) {
+	if o.Spec.RotationGracePeriod == "" {
+		return 0, nil
+	}
+
+	return time.ParseDuration(o.Spec.RotationGracePeriod)
+}
+
+// retainPreviousLease keeps the lease that is about to be replaced by a rotation as the PreviousSecretLease,
+// renewing it for the gracePeriod, so that the consumers of the previous secret have time to roll over to the
+// new one. It returns the ID of any lease that was retained by an earlier rotation, which the caller must revoke
+// once the status has been updated, or empty if there is none.
+func (r *VaultDynamicSecretReconciler) retainPreviousLease(ctx context.Context, c vault.Client, o *secretsv1alpha1.VaultDynamicSecret, gracePeriod time.Duration) string {
+	var replacedID string
+	if prev := o.Status.PreviousSecretLease; prev != nil {
+		replacedID = prev.ID
+		o.Status.PreviousSecretLease = nil
+	}
+
+	lease := o.Status.SecretLease
+	if lease.Renewable {
+		if _, err := c.Write(ctx, "/sys/leases/renew", map[string]interface{}{
+			"lease_id":  lease.ID,
+			"increment": int(gracePeriod.Seconds()),
+		}); err != nil {
+			if isLeaseNotfoundError(err) {
+				// nothing to retain
+				return replacedID
+			}
+			r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonSecretLeaseRenewalError,
+				"Could not renew the previous lease for the rotation grace period, lease_id=%s, err=%s",
+				lease.ID, err)
+		}
+	}
+
+	o.Status.PreviousSecretLease = &lease
+	o.Status.LastRotationTime = time.Now().Unix()
+
+	return replacedID
+}
+
+// handlePreviousLease revokes the PreviousSecretLease once the gracePeriod has elapsed since the last rotation,
+// or once the rollouts of all RolloutRestartTargets have completed. The rollouts are never checked when the
+// targets were restarted by the current reconciliation, since their status cannot have observed the restart yet.
+// It returns the duration after which the previous lease should be checked again, zero if there is no previous lease.
+func (r *VaultDynamicSecretReconciler) handlePreviousLease(ctx context.Context, o *secretsv1alpha1.VaultDynamicSecret, gracePeriod time.Duration, restarted bool) (time.Duration, error) {
+	logger := log.FromContext(ctx)
+	prev := o.Status.PreviousSecretLease
+	if prev == nil {
+		return 0, nil
+	}
+
+	remaining := time.Until(time.Unix(o.Status.LastRotationTime, 0).Add(gracePeriod))
+	if remaining > 0 && len(o.Spec.RolloutRestartTargets) > 0 {
+		var complete bool
+		if !restarted {
+			var err error
+			complete, err = helpers.RolloutRestartsComplete(ctx, r.Client, o, o.Status.RolloutRestartTargets)
+			if err != nil {
+				logger.Error(err, "Failed to check the rollout status of the RolloutRestartTargets")
+			}
+		}
+		if complete {
+			logger.Info("Rollouts completed, revoking the previous lease", "lease_id", prev.ID)
+			remaining = 0
+		} else if remaining > rolloutStatusInterval {
+			return rolloutStatusInterval, nil
+		}
+	}
+
+	if remaining > 0 {
+		return remaining, nil
+	}
+
+	c, err := r.ClientFactory.Get(ctx, r.Client, o)
+	if err != nil {
+		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientConfigError,
+			"Failed to get Vault client: %s, lease_id=%s", err, prev.ID)
+		return 0, err
+	}
+
+	// the previous lease is only revoked once the status no longer references it.
+	o.Status.PreviousSecretLease = nil
+	if err := r.updateStatus(ctx, o); err != nil {
+		o.Status.PreviousSecretLease = prev
+		return 0, err
+	}
+
+	if err := r.revokeLease(ctx, c, o, prev.ID); err != nil {
+		// the lease will expire on its own, it is no longer referenced by the status.
+		logger.Info("Abandoning previous lease", "lease_id", prev.ID)
+	}
+
+	return 0, nil
 }
 
 func (r *VaultDynamicSecretReconciler) addFinalizer(ctx context.Context, o *secretsv1alpha1.VaultDynamicSecret) error {
@@ -273,10 +944,16 @@ func (r *VaultDynamicSecretReconciler) addFinalizer(ctx context.Context, o *secr
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *VaultDynamicSecretReconciler) SetupWithManager(mgr ctrl.Manager, opts controller.Options) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&secretsv1alpha1.VaultDynamicSecret{}).
+	r.leaseRotationCh = make(chan event.GenericEvent)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&secretsv1alpha1.VaultDynamicSecret{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		WithOptions(opts).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
+		Watches(&source.Channel{Source: r.leaseRotationCh}, &handler.EnqueueRequestForObject{})
+	b = watchDependencies(b, r.Client, func() client.ObjectList {
+		return &secretsv1alpha1.VaultDynamicSecretList{}
+	})
+	return watchDestinationSecrets(b, r.SecretsCache, &secretsv1alpha1.VaultDynamicSecret{}).
 		Complete(r)
 }
 
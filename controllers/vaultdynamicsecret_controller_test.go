@@ -0,0 +1,1507 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+	"github.com/hashicorp/vault-secrets-operator/internal/consts"
+	"github.com/hashicorp/vault-secrets-operator/internal/helpers"
+	"github.com/hashicorp/vault-secrets-operator/internal/vault"
+)
+
+// stubVaultClient records all reads and writes, returning resp for each.
+// Any other vault.Client method will panic.
+type stubVaultClient struct {
+	vault.Client
+	reads    []string
+	writes   map[string]map[string]any
+	writeErr error
+	resp     *api.Secret
+	// writeResp is returned by Write instead of resp, when set.
+	writeResp *api.Secret
+	// authObj is returned by GetVaultAuthObj, an empty VaultAuth is returned when unset.
+	authObj *secretsv1alpha1.VaultAuth
+	// connObj is returned by GetVaultConnectionObj, an empty VaultConnection is returned when unset.
+	connObj *secretsv1alpha1.VaultConnection
+}
+
+func (c *stubVaultClient) GetVaultAuthObj() *secretsv1alpha1.VaultAuth {
+	if c.authObj == nil {
+		return &secretsv1alpha1.VaultAuth{}
+	}
+	return c.authObj
+}
+
+func (c *stubVaultClient) GetVaultConnectionObj() *secretsv1alpha1.VaultConnection {
+	if c.connObj == nil {
+		return &secretsv1alpha1.VaultConnection{}
+	}
+	return c.connObj
+}
+
+func (c *stubVaultClient) Read(_ context.Context, path string) (*api.Secret, error) {
+	c.reads = append(c.reads, path)
+	return c.resp, nil
+}
+
+func (c *stubVaultClient) Write(_ context.Context, path string, data map[string]any) (*api.Secret, error) {
+	if c.writes == nil {
+		c.writes = make(map[string]map[string]any)
+	}
+	c.writes[path] = data
+	if c.writeResp != nil {
+		return c.writeResp, c.writeErr
+	}
+	return c.resp, c.writeErr
+}
+
+// newTestDestSecret returns the destination Secret of o, as it was created by a previous sync.
+func newTestDestSecret(o *secretsv1alpha1.VaultDynamicSecret) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      o.Spec.Destination.Name,
+			Namespace: o.Namespace,
+			Labels:    helpers.OwnerLabels,
+		},
+		Data: map[string][]byte{"password": []byte("secret")},
+	}
+}
+
+func TestVaultDynamicSecretReconciler_handleDeletion(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name          string
+		spec          secretsv1alpha1.VaultDynamicSecretSpec
+		leaseID       string
+		writeErr      error
+		wantWrites    map[string]map[string]any
+		wantData      map[string][]byte
+		wantFinalizer bool
+		wantErr       bool
+	}{
+		{
+			name: "no-revoke",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Destination: secretsv1alpha1.Destination{Name: "dest"},
+			},
+			leaseID:  "db/creds/dev/foo",
+			wantData: map[string][]byte{"password": []byte("secret")},
+		},
+		{
+			name: "revoke",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Revoke:      true,
+				Destination: secretsv1alpha1.Destination{Name: "dest"},
+			},
+			leaseID: "db/creds/dev/foo",
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "db/creds/dev/foo"},
+			},
+			wantData: map[string][]byte{"password": []byte("secret")},
+		},
+		{
+			name: "revoke-without-lease",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Revoke:      true,
+				Destination: secretsv1alpha1.Destination{Name: "dest"},
+			},
+			wantData: map[string][]byte{"password": []byte("secret")},
+		},
+		{
+			name: "revoke-and-clear",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Revoke:      true,
+				Clear:       true,
+				Destination: secretsv1alpha1.Destination{Name: "dest"},
+			},
+			leaseID: "db/creds/dev/foo",
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "db/creds/dev/foo"},
+			},
+		},
+		{
+			name: "revoke-error",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Revoke:      true,
+				Clear:       true,
+				Destination: secretsv1alpha1.Destination{Name: "dest"},
+			},
+			leaseID:  "db/creds/dev/foo",
+			writeErr: errors.New("permission denied"),
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "db/creds/dev/foo"},
+			},
+			wantData:      map[string][]byte{"password": []byte("secret")},
+			wantFinalizer: true,
+			wantErr:       true,
+		},
+		{
+			name: "revoke-lease-not-found",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Revoke:      true,
+				Destination: secretsv1alpha1.Destination{Name: "dest"},
+			},
+			leaseID: "db/creds/dev/foo",
+			writeErr: &api.ResponseError{
+				StatusCode: 400,
+				Errors:     []string{"lease not found"},
+			},
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "db/creds/dev/foo"},
+			},
+			wantData: map[string][]byte{"password": []byte("secret")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dest := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "dest",
+					Namespace: "default",
+				},
+				Data: map[string][]byte{"password": []byte("secret")},
+			}
+			now := metav1.Now()
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "vds",
+					Namespace:         "default",
+					DeletionTimestamp: &now,
+					Finalizers:        []string{vaultDynamicSecretFinalizer},
+				},
+				Spec: tt.spec,
+				Status: secretsv1alpha1.VaultDynamicSecretStatus{
+					SecretLease: secretsv1alpha1.VaultSecretLease{ID: tt.leaseID},
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o, dest).Build()
+			vaultClient := &stubVaultClient{writeErr: tt.writeErr}
+			r := &VaultDynamicSecretReconciler{
+				Client:        c,
+				Recorder:      record.NewFakeRecorder(10),
+				ClientFactory: &stubClientFactory{client: vaultClient},
+			}
+
+			err := r.handleDeletion(ctx, o)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantWrites, vaultClient.writes)
+
+			var gotDest corev1.Secret
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(dest), &gotDest))
+			assert.Equal(t, tt.wantData, gotDest.Data)
+
+			var got secretsv1alpha1.VaultDynamicSecret
+			err = c.Get(ctx, client.ObjectKeyFromObject(o), &got)
+			if tt.wantFinalizer {
+				require.NoError(t, err)
+				assert.Contains(t, got.Finalizers, vaultDynamicSecretFinalizer)
+			} else if err == nil {
+				assert.NotContains(t, got.Finalizers, vaultDynamicSecretFinalizer)
+			} else {
+				assert.True(t, apierrors.IsNotFound(err))
+			}
+		})
+	}
+}
+
+func TestVaultDynamicSecretReconciler_syncSecret(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		spec       secretsv1alpha1.VaultDynamicSecretSpec
+		wantReads  []string
+		wantWrites map[string]map[string]any
+		wantErr    string
+	}{
+		{
+			name: "role",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Mount: "database",
+				Role:  "dev",
+			},
+			wantReads: []string{"database/creds/dev"},
+		},
+		{
+			name: "path-takes-precedence",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Mount: "database",
+				Role:  "dev",
+				Path:  "static-creds/dev",
+			},
+			wantReads: []string{"database/static-creds/dev"},
+		},
+		{
+			name: "params-default-method",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Mount: "aws",
+				Path:  "sts/dev",
+				Params: map[string]string{
+					"ttl": "1h",
+				},
+			},
+			wantWrites: map[string]map[string]any{
+				"aws/sts/dev": {"ttl": "1h"},
+			},
+		},
+		{
+			name: "post",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Mount:             "consul",
+				Path:              "creds/dev",
+				RequestHTTPMethod: http.MethodPost,
+			},
+			wantWrites: map[string]map[string]any{
+				"consul/creds/dev": {},
+			},
+		},
+		{
+			name: "get-with-params",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Mount:             "kubernetes",
+				Path:              "creds/dev",
+				RequestHTTPMethod: http.MethodGet,
+				Params: map[string]string{
+					"kubernetes_namespace": "default",
+				},
+			},
+			wantErr: "params are not supported with the GET request method",
+		},
+		{
+			name: "no-path-nor-role",
+			spec: secretsv1alpha1.VaultDynamicSecretSpec{
+				Mount: "database",
+			},
+			wantErr: "one of path or role must be set",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.spec.Destination = secretsv1alpha1.Destination{
+				Name:   "dest",
+				Create: true,
+			}
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vds",
+					Namespace: "default",
+				},
+				Spec: tt.spec,
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build()
+			vaultClient := &stubVaultClient{
+				resp: &api.Secret{
+					LeaseID:       "lease-id",
+					LeaseDuration: 300,
+					Renewable:     true,
+					Data: map[string]any{
+						"password": "secret",
+					},
+				},
+			}
+			r := &VaultDynamicSecretReconciler{
+				Client: c,
+			}
+
+			lease, _, err := r.syncSecret(ctx, vaultClient, o)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantReads, vaultClient.reads)
+			assert.Equal(t, tt.wantWrites, vaultClient.writes)
+			assert.Equal(t, "lease-id", lease.ID)
+
+			var dest corev1.Secret
+			require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "dest"}, &dest))
+			assert.Equal(t, []byte("secret"), dest.Data["password"])
+		})
+	}
+}
+
+func TestVaultDynamicSecretReconciler_Reconcile_rotation(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	tests := []struct {
+		name            string
+		status          secretsv1alpha1.VaultDynamicSecretStatus
+		rotationPercent int
+		gracePeriod     string
+		resp            *api.Secret
+		renewResp       *api.Secret
+		wantReads       []string
+		wantWrites      []string
+		wantLease       secretsv1alpha1.VaultSecretLease
+		wantPrevious    *secretsv1alpha1.VaultSecretLease
+		wantRequeue     time.Duration
+	}{
+		{
+			name: "sync-non-renewable",
+			resp: &api.Secret{
+				LeaseID:       "new-lease",
+				LeaseDuration: 300,
+			},
+			wantReads: []string{"aws/creds/dev"},
+			wantLease: secretsv1alpha1.VaultSecretLease{
+				ID:            "new-lease",
+				LeaseDuration: 300,
+			},
+			wantRequeue: 201 * time.Second,
+		},
+		{
+			name:            "sync-non-renewable-rotation-percent",
+			rotationPercent: 50,
+			resp: &api.Secret{
+				LeaseID:       "new-lease",
+				LeaseDuration: 300,
+			},
+			wantReads: []string{"aws/creds/dev"},
+			wantLease: secretsv1alpha1.VaultSecretLease{
+				ID:            "new-lease",
+				LeaseDuration: 300,
+			},
+			wantRequeue: 150 * time.Second,
+		},
+		{
+			name: "non-renewable-not-due",
+			status: secretsv1alpha1.VaultDynamicSecretStatus{
+				LastRenewalTime: now,
+				SecretLease: secretsv1alpha1.VaultSecretLease{
+					ID:            "lease",
+					LeaseDuration: 300,
+				},
+			},
+			wantLease: secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 300,
+			},
+			wantRequeue: 201 * time.Second,
+		},
+		{
+			name: "non-renewable-due",
+			status: secretsv1alpha1.VaultDynamicSecretStatus{
+				LastRenewalTime: now - 250,
+				SecretLease: secretsv1alpha1.VaultSecretLease{
+					ID:            "lease",
+					LeaseDuration: 300,
+				},
+			},
+			resp: &api.Secret{
+				LeaseID:       "new-lease",
+				LeaseDuration: 300,
+			},
+			wantReads: []string{"aws/creds/dev"},
+			wantLease: secretsv1alpha1.VaultSecretLease{
+				ID:            "new-lease",
+				LeaseDuration: 300,
+			},
+			wantRequeue: 201 * time.Second,
+		},
+		{
+			name:        "non-renewable-due-grace-period",
+			gracePeriod: "1m",
+			status: secretsv1alpha1.VaultDynamicSecretStatus{
+				LastRenewalTime: now - 250,
+				SecretLease: secretsv1alpha1.VaultSecretLease{
+					ID:            "lease",
+					LeaseDuration: 300,
+				},
+			},
+			resp: &api.Secret{
+				LeaseID:       "new-lease",
+				LeaseDuration: 300,
+			},
+			wantReads: []string{"aws/creds/dev"},
+			wantLease: secretsv1alpha1.VaultSecretLease{
+				ID:            "new-lease",
+				LeaseDuration: 300,
+			},
+			wantPrevious: &secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 300,
+			},
+			wantRequeue: time.Minute,
+		},
+		{
+			name: "renewal-truncated",
+			status: secretsv1alpha1.VaultDynamicSecretStatus{
+				LastRenewalTime: now - 250,
+				SecretLease: secretsv1alpha1.VaultSecretLease{
+					ID:            "lease",
+					LeaseDuration: 300,
+					Renewable:     true,
+				},
+			},
+			renewResp: &api.Secret{
+				LeaseID:       "lease",
+				LeaseDuration: 100,
+				Renewable:     true,
+			},
+			wantWrites: []string{"/sys/leases/renew"},
+			wantLease: secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 100,
+			},
+			wantRequeue: 67 * time.Second,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vds",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.VaultDynamicSecretSpec{
+					Mount:               "aws",
+					Role:                "dev",
+					RotationPercent:     tt.rotationPercent,
+					RotationGracePeriod: tt.gracePeriod,
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: true,
+					},
+				},
+				Status: tt.status,
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o, newTestDestSecret(o)).Build()
+			vaultClient := &stubVaultClient{
+				resp:      tt.resp,
+				writeResp: tt.renewResp,
+			}
+			r := &VaultDynamicSecretReconciler{
+				Client:        c,
+				Recorder:      record.NewFakeRecorder(10),
+				ClientFactory: &stubClientFactory{client: vaultClient},
+			}
+
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			require.NoError(t, err)
+			assert.InDelta(t, tt.wantRequeue, result.RequeueAfter, float64(2*time.Second))
+			assert.Equal(t, tt.wantReads, vaultClient.reads)
+			var writes []string
+			for path := range vaultClient.writes {
+				writes = append(writes, path)
+			}
+			assert.Equal(t, tt.wantWrites, writes)
+
+			var got secretsv1alpha1.VaultDynamicSecret
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+			assert.Equal(t, tt.wantLease, got.Status.SecretLease)
+			assert.Equal(t, tt.wantPrevious, got.Status.PreviousSecretLease)
+		})
+	}
+}
+
+func TestVaultDynamicSecretReconciler_Reconcile_staticCreds(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Unix()
+	lastRotation := time.Now().Add(-time.Hour).Truncate(time.Second).UTC()
+	rotation := lastRotation.Add(time.Hour)
+
+	staticCredsResp := func(lastVaultRotation time.Time, ttl int) *api.Secret {
+		return &api.Secret{
+			Data: map[string]any{
+				"username":            "dev",
+				"password":            "secret",
+				"last_vault_rotation": lastVaultRotation.Format(time.RFC3339Nano),
+				"rotation_period":     3600,
+				"ttl":                 ttl,
+			},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		status      secretsv1alpha1.VaultDynamicSecretStatus
+		resp        *api.Secret
+		wantReads   []string
+		wantMeta    *secretsv1alpha1.VaultStaticCredsMetaData
+		wantReason  string
+		wantRequeue time.Duration
+	}{
+		{
+			name:      "initial-sync",
+			resp:      staticCredsResp(lastRotation, 3600),
+			wantReads: []string{"database/static-creds/dev"},
+			wantMeta: &secretsv1alpha1.VaultStaticCredsMetaData{
+				LastVaultRotation: lastRotation.Unix(),
+				RotationPeriod:    3600,
+				TTL:               3600,
+			},
+			wantReason:  consts.ReasonSecretSynced,
+			wantRequeue: time.Hour + staticCredsRotationDelay,
+		},
+		{
+			name: "not-due",
+			status: secretsv1alpha1.VaultDynamicSecretStatus{
+				LastRenewalTime: now,
+				StaticCredsMetaData: &secretsv1alpha1.VaultStaticCredsMetaData{
+					LastVaultRotation: lastRotation.Unix(),
+					RotationPeriod:    3600,
+					TTL:               600,
+				},
+			},
+			wantMeta: &secretsv1alpha1.VaultStaticCredsMetaData{
+				LastVaultRotation: lastRotation.Unix(),
+				RotationPeriod:    3600,
+				TTL:               600,
+			},
+			wantRequeue: 10*time.Minute + staticCredsRotationDelay,
+		},
+		{
+			name: "rotated-by-vault",
+			status: secretsv1alpha1.VaultDynamicSecretStatus{
+				LastRenewalTime: now - 610,
+				StaticCredsMetaData: &secretsv1alpha1.VaultStaticCredsMetaData{
+					LastVaultRotation: lastRotation.Unix(),
+					RotationPeriod:    3600,
+					TTL:               600,
+				},
+			},
+			resp:      staticCredsResp(rotation, 3590),
+			wantReads: []string{"database/static-creds/dev"},
+			wantMeta: &secretsv1alpha1.VaultStaticCredsMetaData{
+				LastVaultRotation: rotation.Unix(),
+				RotationPeriod:    3600,
+				TTL:               3590,
+			},
+			wantReason:  consts.ReasonSecretRotated,
+			wantRequeue: 3590*time.Second + staticCredsRotationDelay,
+		},
+		{
+			name: "rotation-pending",
+			status: secretsv1alpha1.VaultDynamicSecretStatus{
+				LastRenewalTime: now - 610,
+				StaticCredsMetaData: &secretsv1alpha1.VaultStaticCredsMetaData{
+					LastVaultRotation: lastRotation.Unix(),
+					RotationPeriod:    3600,
+					TTL:               600,
+				},
+			},
+			resp:      staticCredsResp(lastRotation, 0),
+			wantReads: []string{"database/static-creds/dev"},
+			wantMeta: &secretsv1alpha1.VaultStaticCredsMetaData{
+				LastVaultRotation: lastRotation.Unix(),
+				RotationPeriod:    3600,
+			},
+			wantReason:  consts.ReasonSecretSynced,
+			wantRequeue: staticCredsRotationDelay,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vds",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.VaultDynamicSecretSpec{
+					Mount: "database",
+					Path:  "static-creds/dev",
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: true,
+					},
+				},
+				Status: tt.status,
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o, newTestDestSecret(o)).Build()
+			vaultClient := &stubVaultClient{resp: tt.resp}
+			recorder := record.NewFakeRecorder(10)
+			r := &VaultDynamicSecretReconciler{
+				Client:        c,
+				Recorder:      recorder,
+				ClientFactory: &stubClientFactory{client: vaultClient},
+			}
+
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			require.NoError(t, err)
+			assert.InDelta(t, tt.wantRequeue, result.RequeueAfter, float64(2*time.Second))
+			assert.Equal(t, tt.wantReads, vaultClient.reads)
+
+			var got secretsv1alpha1.VaultDynamicSecret
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+			assert.Equal(t, tt.wantMeta, got.Status.StaticCredsMetaData)
+
+			close(recorder.Events)
+			var reasons []string
+			for e := range recorder.Events {
+				reasons = append(reasons, e)
+			}
+			if tt.wantReason == "" {
+				assert.Empty(t, reasons)
+			} else {
+				require.Len(t, reasons, 1)
+				assert.Contains(t, reasons[0], tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestVaultDynamicSecretReconciler_Reconcile_leaseManager(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		resp        *api.Secret
+		wantTracked int
+		wantRequeue bool
+	}{
+		{
+			name: "renewable-tracked",
+			resp: &api.Secret{
+				LeaseID:       "new-lease",
+				LeaseDuration: 300,
+				Renewable:     true,
+			},
+			wantTracked: 1,
+		},
+		{
+			name: "non-renewable-untracked",
+			resp: &api.Secret{
+				LeaseID:       "new-lease",
+				LeaseDuration: 300,
+			},
+			wantRequeue: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vds",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.VaultDynamicSecretSpec{
+					Mount: "aws",
+					Role:  "dev",
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: true,
+					},
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o).Build()
+			r := &VaultDynamicSecretReconciler{
+				Client:        c,
+				Recorder:      record.NewFakeRecorder(10),
+				ClientFactory: &stubClientFactory{client: &stubVaultClient{resp: tt.resp}},
+			}
+			lm, err := vault.NewLeaseManager(r, vault.DefaultLeaseManagerConfig())
+			require.NoError(t, err)
+			r.LeaseManager = lm
+			// a stale lease must not remain tracked
+			lm.Track(client.ObjectKeyFromObject(o), secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 300,
+				Renewable:     true,
+			}, time.Now())
+
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRequeue, result.RequeueAfter > 0)
+			assert.Equal(t, tt.wantTracked, lm.Len())
+		})
+	}
+}
+
+func TestVaultDynamicSecretReconciler_Reconcile_drift(t *testing.T) {
+	ctx := context.Background()
+	// the MAC of a Secret's data is its JSON encoding, for the purpose of this test.
+	hmacFunc := func(_ context.Context, _ client.Client, message []byte) ([]byte, error) {
+		return message, nil
+	}
+	validateMACFunc := func(_ context.Context, _ client.Client, message, messageMAC []byte) (bool, []byte, error) {
+		return string(message) == string(messageMAC), message, nil
+	}
+	syncedMAC, err := computeSecretMAC(ctx, nil, hmacFunc, map[string][]byte{"password": []byte("secret")})
+	require.NoError(t, err)
+
+	revoked := map[string]map[string]any{
+		"/sys/leases/revoke": {"lease_id": "lease"},
+	}
+	tests := []struct {
+		name        string
+		gracePeriod string
+		destData    map[string][]byte
+		wantReads   []string
+		wantWrites  map[string]map[string]any
+		wantTracked bool
+		wantReasons []string
+	}{
+		{
+			name:        "unchanged",
+			destData:    map[string][]byte{"password": []byte("secret")},
+			wantTracked: true,
+		},
+		{
+			name:        "deleted",
+			wantReads:   []string{"aws/creds/dev"},
+			wantWrites:  revoked,
+			wantTracked: true,
+			wantReasons: []string{consts.ReasonSecretDrifted, consts.ReasonSecretLeaseRevoke, consts.ReasonSecretRotated},
+		},
+		{
+			name:        "data-changed",
+			destData:    map[string][]byte{"password": []byte("changed")},
+			wantReads:   []string{"aws/creds/dev"},
+			wantWrites:  revoked,
+			wantTracked: true,
+			wantReasons: []string{consts.ReasonSecretDrifted, consts.ReasonSecretLeaseRevoke, consts.ReasonSecretRotated},
+		},
+		{
+			// the replaced lease is retained for the grace period, rather than being revoked.
+			name:        "deleted-grace-period",
+			gracePeriod: "5m",
+			wantReads:   []string{"aws/creds/dev"},
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/renew": {"lease_id": "lease", "increment": 300},
+			},
+			wantTracked: true,
+			wantReasons: []string{consts.ReasonSecretDrifted, consts.ReasonSecretRotated},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 300,
+				Renewable:     true,
+			}
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vds",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.VaultDynamicSecretSpec{
+					Mount:               "aws",
+					Role:                "dev",
+					RotationGracePeriod: tt.gracePeriod,
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: true,
+					},
+				},
+				Status: secretsv1alpha1.VaultDynamicSecretStatus{
+					LastRenewalTime: time.Now().Unix(),
+					SecretLease:     lease,
+					SecretMAC:       syncedMAC,
+				},
+			}
+
+			builder := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o)
+			if tt.destData != nil {
+				dest := newTestDestSecret(o)
+				dest.Data = tt.destData
+				builder = builder.WithObjects(dest)
+			}
+			c := builder.Build()
+			vaultClient := &stubVaultClient{
+				resp: &api.Secret{
+					LeaseID:       "new-lease",
+					LeaseDuration: 300,
+					Renewable:     true,
+					Data:          map[string]any{"password": "new"},
+				},
+			}
+			recorder := record.NewFakeRecorder(10)
+			r := &VaultDynamicSecretReconciler{
+				Client:          c,
+				Recorder:        recorder,
+				ClientFactory:   &stubClientFactory{client: vaultClient},
+				HMACFunc:        hmacFunc,
+				ValidateMACFunc: validateMACFunc,
+			}
+			lm, err := vault.NewLeaseManager(r, vault.DefaultLeaseManagerConfig())
+			require.NoError(t, err)
+			r.LeaseManager = lm
+			lm.Track(client.ObjectKeyFromObject(o), lease, time.Now())
+
+			_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantReads, vaultClient.reads)
+			assert.Equal(t, tt.wantWrites, vaultClient.writes)
+			assert.Equal(t, tt.wantTracked, lm.IsTracked(client.ObjectKeyFromObject(o)))
+
+			close(recorder.Events)
+			var events []string
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			if len(tt.wantReasons) == 0 {
+				assert.Empty(t, events)
+				return
+			}
+
+			require.Len(t, events, len(tt.wantReasons))
+			for i, reason := range tt.wantReasons {
+				assert.Contains(t, events[i], reason)
+			}
+
+			var got secretsv1alpha1.VaultDynamicSecret
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+			assert.Equal(t, "new-lease", got.Status.SecretLease.ID)
+			wantMAC, err := computeSecretMAC(ctx, nil, hmacFunc, map[string][]byte{
+				"_raw":     []byte(`{"password":"new"}`),
+				"password": []byte("new"),
+			})
+			require.NoError(t, err)
+			assert.Equal(t, wantMAC, got.Status.SecretMAC)
+
+			dest, ok, err := helpers.GetSecret(ctx, c, &got)
+			require.NoError(t, err)
+			require.True(t, ok)
+			assert.Equal(t, []byte("new"), dest.Data["password"])
+		})
+	}
+}
+
+func TestVaultDynamicSecretReconciler_syncOrRenewLease_statusUpdateFailed(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name        string
+		gracePeriod time.Duration
+		previous    *secretsv1alpha1.VaultSecretLease
+		wantWrites  map[string]map[string]any
+	}{
+		{
+			name: "no-grace-period",
+		},
+		{
+			name:        "grace-period",
+			gracePeriod: 5 * time.Minute,
+			previous: &secretsv1alpha1.VaultSecretLease{
+				ID:            "previous-lease",
+				LeaseDuration: 300,
+			},
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/renew": {"lease_id": "lease", "increment": 300},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 300,
+				Renewable:     true,
+			}
+			// the VaultDynamicSecret is not stored, so its status cannot be updated, and its destination
+			// Secret is missing, so the lease is replaced.
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vds",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.VaultDynamicSecretSpec{
+					Mount: "aws",
+					Role:  "dev",
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: true,
+					},
+				},
+				Status: secretsv1alpha1.VaultDynamicSecretStatus{
+					LastRenewalTime:     time.Now().Unix(),
+					SecretLease:         lease,
+					PreviousSecretLease: tt.previous,
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).Build()
+			vaultClient := &stubVaultClient{
+				resp: &api.Secret{
+					LeaseID:       "new-lease",
+					LeaseDuration: 300,
+					Renewable:     true,
+					Data:          map[string]any{"password": "new"},
+				},
+			}
+			r := &VaultDynamicSecretReconciler{
+				Client:        c,
+				Recorder:      record.NewFakeRecorder(10),
+				ClientFactory: &stubClientFactory{client: vaultClient},
+			}
+			lm, err := vault.NewLeaseManager(r, vault.DefaultLeaseManagerConfig())
+			require.NoError(t, err)
+			r.LeaseManager = lm
+
+			_, _, err = r.syncOrRenewLease(ctx, o, tt.gracePeriod)
+			require.Error(t, err)
+			assert.Equal(t, []string{"aws/creds/dev"}, vaultClient.reads)
+			// none of the replaced leases are revoked, since the status was never updated.
+			assert.Equal(t, tt.wantWrites, vaultClient.writes)
+		})
+	}
+}
+
+func TestVaultDynamicSecretReconciler_Reconcile_vaultAuthChanged(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name                          string
+		lastVaultAuthGeneration       int64
+		lastVaultConnectionGeneration int64
+		wantReads                     []string
+		wantWrites                    map[string]map[string]any
+		wantVaultAuthGeneration       int64
+		wantVaultConnectionGeneration int64
+	}{
+		{
+			name:                          "unchanged",
+			lastVaultAuthGeneration:       2,
+			lastVaultConnectionGeneration: 3,
+			wantVaultAuthGeneration:       2,
+			wantVaultConnectionGeneration: 3,
+		},
+		{
+			name:                          "changed",
+			lastVaultAuthGeneration:       1,
+			lastVaultConnectionGeneration: 3,
+			wantReads:                     []string{"aws/creds/dev"},
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "lease"},
+			},
+			wantVaultAuthGeneration:       2,
+			wantVaultConnectionGeneration: 3,
+		},
+		{
+			name:                          "connection-changed",
+			lastVaultAuthGeneration:       2,
+			lastVaultConnectionGeneration: 1,
+			wantReads:                     []string{"aws/creds/dev"},
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "lease"},
+			},
+			wantVaultAuthGeneration:       2,
+			wantVaultConnectionGeneration: 3,
+		},
+		{
+			// the status predates the VaultConnection generation being recorded.
+			name:                          "connection-unset",
+			lastVaultAuthGeneration:       2,
+			wantVaultAuthGeneration:       2,
+			wantVaultConnectionGeneration: 0,
+		},
+		{
+			// the status predates the VaultAuth generation being recorded.
+			name:                    "unset",
+			wantVaultAuthGeneration: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 300,
+				Renewable:     true,
+			}
+			a := &secretsv1alpha1.VaultAuth{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "auth",
+					Namespace:  "default",
+					Generation: 2,
+				},
+				Spec: secretsv1alpha1.VaultAuthSpec{
+					VaultConnectionRef: "conn",
+				},
+			}
+			conn := &secretsv1alpha1.VaultConnection{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "conn",
+					Namespace:  "default",
+					Generation: 3,
+				},
+			}
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vds",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.VaultDynamicSecretSpec{
+					VaultAuthRef: "auth",
+					Mount:        "aws",
+					Role:         "dev",
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: true,
+					},
+				},
+				Status: secretsv1alpha1.VaultDynamicSecretStatus{
+					LastRenewalTime:           time.Now().Unix(),
+					SecretLease:               lease,
+					VaultAuthGeneration:       tt.lastVaultAuthGeneration,
+					VaultConnectionGeneration: tt.lastVaultConnectionGeneration,
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).
+				WithObjects(a, conn, o, newTestDestSecret(o)).Build()
+			vaultClient := &stubVaultClient{
+				resp: &api.Secret{
+					LeaseID:       "new-lease",
+					LeaseDuration: 300,
+					Renewable:     true,
+					Data:          map[string]any{"password": "new"},
+				},
+				authObj: a,
+				connObj: conn,
+			}
+			r := &VaultDynamicSecretReconciler{
+				Client:        c,
+				Recorder:      record.NewFakeRecorder(10),
+				ClientFactory: &stubClientFactory{client: vaultClient},
+			}
+			lm, err := vault.NewLeaseManager(r, vault.DefaultLeaseManagerConfig())
+			require.NoError(t, err)
+			r.LeaseManager = lm
+			lm.Track(client.ObjectKeyFromObject(o), lease, time.Now())
+
+			_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantReads, vaultClient.reads)
+			assert.Equal(t, tt.wantWrites, vaultClient.writes)
+			assert.True(t, lm.IsTracked(client.ObjectKeyFromObject(o)))
+
+			var got secretsv1alpha1.VaultDynamicSecret
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+			assert.Equal(t, tt.wantVaultAuthGeneration, got.Status.VaultAuthGeneration)
+			assert.Equal(t, tt.wantVaultConnectionGeneration, got.Status.VaultConnectionGeneration)
+		})
+	}
+}
+
+func TestVaultDynamicSecretReconciler_Reconcile_transformationChanged(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name       string
+		stale      bool
+		wantReads  []string
+		wantWrites map[string]map[string]any
+		wantData   map[string][]byte
+	}{
+		{
+			name: "unchanged",
+		},
+		{
+			name:      "changed",
+			stale:     true,
+			wantReads: []string{"aws/creds/dev"},
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "lease"},
+			},
+			wantData: map[string][]byte{
+				"password": []byte("new"),
+				"user":     []byte("NEW"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lease := secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 300,
+				Renewable:     true,
+			}
+			st := &secretsv1alpha1.SecretTransformation{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "upper",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.SecretTransformationSpec{
+					Templates: map[string]string{"user": "{{ .Secrets.password | upper }}"},
+				},
+			}
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vds",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.VaultDynamicSecretSpec{
+					Mount: "aws",
+					Role:  "dev",
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: true,
+						Transformation: &secretsv1alpha1.Transformation{
+							TransformationRefs: []secretsv1alpha1.TransformationRef{{Name: "upper"}},
+							Excludes:           []string{"^_raw$"},
+						},
+					},
+				},
+				Status: secretsv1alpha1.VaultDynamicSecretStatus{
+					LastRenewalTime: time.Now().Unix(),
+					SecretLease:     lease,
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).
+				WithObjects(st, o, newTestDestSecret(o)).Build()
+			wantHash, err := computeTransformationHash(ctx, c, o)
+			require.NoError(t, err)
+			require.NotEmpty(t, wantHash)
+			if !tt.stale {
+				o.Status.TransformationHash = wantHash
+				require.NoError(t, c.Status().Update(ctx, o))
+			}
+
+			vaultClient := &stubVaultClient{
+				resp: &api.Secret{
+					LeaseID:       "new-lease",
+					LeaseDuration: 300,
+					Renewable:     true,
+					Data:          map[string]any{"password": "new"},
+				},
+				authObj: &secretsv1alpha1.VaultAuth{},
+				connObj: &secretsv1alpha1.VaultConnection{},
+			}
+			r := &VaultDynamicSecretReconciler{
+				Client:        c,
+				Recorder:      record.NewFakeRecorder(10),
+				ClientFactory: &stubClientFactory{client: vaultClient},
+			}
+			lm, err := vault.NewLeaseManager(r, vault.DefaultLeaseManagerConfig())
+			require.NoError(t, err)
+			r.LeaseManager = lm
+			lm.Track(client.ObjectKeyFromObject(o), lease, time.Now())
+
+			_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantReads, vaultClient.reads)
+			assert.Equal(t, tt.wantWrites, vaultClient.writes)
+
+			var got secretsv1alpha1.VaultDynamicSecret
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+			assert.Equal(t, wantHash, got.Status.TransformationHash)
+
+			if tt.wantData != nil {
+				var dest corev1.Secret
+				require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: o.Namespace, Name: "dest"}, &dest))
+				assert.Equal(t, tt.wantData, dest.Data)
+			}
+		})
+	}
+}
+
+func TestVaultDynamicSecretReconciler_OnRenewal(t *testing.T) {
+	ctx := context.Background()
+	o := &secretsv1alpha1.VaultDynamicSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vds",
+			Namespace: "default",
+		},
+		Status: secretsv1alpha1.VaultDynamicSecretStatus{
+			LastRenewalTime: time.Now().Unix() - 250,
+			SecretLease: secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 300,
+				Renewable:     true,
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o).Build()
+	r := &VaultDynamicSecretReconciler{
+		Client:   c,
+		Recorder: record.NewFakeRecorder(10),
+	}
+
+	// a renewal of a lease that was since rotated is ignored
+	require.NoError(t, r.OnRenewal(ctx, client.ObjectKeyFromObject(o), secretsv1alpha1.VaultSecretLease{
+		ID:            "other",
+		LeaseDuration: 300,
+	}))
+	var got secretsv1alpha1.VaultDynamicSecret
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+	assert.Equal(t, o.Status.SecretLease, got.Status.SecretLease)
+
+	renewed := secretsv1alpha1.VaultSecretLease{
+		ID:            "lease",
+		LeaseDuration: 100,
+	}
+	require.NoError(t, r.OnRenewal(ctx, client.ObjectKeyFromObject(o), renewed))
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+	assert.Equal(t, renewed, got.Status.SecretLease)
+	assert.InDelta(t, time.Now().Unix(), got.Status.LastRenewalTime, 2)
+}
+
+// conflictingStatusClient fails the first conflicts status updates with a conflict error.
+type conflictingStatusClient struct {
+	client.Client
+	conflicts int
+}
+
+func (c *conflictingStatusClient) Status() client.SubResourceWriter {
+	return &conflictingStatusWriter{SubResourceWriter: c.Client.Status(), c: c}
+}
+
+type conflictingStatusWriter struct {
+	client.SubResourceWriter
+	c *conflictingStatusClient
+}
+
+func (w *conflictingStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	if w.c.conflicts > 0 {
+		w.c.conflicts--
+		return apierrors.NewConflict(schema.GroupResource{Resource: "vaultdynamicsecrets"}, obj.GetName(),
+			errors.New("the object has been modified"))
+	}
+	return w.SubResourceWriter.Update(ctx, obj, opts...)
+}
+
+func TestVaultDynamicSecretReconciler_OnRenewal_conflict(t *testing.T) {
+	ctx := context.Background()
+	o := &secretsv1alpha1.VaultDynamicSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "vds",
+			Namespace:  "default",
+			Generation: 2,
+		},
+		Status: secretsv1alpha1.VaultDynamicSecretStatus{
+			ObservedGeneration: 1,
+			LastRuntimePodUID:  "previous-pod",
+			SecretLease: secretsv1alpha1.VaultSecretLease{
+				ID:            "lease",
+				LeaseDuration: 300,
+				Renewable:     true,
+			},
+		},
+	}
+
+	c := &conflictingStatusClient{
+		Client:    fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o).Build(),
+		conflicts: 2,
+	}
+	r := &VaultDynamicSecretReconciler{
+		Client:        c,
+		Recorder:      record.NewFakeRecorder(10),
+		runtimePodUID: "current-pod",
+	}
+
+	renewed := secretsv1alpha1.VaultSecretLease{
+		ID:            "lease",
+		LeaseDuration: 300,
+		Renewable:     true,
+	}
+	require.NoError(t, r.OnRenewal(ctx, client.ObjectKeyFromObject(o), renewed))
+	assert.Equal(t, 0, c.conflicts)
+
+	var got secretsv1alpha1.VaultDynamicSecret
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+	assert.Equal(t, renewed, got.Status.SecretLease)
+	assert.InDelta(t, time.Now().Unix(), got.Status.LastRenewalTime, 2)
+	assert.Equal(t, types.UID("current-pod"), got.Status.LastRuntimePodUID)
+	// the spec's generation was not reconciled by the renewal.
+	assert.Equal(t, int64(1), got.Status.ObservedGeneration)
+}
+
+func TestVaultDynamicSecretReconciler_handlePreviousLease(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	newDeployment := func(complete bool, observedGeneration int64) *appsv1.Deployment {
+		d := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:       "app",
+				Namespace:  "default",
+				Generation: 2,
+			},
+			Status: appsv1.DeploymentStatus{
+				ObservedGeneration: observedGeneration,
+				Replicas:           2,
+				UpdatedReplicas:    1,
+				AvailableReplicas:  2,
+			},
+		}
+		if complete {
+			d.Status.Replicas = 1
+			d.Status.UpdatedReplicas = 1
+			d.Status.AvailableReplicas = 1
+		}
+		return d
+	}
+	restartedTargets := []secretsv1alpha1.RolloutRestartTargetStatus{
+		{
+			Kind:       "Deployment",
+			Name:       "app",
+			Generation: 2,
+		},
+	}
+
+	previous := &secretsv1alpha1.VaultSecretLease{
+		ID:            "previous-lease",
+		LeaseDuration: 300,
+	}
+	tests := []struct {
+		name             string
+		previous         *secretsv1alpha1.VaultSecretLease
+		lastRotationTime int64
+		deployment       *appsv1.Deployment
+		restartedTargets []secretsv1alpha1.RolloutRestartTargetStatus
+		restarted        bool
+		notStored        bool
+		wantHorizon      time.Duration
+		wantWrites       map[string]map[string]any
+		wantPrevious     *secretsv1alpha1.VaultSecretLease
+		wantErr          bool
+	}{
+		{
+			name: "no-previous-lease",
+		},
+		{
+			name:             "within-grace-period",
+			previous:         previous,
+			lastRotationTime: now - 30,
+			wantHorizon:      30 * time.Second,
+			wantPrevious:     previous,
+		},
+		{
+			name:             "grace-period-elapsed",
+			previous:         previous,
+			lastRotationTime: now - 60,
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "previous-lease"},
+			},
+		},
+		{
+			// the previous lease is not revoked while the status still references it.
+			name:             "status-update-failed",
+			previous:         previous,
+			lastRotationTime: now - 60,
+			notStored:        true,
+			wantPrevious:     previous,
+			wantErr:          true,
+		},
+		{
+			name:             "rollout-in-progress",
+			previous:         previous,
+			lastRotationTime: now - 30,
+			deployment:       newDeployment(false, 2),
+			restartedTargets: restartedTargets,
+			wantHorizon:      rolloutStatusInterval,
+			wantPrevious:     previous,
+		},
+		{
+			name:             "rollout-complete",
+			previous:         previous,
+			lastRotationTime: now - 30,
+			deployment:       newDeployment(true, 2),
+			restartedTargets: restartedTargets,
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "previous-lease"},
+			},
+		},
+		{
+			// the target's status has not yet observed the generation of the rollout-restart,
+			// its replica counts are those of the previous rollout.
+			name:             "rollout-status-stale",
+			previous:         previous,
+			lastRotationTime: now - 30,
+			deployment:       newDeployment(true, 1),
+			restartedTargets: restartedTargets,
+			wantHorizon:      rolloutStatusInterval,
+			wantPrevious:     previous,
+		},
+		{
+			name:             "rollout-restarted-by-this-reconcile",
+			previous:         previous,
+			lastRotationTime: now,
+			deployment:       newDeployment(true, 2),
+			restartedTargets: restartedTargets,
+			restarted:        true,
+			wantHorizon:      rolloutStatusInterval,
+			wantPrevious:     previous,
+		},
+		{
+			name:             "rollout-restart-failed",
+			previous:         previous,
+			lastRotationTime: now - 30,
+			deployment:       newDeployment(true, 2),
+			wantHorizon:      rolloutStatusInterval,
+			wantPrevious:     previous,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &secretsv1alpha1.VaultDynamicSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vds",
+					Namespace: "default",
+				},
+				Status: secretsv1alpha1.VaultDynamicSecretStatus{
+					PreviousSecretLease:   tt.previous,
+					LastRotationTime:      tt.lastRotationTime,
+					RolloutRestartTargets: tt.restartedTargets,
+				},
+			}
+
+			builder := fake.NewClientBuilder().WithScheme(newTestScheme(t))
+			if !tt.notStored {
+				builder = builder.WithObjects(o)
+			}
+			if tt.deployment != nil {
+				o.Spec.RolloutRestartTargets = []secretsv1alpha1.RolloutRestartTarget{
+					{
+						Kind: "Deployment",
+						Name: tt.deployment.Name,
+					},
+				}
+				builder = builder.WithObjects(tt.deployment)
+			}
+			c := builder.Build()
+
+			vaultClient := &stubVaultClient{}
+			r := &VaultDynamicSecretReconciler{
+				Client:        c,
+				Recorder:      record.NewFakeRecorder(10),
+				ClientFactory: &stubClientFactory{client: vaultClient},
+			}
+
+			horizon, err := r.handlePreviousLease(ctx, o, time.Minute, tt.restarted)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.InDelta(t, tt.wantHorizon, horizon, float64(2*time.Second))
+			assert.Equal(t, tt.wantWrites, vaultClient.writes)
+			assert.Equal(t, tt.wantPrevious, o.Status.PreviousSecretLease)
+		})
+	}
+}
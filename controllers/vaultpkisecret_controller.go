@@ -5,17 +5,21 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/hashicorp/vault/api"
 	"github.com/operator-framework/operator-lib/handler"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
@@ -37,12 +41,20 @@ type VaultPKISecretReconciler struct {
 	Scheme        *runtime.Scheme
 	ClientFactory vault.ClientFactory
 	Recorder      record.EventRecorder
+	// HMACFunc and ValidateMACFunc are used to detect drift in the destination Secret's data,
+	// only its deletion is detected if they are not set.
+	HMACFunc        vault.HMACFromSecretFunc
+	ValidateMACFunc vault.ValidateMACFromSecretFunc
+	// SecretsCache holds the destination Secrets that are watched for drift, in which case a new
+	// certificate is issued right away. The destination Secrets are not watched if it is nil.
+	SecretsCache cache.Cache
 }
 
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultpkisecrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultpkisecrets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultpkisecrets/finalizers,verbs=update
-//+kubebuilder:rbac:groups="",resources=secrets,verbs=get
+//+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=secrettransformations,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //
 // required for rollout-restart
@@ -69,7 +81,7 @@ func (r *VaultPKISecretReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 		return ctrl.Result{}, err
 	}
 
-	path := r.getPath(o.Spec)
+	path := getPKIIssuePath(o.Spec.Mount, o.Spec.IssuerRef, o.Spec.Name)
 	if o.GetDeletionTimestamp() != nil {
 		if err := r.handleDeletion(ctx, logger, o); err != nil {
 			msg := "Failed to handle deletion"
@@ -91,6 +103,7 @@ func (r *VaultPKISecretReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 			msg := fmt.Sprintf("Failed to parse ExpiryOffset %q", o.Spec.ExpiryOffset)
 			logger.Error(err, msg)
 			r.recordEvent(o, o.Status.Error, msg+": %s", err)
+			r.setSyncedCondition(o, false, o.Status.Error, fmt.Sprintf("%s: %s", msg, err))
 			if err := r.updateStatus(ctx, o); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -101,7 +114,23 @@ func (r *VaultPKISecretReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	timeToRenew := false
 	if o.Status.SerialNumber != "" {
-		if expiryOffset > 0 {
+		if r.isDestinationDrifted(ctx, o) {
+			// the destination Secret was deleted, or its data was changed.
+			logger.Info("Setting renewal for destination secret drift")
+			r.recordEvent(o, consts.ReasonSecretDrifted,
+				"Destination secret %s drifted, issuing a new certificate", o.Spec.Destination.Name)
+			timeToRenew = true
+		} else if isVaultAuthChanged(ctx, r.Client, o, o.Status.VaultAuthGeneration, o.Status.VaultConnectionGeneration) {
+			logger.Info("Setting renewal for VaultAuth or VaultConnection change")
+			r.recordEvent(o, consts.ReasonVaultAuthChanged,
+				"VaultAuth or VaultConnection changed since the last sync, issuing a new certificate")
+			timeToRenew = true
+		} else if isTransformationChanged(ctx, r.Client, o, o.Status.TransformationHash) {
+			logger.Info("Setting renewal for Transformation change")
+			r.recordEvent(o, consts.ReasonTransformationChanged,
+				"Transformation changed since the last sync, issuing a new certificate")
+			timeToRenew = true
+		} else if expiryOffset > 0 {
 			// check if within the certificate renewal window
 			if checkPKICertExpiry(o.Status.Expiration, expiryOffset) {
 				logger.Info("Setting renewal for certificate expiry")
@@ -128,6 +157,7 @@ func (r *VaultPKISecretReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 				o.Spec.Destination.Name, err)
 			o.Status.Error = consts.ReasonK8sClientError
 			r.recordEvent(o, o.Status.Error, msg)
+			r.setSyncedCondition(o, false, o.Status.Error, msg)
 			if err := r.updateStatus(ctx, o); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -141,6 +171,7 @@ func (r *VaultPKISecretReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 			logger.Info(msg)
 			o.Status.Error = consts.ReasonK8sClientError
 			r.recordEvent(o, o.Status.Error, msg)
+			r.setSyncedCondition(o, false, o.Status.Error, msg)
 			if err := r.updateStatus(ctx, o); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -152,82 +183,103 @@ func (r *VaultPKISecretReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 
 	c, err := r.ClientFactory.Get(ctx, r.Client, o)
 	if err != nil {
+		o.Status.Error = consts.ReasonVaultClientConfigError
+		msg := "Failed to get Vault client"
+		logger.Error(err, msg)
+		r.recordEvent(o, o.Status.Error, msg+": %s", err)
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, false,
+			o.Status.Error, fmt.Sprintf("%s: %s", msg, err))
+		setReadyCondition(&o.Status.Conditions, o.Generation,
+			consts.ConditionTypeAuthValid, consts.ConditionTypeSynced)
+		if err := r.updateStatus(ctx, o); err != nil {
+			return ctrl.Result{}, err
+		}
 		return ctrl.Result{}, err
 	}
+	setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, true,
+		consts.ReasonAccepted, "Vault auth login succeeded")
 
-	resp, err := c.Write(ctx, path, o.GetIssuerAPIData())
+	resp, certResp, err := issuePKICertificate(ctx, c, path, o.GetIssuerAPIData())
 	if err != nil {
 		o.Status.Error = consts.ReasonK8sClientError
 		msg := "Failed to issue certificate from Vault"
 		logger.Error(err, msg)
 		r.recordEvent(o, o.Status.Error, msg+": %s", err)
+		r.setSyncedCondition(o, false, o.Status.Error, fmt.Sprintf("%s: %s", msg, err))
 		if err := r.updateStatus(ctx, o); err != nil {
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, err
 	}
 
-	if resp == nil {
+	data, err := vault.MarshalSecretData(resp)
+	if err != nil {
 		o.Status.Error = consts.ReasonK8sClientError
-		msg := fmt.Sprintf("Empty Vault secret at path %s", path)
-		logger.Error(nil, msg)
-		r.recordEvent(o, o.Status.Error, msg)
+		msg := "Failed to marshal Vault secret data"
+		logger.Error(err, msg)
+		r.recordEvent(o, o.Status.Error, msg+": %s", err)
+		r.setSyncedCondition(o, false, o.Status.Error, fmt.Sprintf("%s: %s", msg, err))
 		if err := r.updateStatus(ctx, o); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{
-			RequeueAfter: time.Second * 30,
-		}, nil
+		return ctrl.Result{}, err
+	}
+	if o.Spec.Destination.Type == corev1.SecretTypeTLS {
+		data[corev1.TLSCertKey] = data["certificate"]
+		data[corev1.TLSPrivateKeyKey] = data["private_key"]
 	}
 
-	certResp, err := vault.UnmarshalPKIIssueResponse(resp)
+	data, err = helpers.TransformSecretData(ctx, r.Client, o, data, resp.Data)
+	var transformationHash string
+	if err == nil {
+		transformationHash, err = computeTransformationHash(ctx, r.Client, o)
+	}
 	if err != nil {
-		o.Status.Error = consts.ReasonK8sClientError
-		msg := "Failed to unmarshal PKI response"
+		o.Status.Error = consts.ReasonTransformationError
+		msg := "Failed to transform the secret data"
 		logger.Error(err, msg)
 		r.recordEvent(o, o.Status.Error, msg+": %s", err)
+		r.setSyncedCondition(o, false, o.Status.Error, fmt.Sprintf("%s: %s", msg, err))
 		if err := r.updateStatus(ctx, o); err != nil {
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, err
 	}
 
-	if certResp.SerialNumber == "" {
-		o.Status.Error = consts.ReasonK8sClientError
-		msg := "Invalid Vault secret data, serial_number cannot be empty"
-		logger.Error(nil, msg)
-		r.recordEvent(o, o.Status.Error, msg)
-		if err := r.updateStatus(ctx, o); err != nil {
+	var secretMAC string
+	if r.HMACFunc != nil {
+		secretMAC, err = computeSecretMAC(ctx, r.Client, r.HMACFunc, data)
+		if err != nil {
+			o.Status.Error = consts.ReasonSecretSyncError
+			msg := "Failed to compute the secret data HMAC"
+			logger.Error(err, msg)
+			r.recordEvent(o, o.Status.Error, msg+": %s", err)
+			r.setSyncedCondition(o, false, o.Status.Error, fmt.Sprintf("%s: %s", msg, err))
+			if err := r.updateStatus(ctx, o); err != nil {
+				return ctrl.Result{}, err
+			}
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{}, err
 	}
 
-	data, err := vault.MarshalSecretData(resp)
-	if err != nil {
-		o.Status.Error = consts.ReasonK8sClientError
-		msg := "Failed to marshal Vault secret data"
+	if err := helpers.SyncSecret(ctx, r.Client, o, data); err != nil {
+		o.Status.Error = consts.ReasonSecretSyncError
+		msg := "Failed to update k8s secret"
 		logger.Error(err, msg)
 		r.recordEvent(o, o.Status.Error, msg+": %s", err)
+		r.setSyncedCondition(o, false, o.Status.Error, fmt.Sprintf("%s: %s", msg, err))
 		if err := r.updateStatus(ctx, o); err != nil {
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{}, err
 	}
-	if o.Spec.Destination.Type == corev1.SecretTypeTLS {
-		data[corev1.TLSCertKey] = data["certificate"]
-		data[corev1.TLSPrivateKeyKey] = data["private_key"]
-	}
-	if err := helpers.SyncSecret(ctx, r.Client, o, data); err != nil {
-		return ctrl.Result{}, err
-	}
 
 	reason := consts.ReasonSecretSynced
 	if timeToRenew {
 		reason = consts.ReasonSecretRotated
 		// rollout-restart errors are not retryable
 		// all error reporting is handled by helpers.HandleRolloutRestarts
-		_ = helpers.HandleRolloutRestarts(ctx, r.Client, o, r.Recorder)
+		_, _ = helpers.HandleRolloutRestarts(ctx, r.Client, o, r.Recorder)
 	}
 
 	// revoke the certificate on renewal
@@ -241,6 +293,11 @@ func (r *VaultPKISecretReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	o.Status.Error = ""
 	o.Status.SerialNumber = certResp.SerialNumber
 	o.Status.Expiration = certResp.Expiration
+	o.Status.SecretMAC = secretMAC
+	o.Status.TransformationHash = transformationHash
+	o.Status.VaultAuthGeneration = c.GetVaultAuthObj().Generation
+	o.Status.VaultConnectionGeneration = c.GetVaultConnectionObj().Generation
+	r.setSyncedCondition(o, true, reason, "Secret synced")
 	if err := r.updateStatus(ctx, o); err != nil {
 		logger.Error(err, "Failed to update the status")
 		return ctrl.Result{}, err
@@ -258,6 +315,19 @@ func (r *VaultPKISecretReconciler) Reconcile(ctx context.Context, req ctrl.Reque
 	}, nil
 }
 
+// isDestinationDrifted returns true if the destination Secret of o was deleted, or if its data has changed
+// since it was last synced. Errors are logged and treated as no drift.
+func (r *VaultPKISecretReconciler) isDestinationDrifted(ctx context.Context, o *secretsv1alpha1.VaultPKISecret) bool {
+	drifted, err := isDestinationDrifted(ctx, r.Client, destinationSecretsReader(r.Client, r.SecretsCache, o), o,
+		r.ValidateMACFunc, o.Status.SecretMAC)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to check the destination secret for drift")
+		return false
+	}
+
+	return drifted
+}
+
 func (r *VaultPKISecretReconciler) handleDeletion(ctx context.Context, l logr.Logger, s *secretsv1alpha1.VaultPKISecret) error {
 	l.Info("In deletion")
 	if controllerutil.ContainsFinalizer(s, vaultPKIFinalizer) {
@@ -295,12 +365,17 @@ func (r *VaultPKISecretReconciler) addFinalizer(ctx context.Context, l logr.Logg
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *VaultPKISecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&secretsv1alpha1.VaultPKISecret{}).
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&secretsv1alpha1.VaultPKISecret{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		// Add metrics for create/update/delete of the resource
 		Watches(&source.Kind{Type: &secretsv1alpha1.VaultPKISecret{}},
-			&handler.InstrumentedEnqueueRequestForObject{}).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
+			&handler.InstrumentedEnqueueRequestForObject{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	b = watchDependencies(b, r.Client, func() client.ObjectList {
+		return &secretsv1alpha1.VaultPKISecretList{}
+	})
+	return watchDestinationSecrets(b, r.SecretsCache, &secretsv1alpha1.VaultPKISecret{}).
 		Complete(r)
 }
 
@@ -332,9 +407,7 @@ func (r *VaultPKISecretReconciler) revokeCertificate(ctx context.Context, l logr
 
 	l.Info(fmt.Sprintf("Revoking certificate %q", s.Status.SerialNumber))
 
-	if _, err := c.Write(ctx, fmt.Sprintf("%s/revoke", s.Spec.Mount), map[string]interface{}{
-		"serial_number": s.Status.SerialNumber,
-	}); err != nil {
+	if err := revokePKICertificate(ctx, c, s.Spec.Mount, s.Status.SerialNumber); err != nil {
 		l.Error(err, "Failed to revoke certificate", "serial_number", s.Status.SerialNumber)
 		return err
 	}
@@ -342,18 +415,50 @@ func (r *VaultPKISecretReconciler) revokeCertificate(ctx context.Context, l logr
 	return nil
 }
 
-func (r *VaultPKISecretReconciler) getPath(spec secretsv1alpha1.VaultPKISecretSpec) string {
-	parts := []string{spec.Mount}
-	if spec.IssuerRef != "" {
-		parts = append(parts, "issuer", spec.IssuerRef)
+// getPKIIssuePath in Vault to issue a certificate for role from, using the issuer of issuerRef if it is set.
+func getPKIIssuePath(mount, issuerRef, role string) string {
+	parts := []string{mount}
+	if issuerRef != "" {
+		parts = append(parts, "issuer", issuerRef)
 	} else {
 		parts = append(parts, "issue")
 	}
-	parts = append(parts, spec.Name)
+	parts = append(parts, role)
 
 	return strings.Join(parts, "/")
 }
 
+// issuePKICertificate from path in Vault, returning the response along with the issued certificate.
+func issuePKICertificate(ctx context.Context, c vault.Client, path string, data map[string]interface{}) (*api.Secret, *vault.PKICertResponse, error) {
+	resp, err := c.Write(ctx, path, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if resp == nil {
+		return nil, nil, fmt.Errorf("empty Vault secret at path %s", path)
+	}
+
+	certResp, err := vault.UnmarshalPKIIssueResponse(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if certResp.SerialNumber == "" {
+		return nil, nil, errors.New("invalid Vault secret data, serial_number cannot be empty")
+	}
+
+	return resp, certResp, nil
+}
+
+// revokePKICertificate of serialNumber, issued from mount in Vault.
+func revokePKICertificate(ctx context.Context, c vault.Client, mount, serialNumber string) error {
+	_, err := c.Write(ctx, fmt.Sprintf("%s/revoke", mount), map[string]interface{}{
+		"serial_number": serialNumber,
+	})
+	return err
+}
+
 func (r *VaultPKISecretReconciler) recordEvent(p *secretsv1alpha1.VaultPKISecret, reason, msg string, i ...interface{}) {
 	eventType := corev1.EventTypeNormal
 	if !p.Status.Valid {
@@ -363,8 +468,16 @@ func (r *VaultPKISecretReconciler) recordEvent(p *secretsv1alpha1.VaultPKISecret
 	r.Recorder.Eventf(p, eventType, reason, msg, i...)
 }
 
+// setSyncedCondition sets the Synced condition, along with the Ready condition which depends on it.
+func (r *VaultPKISecretReconciler) setSyncedCondition(p *secretsv1alpha1.VaultPKISecret, ok bool, reason, message string) {
+	setCondition(&p.Status.Conditions, p.Generation, consts.ConditionTypeSynced, ok, reason, message)
+	setReadyCondition(&p.Status.Conditions, p.Generation,
+		consts.ConditionTypeAuthValid, consts.ConditionTypeSynced)
+}
+
 func (r *VaultPKISecretReconciler) updateStatus(ctx context.Context, p *secretsv1alpha1.VaultPKISecret) error {
 	logger := log.FromContext(ctx)
+	p.Status.ObservedGeneration = p.Generation
 	metrics.SetResourceStatus("vaultpkisecret", p, p.Status.Valid)
 	if err := r.Status().Update(ctx, p); err != nil {
 		msg := "Failed to update the resource's status"
@@ -0,0 +1,675 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+	"github.com/hashicorp/vault-secrets-operator/internal/consts"
+	"github.com/hashicorp/vault-secrets-operator/internal/helpers"
+	"github.com/hashicorp/vault-secrets-operator/internal/vault"
+)
+
+const vaultSecretBundleFinalizer = "vaultsecretbundles.secrets.hashicorp.com/finalizer"
+
+// VaultSecretBundleReconciler reconciles a VaultSecretBundle object
+type VaultSecretBundleReconciler struct {
+	client.Client
+	Scheme        *runtime.Scheme
+	ClientFactory vault.ClientFactory
+	Recorder      record.EventRecorder
+	// HMACFunc and ValidateMACFunc are used to detect drift in the destination Secret's data,
+	// only its deletion is detected if they are not set.
+	HMACFunc        vault.HMACFromSecretFunc
+	ValidateMACFunc vault.ValidateMACFromSecretFunc
+	// SecretsCache holds the destination Secrets that are watched for drift, in which case every
+	// source is synced again right away. The destination Secrets are not watched if it is nil.
+	SecretsCache cache.Cache
+}
+
+//+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultsecretbundles,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultsecretbundles/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultsecretbundles/finalizers,verbs=update
+//+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=secrettransformations,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
+//
+// required for rollout-restart
+//+kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;patch
+//+kubebuilder:rbac:groups=apps,resources=daemonsets,verbs=get;list;watch;patch
+//
+
+// Reconcile merges the data of each of the VaultSecretBundle's sources into a single destination Secret.
+// Static sources are read on every reconciliation, whereas Dynamic and PKI sources are only requested from
+// Vault once they are due for rotation, their previous data is otherwise carried over from the destination
+// Secret. The RolloutRestartTargets are restarted once whenever the merged data has changed.
+//
+// Every source is synced again whenever the resource's spec or its VaultAuth has changed, or the destination
+// Secret is deleted, or its data is changed out-of-band. The lease of a Dynamic source is revoked once it has
+// been replaced, as is the certificate of a PKI source if the resource is configured to Revoke it.
+func (r *VaultSecretBundleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	o := &secretsv1alpha1.VaultSecretBundle{}
+	if err := r.Client.Get(ctx, req.NamespacedName, o); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+
+		logger.Error(err, "Failed to get VaultSecretBundle resource", "resource", req.NamespacedName)
+		return ctrl.Result{}, err
+	}
+
+	if o.GetDeletionTimestamp() != nil {
+		logger.Info("Got deletion timestamp", "obj", o)
+		return ctrl.Result{}, r.handleDeletion(ctx, o)
+	}
+
+	if err := r.addFinalizer(ctx, o); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var refreshAfter time.Duration
+	if o.Spec.RefreshAfter != "" {
+		d, err := time.ParseDuration(o.Spec.RefreshAfter)
+		if err != nil {
+			msg := fmt.Sprintf("Failed to parse RefreshAfter %q: %s", o.Spec.RefreshAfter, err)
+			logger.Error(err, "Failed to parse RefreshAfter")
+			r.Recorder.Event(o, corev1.EventTypeWarning, consts.ReasonInvalidConfiguration, msg)
+			r.setSyncedCondition(o, false, consts.ReasonInvalidConfiguration, msg)
+			return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+		}
+		refreshAfter = d
+	}
+
+	for i, src := range o.Spec.Sources {
+		if err := validateBundleSource(src); err != nil {
+			err = fmt.Errorf("invalid source %d: %w", i, err)
+			logger.Error(err, "")
+			r.Recorder.Event(o, corev1.EventTypeWarning, consts.ReasonInvalidConfiguration, err.Error())
+			r.setSyncedCondition(o, false, consts.ReasonInvalidConfiguration, err.Error())
+			return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+		}
+	}
+
+	c, err := r.ClientFactory.Get(ctx, r.Client, o)
+	if err != nil {
+		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientConfigError,
+			"Failed to get Vault auth login: %s", err)
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, false,
+			consts.ReasonVaultClientConfigError, fmt.Sprintf("Failed to get Vault auth login: %s", err))
+		setReadyCondition(&o.Status.Conditions, o.Generation,
+			consts.ConditionTypeAuthValid, consts.ConditionTypeSynced)
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+	}
+	setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, true,
+		consts.ReasonAccepted, "Vault auth login succeeded")
+
+	dest, destExists, err := helpers.GetSecret(ctx, r.Client, o)
+	if err != nil {
+		r.setSyncedCondition(o, false, consts.ReasonK8sClientError,
+			fmt.Sprintf("Failed to get the destination secret: %s", err))
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+	}
+
+	authGeneration := c.GetVaultAuthObj().Generation
+	connGeneration := c.GetVaultConnectionObj().Generation
+	// every source is synced again once the spec has changed, the ObservedGeneration is only updated once the
+	// sync succeeds, so that this still holds should it fail.
+	syncAll := !destExists ||
+		o.Status.ObservedGeneration != o.Generation ||
+		o.Status.VaultAuthGeneration != authGeneration ||
+		// the VaultConnection generation is not compared when the status predates it being recorded.
+		(o.Status.VaultConnectionGeneration != 0 && o.Status.VaultConnectionGeneration != connGeneration) ||
+		len(o.Status.Sources) != len(o.Spec.Sources)
+	if !syncAll && r.isDestinationDrifted(ctx, o) {
+		r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretDrifted,
+			"Destination secret %s drifted, syncing all sources", o.Spec.Destination.Name)
+		syncAll = true
+	}
+
+	now := time.Now()
+	sourcesData := make([]map[string][]byte, len(o.Spec.Sources))
+	statuses := make([]secretsv1alpha1.VaultSecretBundleSourceStatus, len(o.Spec.Sources))
+	prevStatuses := o.Status.Sources
+	// the leases and certificates that are requested by this reconciliation are not recorded anywhere until the
+	// destination Secret has been synced, so they are revoked should the sync fail before then.
+	var synced bool
+	defer func() {
+		if !synced {
+			_ = r.revokeSources(ctx, c, o, getReplacedSources(statuses, prevStatuses))
+		}
+	}()
+	var horizon time.Duration
+	for i, src := range o.Spec.Sources {
+		var last *secretsv1alpha1.VaultSecretBundleSourceStatus
+		if !syncAll {
+			last = &o.Status.Sources[i]
+		}
+
+		data, status, h, err := r.syncSource(ctx, c, src, last, dest, refreshAfter, now)
+		if err != nil {
+			err = fmt.Errorf("failed to sync source %d: %w", i, err)
+			logger.Error(err, "")
+			r.Recorder.Event(o, corev1.EventTypeWarning, consts.ReasonVaultClientError, err.Error())
+			r.setSyncedCondition(o, false, consts.ReasonVaultClientError, err.Error())
+			return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+		}
+
+		sourcesData[i] = data
+		statuses[i] = *status
+		if h > 0 && (horizon == 0 || h < horizon) {
+			horizon = h
+		}
+	}
+
+	data, keys, err := mergeBundleData(o.Spec.Sources, sourcesData, o.Spec.ConflictPolicy)
+	if err != nil {
+		logger.Error(err, "Failed to merge the sources")
+		r.Recorder.Event(o, corev1.EventTypeWarning, consts.ReasonInvalidConfiguration, err.Error())
+		r.setSyncedCondition(o, false, consts.ReasonInvalidConfiguration, err.Error())
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+	}
+	for i := range statuses {
+		statuses[i].Keys = keys[i]
+	}
+
+	if err := r.validateTransformation(ctx, o, data); err != nil {
+		logger.Error(err, "Invalid transformation")
+		r.Recorder.Event(o, corev1.EventTypeWarning, consts.ReasonInvalidConfiguration, err.Error())
+		r.setSyncedCondition(o, false, consts.ReasonInvalidConfiguration, err.Error())
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+	}
+
+	data, err = helpers.TransformSecretData(ctx, r.Client, o, data, nil)
+	if err != nil {
+		logger.Error(err, "Failed to transform the secret data")
+		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonTransformationError,
+			"Failed to transform the secret data: %s", err)
+		r.setSyncedCondition(o, false, consts.ReasonTransformationError,
+			fmt.Sprintf("Failed to transform the secret data: %s", err))
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+	}
+
+	reason := consts.ReasonSecretSynced
+	if destExists && equality.Semantic.DeepEqual(dest.Data, data) {
+		r.Recorder.Event(o, corev1.EventTypeNormal, consts.ReasonSecretSync, "Secret sync not required")
+	} else {
+		var secretMAC string
+		if r.HMACFunc != nil {
+			secretMAC, err = computeSecretMAC(ctx, r.Client, r.HMACFunc, data)
+			if err != nil {
+				r.setSyncedCondition(o, false, consts.ReasonSecretSyncError,
+					fmt.Sprintf("Failed to compute the secret data HMAC: %s", err))
+				return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+			}
+		}
+
+		if err := helpers.SyncSecret(ctx, r.Client, o, data); err != nil {
+			r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonSecretSyncError,
+				"Failed to update k8s secret: %s", err)
+			r.setSyncedCondition(o, false, consts.ReasonSecretSyncError,
+				fmt.Sprintf("Failed to update k8s secret: %s", err))
+			return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+		}
+
+		if o.Status.LastSyncTime > 0 {
+			reason = consts.ReasonSecretRotated
+			// rollout-restart errors are not retryable
+			// all error reporting is handled by helpers.HandleRolloutRestarts
+			_, _ = helpers.HandleRolloutRestarts(ctx, r.Client, o, r.Recorder)
+		}
+		o.Status.LastSyncTime = now.Unix()
+		o.Status.SecretMAC = secretMAC
+		r.Recorder.Event(o, corev1.EventTypeNormal, reason, "Secret synced")
+	}
+
+	synced = true
+	o.Status.Sources = statuses
+	o.Status.VaultAuthGeneration = authGeneration
+	o.Status.VaultConnectionGeneration = connGeneration
+	o.Status.ObservedGeneration = o.Generation
+	r.setSyncedCondition(o, true, reason, "Secret synced")
+	if err := r.updateStatus(ctx, o); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the replaced leases and certificates are revoked once the destination Secret no longer holds them,
+	// failures are not retried, since they will expire on their own.
+	_ = r.revokeSources(ctx, c, o, getReplacedSources(prevStatuses, statuses))
+
+	if horizon > 0 {
+		return ctrl.Result{RequeueAfter: computeHorizonWithJitter(horizon)}, nil
+	}
+	return ctrl.Result{}, nil
+}
+
+// syncSource returns the data of src, along with its status, and the duration until it should be synced again.
+// The data of a Dynamic or PKI source is carried over from the destination Secret dest, as per its last status,
+// until it is due for rotation. Every source is requested from Vault if last is nil.
+func (r *VaultSecretBundleReconciler) syncSource(ctx context.Context, c vault.Client,
+	src secretsv1alpha1.VaultSecretBundleSource, last *secretsv1alpha1.VaultSecretBundleSourceStatus,
+	dest *corev1.Secret, refreshAfter time.Duration, now time.Time,
+) (map[string][]byte, *secretsv1alpha1.VaultSecretBundleSourceStatus, time.Duration, error) {
+	switch {
+	case src.Static != nil:
+		data, err := r.readStaticSource(ctx, c, src.Static)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		return data, &secretsv1alpha1.VaultSecretBundleSourceStatus{LastSyncTime: now.Unix()}, refreshAfter, nil
+	case src.Dynamic != nil:
+		percent := src.Dynamic.RotationPercent
+		if percent <= 0 {
+			percent = defaultRotationPercent
+		}
+
+		if last != nil && last.SecretLease != nil {
+			leaseDuration := time.Duration(last.SecretLease.LeaseDuration) * time.Second
+			rotateAt := time.Unix(last.LastSyncTime, 0).Add(leaseDuration * time.Duration(percent) / 100)
+			if data, ok := getBundleSourceData(dest, last.Keys, src.KeyPrefix); ok {
+				if leaseDuration <= 0 {
+					return data, last, 0, nil
+				}
+				if rotateAt.After(now) {
+					return data, last, rotateAt.Sub(now), nil
+				}
+			}
+		}
+
+		data, lease, err := r.requestDynamicSource(ctx, c, src.Dynamic)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		status := &secretsv1alpha1.VaultSecretBundleSourceStatus{
+			LastSyncTime: now.Unix(),
+			SecretLease:  lease,
+		}
+		leaseDuration := time.Duration(lease.LeaseDuration) * time.Second
+		return data, status, leaseDuration * time.Duration(percent) / 100, nil
+	case src.PKI != nil:
+		var expiryOffset time.Duration
+		if src.PKI.ExpiryOffset != "" {
+			d, err := time.ParseDuration(src.PKI.ExpiryOffset)
+			if err != nil {
+				return nil, nil, 0, fmt.Errorf("failed to parse ExpiryOffset %q: %w", src.PKI.ExpiryOffset, err)
+			}
+			expiryOffset = d
+		}
+
+		if last != nil && last.SerialNumber != "" {
+			if data, ok := getBundleSourceData(dest, last.Keys, src.KeyPrefix); ok {
+				if expiryOffset <= 0 {
+					return data, last, 0, nil
+				}
+				if !checkPKICertExpiry(last.Expiration, expiryOffset) {
+					return data, last, getRenewTime(last.Expiration, expiryOffset), nil
+				}
+			}
+		}
+
+		data, certResp, err := r.issuePKISource(ctx, c, src.PKI)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		status := &secretsv1alpha1.VaultSecretBundleSourceStatus{
+			LastSyncTime: now.Unix(),
+			SerialNumber: certResp.SerialNumber,
+			Mount:        src.PKI.Mount,
+			Expiration:   certResp.Expiration,
+		}
+		if expiryOffset <= 0 {
+			return data, status, 0, nil
+		}
+		return data, status, getRenewTime(certResp.Expiration, expiryOffset), nil
+	default:
+		return nil, nil, 0, errors.New("one of static, dynamic, or pki must be set")
+	}
+}
+
+func (r *VaultSecretBundleReconciler) readStaticSource(ctx context.Context, c vault.Client,
+	src *secretsv1alpha1.VaultSecretBundleStaticSource,
+) (map[string][]byte, error) {
+	var resp *api.KVSecret
+	var err error
+	switch src.Type {
+	case consts.KVSecretTypeV1:
+		resp, err = c.KVv1(src.Mount).Get(ctx, src.Name)
+	case consts.KVSecretTypeV2:
+		if src.Version > 0 {
+			resp, err = c.KVv2(src.Mount).GetVersion(ctx, src.Name, src.Version)
+		} else {
+			resp, err = c.KVv2(src.Mount).Get(ctx, src.Name)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported secret type %q", src.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if resp == nil {
+		return nil, fmt.Errorf("empty Vault secret, mount %s, name %s", src.Mount, src.Name)
+	}
+
+	data, err := makeK8sSecret(resp)
+	if err != nil {
+		return nil, err
+	}
+	delete(data, "_raw")
+
+	return data, nil
+}
+
+func (r *VaultSecretBundleReconciler) requestDynamicSource(ctx context.Context, c vault.Client,
+	src *secretsv1alpha1.VaultSecretBundleDynamicSource,
+) (map[string][]byte, *secretsv1alpha1.VaultSecretLease, error) {
+	p, err := getDynamicSecretPath(src.Mount, src.Path, src.Role)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := requestDynamicSecret(ctx, c, p,
+		getDynamicSecretRequestMethod(src.RequestHTTPMethod, src.Params), src.Params)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := vault.MarshalSecretData(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	delete(data, "_raw")
+
+	return data, getVaultSecretLease(resp), nil
+}
+
+func (r *VaultSecretBundleReconciler) issuePKISource(ctx context.Context, c vault.Client,
+	src *secretsv1alpha1.VaultSecretBundlePKISource,
+) (map[string][]byte, *vault.PKICertResponse, error) {
+	resp, certResp, err := issuePKICertificate(ctx, c,
+		getPKIIssuePath(src.Mount, src.IssuerRef, src.Name), src.GetIssuerAPIData())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := vault.MarshalSecretData(resp)
+	if err != nil {
+		return nil, nil, err
+	}
+	delete(data, "_raw")
+
+	return data, certResp, nil
+}
+
+// revokeSources revokes the leases of the Dynamic sources in sources, along with the certificates of the PKI
+// sources if o is configured to Revoke them. Every source is revoked, regardless of any failure, the failures
+// are recorded as Events and returned.
+func (r *VaultSecretBundleReconciler) revokeSources(ctx context.Context, c vault.Client,
+	o *secretsv1alpha1.VaultSecretBundle, sources []secretsv1alpha1.VaultSecretBundleSourceStatus,
+) error {
+	logger := log.FromContext(ctx)
+	var errs error
+	for _, src := range sources {
+		switch {
+		case src.SecretLease != nil && src.SecretLease.ID != "":
+			leaseID := src.SecretLease.ID
+			if err := revokeVaultLease(ctx, c, leaseID); err != nil {
+				logger.Error(err, "Failed to revoke lease", "lease_id", leaseID)
+				r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonSecretLeaseRevokeError,
+					"Failed to revoke lease, lease_id=%s, err=%s", leaseID, err)
+				errs = errors.Join(errs, err)
+				continue
+			}
+			r.Recorder.Eventf(o, corev1.EventTypeNormal, consts.ReasonSecretLeaseRevoke,
+				"Revoked lease, lease_id=%s", leaseID)
+		case src.SerialNumber != "" && src.Mount != "" && o.Spec.Revoke:
+			if err := revokePKICertificate(ctx, c, src.Mount, src.SerialNumber); err != nil {
+				logger.Error(err, "Failed to revoke certificate", "serial_number", src.SerialNumber)
+				r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientError,
+					"Failed to revoke certificate, serial_number=%s, err=%s", src.SerialNumber, err)
+				errs = errors.Join(errs, err)
+				continue
+			}
+			logger.Info("Revoked certificate", "serial_number", src.SerialNumber)
+		}
+	}
+
+	return errs
+}
+
+// handleDeletion revokes the leases and certificates of the sources, if the resource is configured to do so,
+// before removing the finalizer. The finalizer is kept if the revocation fails, so that it can be retried.
+func (r *VaultSecretBundleReconciler) handleDeletion(ctx context.Context, o *secretsv1alpha1.VaultSecretBundle) error {
+	logger := log.FromContext(ctx)
+	if !controllerutil.ContainsFinalizer(o, vaultSecretBundleFinalizer) {
+		return nil
+	}
+
+	if o.Spec.Revoke && len(o.Status.Sources) > 0 {
+		c, err := r.ClientFactory.Get(ctx, r.Client, o)
+		if err != nil {
+			logger.Error(err, "Failed to get Vault client")
+			r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientConfigError,
+				"Failed to get Vault client: %s", err)
+			return err
+		}
+
+		if err := r.revokeSources(ctx, c, o, o.Status.Sources); err != nil {
+			return err
+		}
+	}
+
+	if controllerutil.RemoveFinalizer(o, vaultSecretBundleFinalizer) {
+		if err := r.Update(ctx, o); err != nil {
+			logger.Error(err, "Failed to remove the finalizer")
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *VaultSecretBundleReconciler) addFinalizer(ctx context.Context, o *secretsv1alpha1.VaultSecretBundle) error {
+	if !controllerutil.ContainsFinalizer(o, vaultSecretBundleFinalizer) {
+		controllerutil.AddFinalizer(o, vaultSecretBundleFinalizer)
+		if err := r.Client.Update(ctx, o); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateTransformation ensures that the Destination.Transformation of o neither excludes, nor replaces,
+// any of the keys of the merged data of its sources, since the data of the Dynamic and PKI sources is carried
+// over from the destination Secret.
+func (r *VaultSecretBundleReconciler) validateTransformation(ctx context.Context, o *secretsv1alpha1.VaultSecretBundle,
+	data map[string][]byte,
+) error {
+	t := o.Spec.Destination.Transformation
+	if t == nil {
+		return nil
+	}
+
+	if len(t.Excludes) > 0 {
+		return errors.New("transformation excludes are not supported")
+	}
+
+	templates, err := helpers.GetTransformationTemplates(ctx, r.Client, o.Namespace, t)
+	if err != nil {
+		return err
+	}
+	for k := range templates {
+		if _, ok := data[k]; ok {
+			return fmt.Errorf("transformation template key %q is provided by a source", k)
+		}
+	}
+
+	return nil
+}
+
+// isDestinationDrifted returns true if the destination Secret of o was deleted, or if its data has changed
+// since it was last synced. Errors are logged and treated as no drift.
+func (r *VaultSecretBundleReconciler) isDestinationDrifted(ctx context.Context, o *secretsv1alpha1.VaultSecretBundle) bool {
+	drifted, err := isDestinationDrifted(ctx, r.Client, destinationSecretsReader(r.Client, r.SecretsCache, o), o,
+		r.ValidateMACFunc, o.Status.SecretMAC)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to check the destination secret for drift")
+		return false
+	}
+
+	return drifted
+}
+
+// setSyncedCondition sets the Synced condition, along with the Ready condition which depends on it.
+func (r *VaultSecretBundleReconciler) setSyncedCondition(o *secretsv1alpha1.VaultSecretBundle, ok bool, reason, message string) {
+	setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeSynced, ok, reason, message)
+	setReadyCondition(&o.Status.Conditions, o.Generation,
+		consts.ConditionTypeAuthValid, consts.ConditionTypeSynced)
+}
+
+func (r *VaultSecretBundleReconciler) updateStatus(ctx context.Context, o *secretsv1alpha1.VaultSecretBundle) error {
+	logger := log.FromContext(ctx)
+	if err := r.Status().Update(ctx, o); err != nil {
+		logger.Error(err, "Failed to update the resource's status")
+		return err
+	}
+	return nil
+}
+
+// validateBundleSource ensures that exactly one of the source's Static, Dynamic, or PKI is set.
+func validateBundleSource(src secretsv1alpha1.VaultSecretBundleSource) error {
+	var count int
+	if src.Static != nil {
+		count++
+	}
+	if src.Dynamic != nil {
+		count++
+	}
+	if src.PKI != nil {
+		count++
+	}
+	if count != 1 {
+		return errors.New("exactly one of static, dynamic, or pki must be set")
+	}
+	return nil
+}
+
+// getReplacedSources returns the sources whose lease, or certificate, is not held by any of the current sources.
+func getReplacedSources(sources, current []secretsv1alpha1.VaultSecretBundleSourceStatus) []secretsv1alpha1.VaultSecretBundleSourceStatus {
+	leaseIDs := make(map[string]bool)
+	serialNumbers := make(map[string]bool)
+	for _, src := range current {
+		if src.SecretLease != nil && src.SecretLease.ID != "" {
+			leaseIDs[src.SecretLease.ID] = true
+		}
+		if src.SerialNumber != "" {
+			serialNumbers[src.SerialNumber] = true
+		}
+	}
+
+	var replaced []secretsv1alpha1.VaultSecretBundleSourceStatus
+	for _, src := range sources {
+		switch {
+		case src.SecretLease != nil && src.SecretLease.ID != "":
+			if !leaseIDs[src.SecretLease.ID] {
+				replaced = append(replaced, src)
+			}
+		case src.SerialNumber != "":
+			if !serialNumbers[src.SerialNumber] {
+				replaced = append(replaced, src)
+			}
+		}
+	}
+
+	return replaced
+}
+
+// getBundleSourceData returns a source's data from the destination Secret dest, with keyPrefix removed from
+// each of its keys. It returns false if dest is nil, or if any of the keys are missing from it.
+func getBundleSourceData(dest *corev1.Secret, keys []string, keyPrefix string) (map[string][]byte, bool) {
+	if dest == nil {
+		return nil, false
+	}
+
+	data := make(map[string][]byte, len(keys))
+	for _, k := range keys {
+		v, ok := dest.Data[k]
+		if !ok {
+			return nil, false
+		}
+		data[strings.TrimPrefix(k, keyPrefix)] = v
+	}
+	return data, true
+}
+
+// mergeBundleData merges the data of each source, in order, prepending the source's KeyPrefix to each of its keys.
+// A key that is provided by more than one source is handled according to conflictPolicy. It returns the merged data,
+// along with the sorted keys that each source contributes to it.
+func mergeBundleData(sources []secretsv1alpha1.VaultSecretBundleSource, sourcesData []map[string][]byte,
+	conflictPolicy string,
+) (map[string][]byte, [][]string, error) {
+	data := make(map[string][]byte)
+	owners := make(map[string]int)
+	for i, src := range sources {
+		for k, v := range sourcesData[i] {
+			key := src.KeyPrefix + k
+			if j, ok := owners[key]; ok {
+				switch conflictPolicy {
+				case consts.ConflictPolicyFirst:
+					continue
+				case consts.ConflictPolicyLast:
+				default:
+					return nil, nil, fmt.Errorf("key %q is provided by both source %d and source %d", key, j, i)
+				}
+			}
+			owners[key] = i
+			data[key] = v
+		}
+	}
+
+	keys := make([][]string, len(sources))
+	for key, i := range owners {
+		keys[i] = append(keys[i], key)
+	}
+	for _, k := range keys {
+		sort.Strings(k)
+	}
+
+	return data, keys, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *VaultSecretBundleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&secretsv1alpha1.VaultSecretBundle{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	b = watchDependencies(b, r.Client, func() client.ObjectList {
+		return &secretsv1alpha1.VaultSecretBundleList{}
+	})
+	return watchDestinationSecrets(b, r.SecretsCache, &secretsv1alpha1.VaultSecretBundle{}).
+		Complete(r)
+}
@@ -0,0 +1,381 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+	"github.com/hashicorp/vault-secrets-operator/internal/consts"
+	"github.com/hashicorp/vault-secrets-operator/internal/helpers"
+)
+
+func Test_mergeBundleData(t *testing.T) {
+	sources := []secretsv1alpha1.VaultSecretBundleSource{
+		{},
+		{KeyPrefix: "db_"},
+		{},
+	}
+	sourcesData := []map[string][]byte{
+		{"username": []byte("app"), "url": []byte("https://example.com")},
+		{"username": []byte("v-dev"), "password": []byte("secret")},
+		{"url": []byte("https://other.example.com")},
+	}
+
+	tests := []struct {
+		name           string
+		conflictPolicy string
+		want           map[string][]byte
+		wantKeys       [][]string
+		wantErr        string
+	}{
+		{
+			name:           "error",
+			conflictPolicy: consts.ConflictPolicyError,
+			wantErr:        `key "url" is provided by both source 0 and source 2`,
+		},
+		{
+			name:    "default",
+			wantErr: `key "url" is provided by both source 0 and source 2`,
+		},
+		{
+			name:           "first",
+			conflictPolicy: consts.ConflictPolicyFirst,
+			want: map[string][]byte{
+				"username":    []byte("app"),
+				"url":         []byte("https://example.com"),
+				"db_username": []byte("v-dev"),
+				"db_password": []byte("secret"),
+			},
+			wantKeys: [][]string{{"url", "username"}, {"db_password", "db_username"}, nil},
+		},
+		{
+			name:           "last",
+			conflictPolicy: consts.ConflictPolicyLast,
+			want: map[string][]byte{
+				"username":    []byte("app"),
+				"url":         []byte("https://other.example.com"),
+				"db_username": []byte("v-dev"),
+				"db_password": []byte("secret"),
+			},
+			wantKeys: [][]string{{"username"}, {"db_password", "db_username"}, {"url"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, gotKeys, err := mergeBundleData(sources, sourcesData, tt.conflictPolicy)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantKeys, gotKeys)
+		})
+	}
+}
+
+func TestVaultSecretBundleReconciler_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	o := &secretsv1alpha1.VaultSecretBundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "bundle",
+			Namespace: "default",
+		},
+		Spec: secretsv1alpha1.VaultSecretBundleSpec{
+			Sources: []secretsv1alpha1.VaultSecretBundleSource{
+				{
+					KeyPrefix: "db_",
+					Dynamic: &secretsv1alpha1.VaultSecretBundleDynamicSource{
+						Mount: "db",
+						Role:  "dev",
+					},
+				},
+				{
+					KeyPrefix: "tls_",
+					PKI: &secretsv1alpha1.VaultSecretBundlePKISource{
+						Mount:        "pki",
+						Name:         "default",
+						CommonName:   "app.example.com",
+						ExpiryOffset: "1h",
+					},
+				},
+			},
+			Revoke: true,
+			Destination: secretsv1alpha1.Destination{
+				Name:   "dest",
+				Create: true,
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o).Build()
+	vaultClient := &stubVaultClient{
+		resp: &api.Secret{
+			LeaseID:       "lease",
+			LeaseDuration: 300,
+			Renewable:     true,
+			Data:          map[string]any{"password": "secret"},
+		},
+		writeResp: &api.Secret{
+			Data: map[string]any{
+				"certificate":   "cert",
+				"private_key":   "key",
+				"serial_number": "01",
+				"expiration":    time.Now().Add(time.Hour * 24).Unix(),
+			},
+		},
+		connObj: &secretsv1alpha1.VaultConnection{
+			ObjectMeta: metav1.ObjectMeta{Generation: 1},
+		},
+	}
+	r := &VaultSecretBundleReconciler{
+		Client:        c,
+		Recorder:      record.NewFakeRecorder(100),
+		ClientFactory: &stubClientFactory{client: vaultClient},
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)}
+
+	getBundle := func() *secretsv1alpha1.VaultSecretBundle {
+		var got secretsv1alpha1.VaultSecretBundle
+		require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+		return &got
+	}
+
+	// the initial sync requests every source.
+	result, err := r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.True(t, result.RequeueAfter > 0 && result.RequeueAfter <= time.Second*200)
+	assert.Equal(t, []string{"db/creds/dev"}, vaultClient.reads)
+	assert.Contains(t, vaultClient.writes, "pki/issue/default")
+
+	got := getBundle()
+	assert.Contains(t, got.Finalizers, vaultSecretBundleFinalizer)
+	require.Len(t, got.Status.Sources, 2)
+	assert.Equal(t, []string{"db_password"}, got.Status.Sources[0].Keys)
+	assert.Equal(t, "lease", got.Status.Sources[0].SecretLease.ID)
+	assert.Equal(t, "01", got.Status.Sources[1].SerialNumber)
+	assert.Equal(t, int64(1), got.Status.VaultConnectionGeneration)
+	dest, ok, err := helpers.GetSecret(ctx, c, got)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []byte("secret"), dest.Data["db_password"])
+	assert.Equal(t, []byte("cert"), dest.Data["tls_certificate"])
+	assert.NotContains(t, dest.Data, "db__raw")
+
+	// nothing is due, so the data of each source is carried over from the destination Secret.
+	vaultClient.reads = nil
+	vaultClient.writes = nil
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Empty(t, vaultClient.reads)
+	assert.Empty(t, vaultClient.writes)
+	assert.Equal(t, got.Status.Sources, getBundle().Status.Sources)
+
+	// the dynamic source is rotated once its lease is due, and its replaced lease is revoked,
+	// the PKI source is carried over.
+	got = getBundle()
+	got.Status.Sources[0].LastSyncTime = time.Now().Add(-time.Second * 250).Unix()
+	require.NoError(t, c.Status().Update(ctx, got))
+	vaultClient.resp.LeaseID = "lease-2"
+	vaultClient.resp.Data = map[string]any{"password": "rotated"}
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db/creds/dev"}, vaultClient.reads)
+	assert.Equal(t, map[string]map[string]any{
+		"/sys/leases/revoke": {"lease_id": "lease"},
+	}, vaultClient.writes)
+
+	got = getBundle()
+	cond := apimeta.FindStatusCondition(got.Status.Conditions, consts.ConditionTypeSynced)
+	require.NotNil(t, cond)
+	assert.Equal(t, consts.ReasonSecretRotated, cond.Reason)
+	dest, ok, err = helpers.GetSecret(ctx, c, got)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, []byte("rotated"), dest.Data["db_password"])
+	assert.Equal(t, []byte("cert"), dest.Data["tls_certificate"])
+
+	// every source is synced again once the destination Secret is deleted.
+	vaultClient.reads = nil
+	require.NoError(t, c.Delete(ctx, dest))
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db/creds/dev"}, vaultClient.reads)
+	assert.Contains(t, vaultClient.writes, "pki/issue/default")
+
+	// every source is synced again once the VaultConnection changes, the replaced lease and certificate
+	// are revoked.
+	vaultClient.reads = nil
+	vaultClient.writes = nil
+	vaultClient.connObj.Generation = 2
+	vaultClient.resp.LeaseID = "lease-3"
+	vaultClient.writeResp.Data["serial_number"] = "02"
+	_, err = r.Reconcile(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db/creds/dev"}, vaultClient.reads)
+	assert.Contains(t, vaultClient.writes, "pki/issue/default")
+	assert.Equal(t, map[string]any{"lease_id": "lease-2"}, vaultClient.writes["/sys/leases/revoke"])
+	assert.Equal(t, map[string]any{"serial_number": "01"}, vaultClient.writes["pki/revoke"])
+	assert.Equal(t, int64(2), getBundle().Status.VaultConnectionGeneration)
+}
+
+func TestVaultSecretBundleReconciler_Reconcile_revokeOnFailure(t *testing.T) {
+	ctx := context.Background()
+	// both sources provide the same key, so the merge fails after their leases have been requested.
+	o := &secretsv1alpha1.VaultSecretBundle{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "bundle",
+			Namespace: "default",
+		},
+		Spec: secretsv1alpha1.VaultSecretBundleSpec{
+			Sources: []secretsv1alpha1.VaultSecretBundleSource{
+				{
+					Dynamic: &secretsv1alpha1.VaultSecretBundleDynamicSource{
+						Mount: "db",
+						Role:  "dev",
+					},
+				},
+				{
+					Dynamic: &secretsv1alpha1.VaultSecretBundleDynamicSource{
+						Mount: "db",
+						Role:  "prod",
+					},
+				},
+			},
+			Destination: secretsv1alpha1.Destination{
+				Name:   "dest",
+				Create: true,
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o).Build()
+	vaultClient := &stubVaultClient{
+		resp: &api.Secret{
+			LeaseID:       "lease",
+			LeaseDuration: 300,
+			Renewable:     true,
+			Data:          map[string]any{"password": "secret"},
+		},
+	}
+	r := &VaultSecretBundleReconciler{
+		Client:        c,
+		Recorder:      record.NewFakeRecorder(100),
+		ClientFactory: &stubClientFactory{client: vaultClient},
+	}
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+	require.Error(t, err)
+	assert.Equal(t, []string{"db/creds/dev", "db/creds/prod"}, vaultClient.reads)
+	// the requested leases are not recorded anywhere, so they are revoked.
+	assert.Equal(t, map[string]map[string]any{
+		"/sys/leases/revoke": {"lease_id": "lease"},
+	}, vaultClient.writes)
+
+	var got secretsv1alpha1.VaultSecretBundle
+	require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+	assert.Empty(t, got.Status.Sources)
+}
+
+func TestVaultSecretBundleReconciler_handleDeletion(t *testing.T) {
+	ctx := context.Background()
+	sources := []secretsv1alpha1.VaultSecretBundleSourceStatus{
+		{SecretLease: &secretsv1alpha1.VaultSecretLease{ID: "db/creds/dev/foo"}},
+		{SerialNumber: "01", Mount: "pki"},
+		{},
+	}
+
+	tests := []struct {
+		name          string
+		revoke        bool
+		writeErr      error
+		wantWrites    map[string]map[string]any
+		wantFinalizer bool
+		wantErr       bool
+	}{
+		{
+			name: "no-revoke",
+		},
+		{
+			name:   "revoke",
+			revoke: true,
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "db/creds/dev/foo"},
+				"pki/revoke":         {"serial_number": "01"},
+			},
+		},
+		{
+			name:     "revoke-error",
+			revoke:   true,
+			writeErr: errors.New("permission denied"),
+			wantWrites: map[string]map[string]any{
+				"/sys/leases/revoke": {"lease_id": "db/creds/dev/foo"},
+				"pki/revoke":         {"serial_number": "01"},
+			},
+			wantFinalizer: true,
+			wantErr:       true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := metav1.Now()
+			o := &secretsv1alpha1.VaultSecretBundle{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              "bundle",
+					Namespace:         "default",
+					DeletionTimestamp: &now,
+					Finalizers:        []string{vaultSecretBundleFinalizer},
+				},
+				Spec: secretsv1alpha1.VaultSecretBundleSpec{
+					Revoke: tt.revoke,
+				},
+				Status: secretsv1alpha1.VaultSecretBundleStatus{
+					Sources: sources,
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o).Build()
+			vaultClient := &stubVaultClient{writeErr: tt.writeErr}
+			r := &VaultSecretBundleReconciler{
+				Client:        c,
+				Recorder:      record.NewFakeRecorder(10),
+				ClientFactory: &stubClientFactory{client: vaultClient},
+			}
+
+			err := r.handleDeletion(ctx, o)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantWrites, vaultClient.writes)
+
+			var got secretsv1alpha1.VaultSecretBundle
+			err = c.Get(ctx, client.ObjectKeyFromObject(o), &got)
+			if tt.wantFinalizer {
+				require.NoError(t, err)
+				assert.Contains(t, got.Finalizers, vaultSecretBundleFinalizer)
+			} else if err == nil {
+				assert.NotContains(t, got.Finalizers, vaultSecretBundleFinalizer)
+			} else {
+				assert.True(t, apierrors.IsNotFound(err))
+			}
+		})
+	}
+}
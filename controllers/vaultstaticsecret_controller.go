@@ -5,20 +5,34 @@ package controllers
 
 import (
 	"context"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"path"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/vault/api"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/json"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
 	"github.com/hashicorp/vault-secrets-operator/internal/consts"
@@ -34,11 +48,20 @@ type VaultStaticSecretReconciler struct {
 	ClientFactory   vault.ClientFactory
 	HMACFunc        vault.HMACFromSecretFunc
 	ValidateMACFunc vault.ValidateMACFromSecretFunc
+	// EventWatcher subscribes to the kv-v2 event notifications of each VaultConnection when set,
+	// any resource whose secret has changed is then synced right away, rather than on its next refresh.
+	EventWatcher vault.EventWatcher
+	// SecretsCache holds the destination Secrets that are watched for drift, in which case the resource
+	// is synced again right away. The destination Secrets are not watched if it is nil.
+	SecretsCache cache.Cache
+	// eventCh receives the resources whose secret has changed in Vault.
+	eventCh chan event.GenericEvent
 }
 
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultstaticsecrets,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultstaticsecrets/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=vaultstaticsecrets/finalizers,verbs=update
+//+kubebuilder:rbac:groups=secrets.hashicorp.com,resources=secrettransformations,verbs=get;list;watch
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
 //+kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create;update;patch
 //
@@ -54,6 +77,9 @@ func (r *VaultStaticSecretReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	o := &secretsv1alpha1.VaultStaticSecret{}
 	if err := r.Client.Get(ctx, req.NamespacedName, o); err != nil {
 		if apierrors.IsNotFound(err) {
+			if r.EventWatcher != nil {
+				r.EventWatcher.Unwatch(req.NamespacedName)
+			}
 			return ctrl.Result{}, nil
 		}
 
@@ -65,7 +91,25 @@ func (r *VaultStaticSecretReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	if err != nil {
 		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientConfigError,
 			"Failed to get Vault auth login: %s", err)
-		return ctrl.Result{}, err
+		setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, false,
+			consts.ReasonVaultClientConfigError, fmt.Sprintf("Failed to get Vault auth login: %s", err))
+		setReadyCondition(&o.Status.Conditions, o.Generation,
+			consts.ConditionTypeAuthValid, consts.ConditionTypeSynced)
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+	}
+	setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeAuthValid, true,
+		consts.ReasonAccepted, "Vault auth login succeeded")
+	authGeneration := c.GetVaultAuthObj().Generation
+
+	if r.EventWatcher != nil {
+		if o.Spec.Type == consts.KVSecretTypeV2 {
+			// polling continues regardless, in case an event is missed.
+			if err := r.EventWatcher.Watch(o, c); err != nil {
+				logger.V(consts.LogLevelWarning).Info("Failed to watch Vault events", "err", err)
+			}
+		} else {
+			r.EventWatcher.Unwatch(req.NamespacedName)
+		}
 	}
 
 	var requeueAfter time.Duration
@@ -75,46 +119,90 @@ func (r *VaultStaticSecretReconciler) Reconcile(ctx context.Context, req ctrl.Re
 			logger.Error(err, "Failed to parse o.Spec.RefreshAfter")
 			r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultStaticSecret,
 				"Failed to parse o.Spec.RefreshAfter %s", o.Spec.RefreshAfter)
-			return ctrl.Result{}, err
+			r.setSyncedCondition(o, false, consts.ReasonInvalidConfiguration,
+				fmt.Sprintf("Failed to parse o.Spec.RefreshAfter %s", o.Spec.RefreshAfter))
+			return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
 		}
 		requeueAfter = computeHorizonWithJitter(d)
 	}
 
+	if o.Spec.HMACSecretData && requeueAfter == 0 {
+		// we want to ensure that requeueAfter is set so that we can perform the proper drift detection during each reconciliation.
+		// setting up a watcher on the Secret is also possibility, but polling seems to be the simplest approach for now.
+		// hardcoding a default horizon here, perhaps we will want make this value public?
+		requeueAfter = computeHorizonWithJitter(time.Second * 60)
+	}
+
+	if o.Spec.Version > 0 && o.Spec.Type != consts.KVSecretTypeV2 {
+		err := fmt.Errorf("version is not supported by the secret type %q", o.Spec.Type)
+		logger.Error(err, "")
+		r.Recorder.Event(o, corev1.EventTypeWarning, consts.ReasonVaultStaticSecret, err.Error())
+		r.setSyncedCondition(o, false, consts.ReasonInvalidConfiguration, err.Error())
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+	}
+
+	// errors are handled by the sync, since the inputs are needed to render the destination Secret.
+	inputsHash, _ := computeSyncInputsHash(ctx, r.Client, o, c)
+
 	var resp *api.KVSecret
 	switch o.Spec.Type {
 	case consts.KVSecretTypeV1:
-		w, err := c.KVv1(o.Spec.Mount)
-		if err != nil {
-			return ctrl.Result{}, err
-		}
-		resp, err = w.Get(ctx, o.Spec.Name)
+		resp, err = c.KVv1(o.Spec.Mount).Get(ctx, o.Spec.Name)
 	case consts.KVSecretTypeV2:
-		w, err := c.KVv2(o.Spec.Mount)
-		if err != nil {
-			return ctrl.Result{}, err
+		w := c.KVv2(o.Spec.Mount)
+		if o.Spec.Version > 0 {
+			resp, err = w.GetVersion(ctx, o.Spec.Name, o.Spec.Version)
+		} else if o.Status.VaultAuthGeneration == authGeneration && r.isSecretVersionCurrent(ctx, o, w, inputsHash) {
+			// a changed VaultAuth may resolve the secret in another Vault namespace, so it is always read.
+			r.Recorder.Event(o, corev1.EventTypeNormal, consts.ReasonSecretSync, "Secret sync not required")
+			r.setSyncedCondition(o, true, consts.ReasonSecretSynced, "Secret synced")
+			return ctrl.Result{
+				RequeueAfter: requeueAfter,
+			}, r.updateStatus(ctx, o)
+		} else {
+			resp, err = w.Get(ctx, o.Spec.Name)
 		}
-		resp, err = w.Get(ctx, o.Spec.Name)
 	default:
 		err = fmt.Errorf("unsupported secret type %q", o.Spec.Type)
 		logger.Error(err, "")
 		r.Recorder.Event(o, corev1.EventTypeWarning, consts.ReasonVaultStaticSecret, err.Error())
-		return ctrl.Result{}, err
+		r.setSyncedCondition(o, false, consts.ReasonInvalidConfiguration, err.Error())
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
 	}
 
 	if err != nil {
 		logger.Error(err, "Failed to read Vault secret")
 		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientError,
 			"Failed to read Vault secret: %s", err)
-		return ctrl.Result{}, nil
+		r.setSyncedCondition(o, false, consts.ReasonVaultClientError,
+			fmt.Sprintf("Failed to read Vault secret: %s", err))
+		return ctrl.Result{}, r.updateStatus(ctx, o)
 	}
 
 	if resp == nil {
 		logger.Error(nil, "empty Vault secret", "mount", o.Spec.Mount, "name", o.Spec.Name)
 		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientError,
 			"Vault secret was empty, mount %s, name %s", o.Spec.Mount, o.Spec.Name)
+		r.setSyncedCondition(o, false, consts.ReasonVaultClientError,
+			fmt.Sprintf("Vault secret was empty, mount %s, name %s", o.Spec.Mount, o.Spec.Name))
 		return ctrl.Result{
 			RequeueAfter: requeueAfter,
-		}, nil
+		}, r.updateStatus(ctx, o)
+	}
+
+	if o.Spec.Type == consts.KVSecretTypeV2 {
+		o.Status.SecretVersion = getSecretVersion(resp)
+		// the destination is left as is, rather than having its data removed.
+		if v := o.Status.SecretVersion; v != nil && (v.DeletionTime != nil || v.Destroyed) {
+			msg := fmt.Sprintf("Vault secret version %d is deleted, mount %s, name %s", v.Version, o.Spec.Mount, o.Spec.Name)
+			logger.Error(nil, msg)
+			r.Recorder.Event(o, corev1.EventTypeWarning, consts.ReasonSecretVersionDeleted, msg)
+			r.setSyncedCondition(o, false, consts.ReasonSecretVersionDeleted, msg)
+			o.Status.SyncInputsHash = ""
+			return ctrl.Result{
+				RequeueAfter: requeueAfter,
+			}, r.updateStatus(ctx, o)
+		}
 	}
 
 	data, err := makeK8sSecret(resp)
@@ -122,28 +210,50 @@ func (r *VaultStaticSecretReconciler) Reconcile(ctx context.Context, req ctrl.Re
 		logger.Error(err, "Failed to construct k8s secret")
 		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonVaultClientError,
 			"Failed to construct k8s secret: %s", err)
-		return ctrl.Result{}, err
+		r.setSyncedCondition(o, false, consts.ReasonVaultClientError,
+			fmt.Sprintf("Failed to construct k8s secret: %s", err))
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+	}
+
+	data, err = helpers.TransformSecretData(ctx, r.Client, o, data, resp.Data)
+	if err != nil {
+		logger.Error(err, "Failed to transform the secret data")
+		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonTransformationError,
+			"Failed to transform the secret data: %s", err)
+		r.setSyncedCondition(o, false, consts.ReasonTransformationError,
+			fmt.Sprintf("Failed to transform the secret data: %s", err))
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
+	}
+
+	labels, annotations, err := getCustomMetadata(o, resp.CustomMetadata)
+	if err != nil {
+		logger.Error(err, "Invalid custom_metadata")
+		r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonInvalidConfiguration,
+			"Invalid custom_metadata: %s", err)
+		r.setSyncedCondition(o, false, consts.ReasonInvalidConfiguration,
+			fmt.Sprintf("Invalid custom_metadata: %s", err))
+		return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
 	}
 
 	var doRolloutRestart bool
 	syncSecret := true
 	if o.Spec.HMACSecretData {
-		// we want to ensure that requeueAfter is set so that we can perform the proper drift detection during each reconciliation.
-		// setting up a watcher on the Secret is also possibility, but polling seems to be the simplest approach for now.
-		if requeueAfter == 0 {
-			// hardcoding a default horizon here, perhaps we will want make this value public?
-			requeueAfter = computeHorizonWithJitter(time.Second * 60)
-		}
-
 		// doRolloutRestart only if this is not the first time this secret has been synced
 		doRolloutRestart = o.Status.SecretMAC != ""
 
 		macsEqual, messageMAC, err := r.handleSecretHMAC(ctx, o, data)
 		if err != nil {
-			return ctrl.Result{}, err
+			r.setSyncedCondition(o, false, consts.ReasonSecretSyncError,
+				fmt.Sprintf("Failed to compute the secret data HMAC: %s", err))
+			return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
 		}
 
 		syncSecret = !macsEqual
+		if macsEqual && r.customMetadataChanged(ctx, o, nil, labels, annotations) {
+			// only the destination Secret's labels or annotations need to be updated.
+			syncSecret = true
+			doRolloutRestart = false
+		}
 
 		o.Status.SecretMAC = base64.StdEncoding.EncodeToString(messageMAC)
 	} else if len(o.Spec.RolloutRestartTargets) > 0 {
@@ -153,24 +263,29 @@ func (r *VaultStaticSecretReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	}
 
 	if syncSecret {
-		if err := helpers.SyncSecret(ctx, r.Client, o, data); err != nil {
+		if err := helpers.SyncSecretWithMetadata(ctx, r.Client, o, data, labels, annotations); err != nil {
 			r.Recorder.Eventf(o, corev1.EventTypeWarning, consts.ReasonSecretSyncError,
 				"Failed to update k8s secret: %s", err)
-			return ctrl.Result{}, err
+			r.setSyncedCondition(o, false, consts.ReasonSecretSyncError,
+				fmt.Sprintf("Failed to update k8s secret: %s", err))
+			return ctrl.Result{}, errors.Join(err, r.updateStatus(ctx, o))
 		}
 		reason := consts.ReasonSecretSynced
 		if doRolloutRestart {
 			reason = consts.ReasonSecretRotated
 			// rollout-restart errors are not retryable
 			// all error reporting is handled by helpers.HandleRolloutRestarts
-			_ = helpers.HandleRolloutRestarts(ctx, r.Client, o, r.Recorder)
+			_, _ = helpers.HandleRolloutRestarts(ctx, r.Client, o, r.Recorder)
 		}
 		r.Recorder.Event(o, corev1.EventTypeNormal, reason, "Secret synced")
 	} else {
 		r.Recorder.Event(o, corev1.EventTypeNormal, consts.ReasonSecretSync, "Secret sync not required")
 	}
 
-	if err := r.Status().Update(ctx, o); err != nil {
+	o.Status.VaultAuthGeneration = authGeneration
+	o.Status.SyncInputsHash = inputsHash
+	r.setSyncedCondition(o, true, consts.ReasonSecretSynced, "Secret synced")
+	if err := r.updateStatus(ctx, o); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -179,6 +294,190 @@ func (r *VaultStaticSecretReconciler) Reconcile(ctx context.Context, req ctrl.Re
 	}, nil
 }
 
+// computeSyncInputsHash returns the hex encoded SHA-256 of the inputs, other than the Vault secret itself,
+// that the destination Secret of o is rendered from: its spec, the spec of the VaultConnection of vc, and the
+// templates of its Transformation, including the ones of the referenced SecretTransformations.
+func computeSyncInputsHash(ctx context.Context, c client.Client, o *secretsv1alpha1.VaultStaticSecret, vc vault.Client) (string, error) {
+	inputs := struct {
+		Spec       secretsv1alpha1.VaultStaticSecretSpec `json:"spec"`
+		Connection *secretsv1alpha1.VaultConnectionSpec  `json:"connection,omitempty"`
+		Templates  map[string]string                     `json:"templates,omitempty"`
+	}{
+		Spec: o.Spec,
+	}
+
+	if connObj := vc.GetVaultConnectionObj(); connObj != nil {
+		inputs.Connection = &connObj.Spec
+	}
+
+	if t := o.Spec.Destination.Transformation; t != nil {
+		templates, err := helpers.GetTransformationTemplates(ctx, c, o.Namespace, t)
+		if err != nil {
+			return "", err
+		}
+		inputs.Templates = templates
+	}
+
+	b, err := json.Marshal(inputs)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setSyncedCondition sets the Synced condition, along with the Ready condition which depends on it.
+func (r *VaultStaticSecretReconciler) setSyncedCondition(o *secretsv1alpha1.VaultStaticSecret, ok bool, reason, message string) {
+	setCondition(&o.Status.Conditions, o.Generation, consts.ConditionTypeSynced, ok, reason, message)
+	setReadyCondition(&o.Status.Conditions, o.Generation,
+		consts.ConditionTypeAuthValid, consts.ConditionTypeSynced)
+}
+
+func (r *VaultStaticSecretReconciler) updateStatus(ctx context.Context, o *secretsv1alpha1.VaultStaticSecret) error {
+	logger := log.FromContext(ctx)
+	o.Status.ObservedGeneration = o.Generation
+	if err := r.Status().Update(ctx, o); err != nil {
+		logger.Error(err, "Failed to update the resource's status")
+		return err
+	}
+	return nil
+}
+
+// OnEvent implements vault.EventHandler. It enqueues every kv-v2 resource for the secret that the event is for.
+// Resources are not matched on their VaultConnection, since they are synced only if the secret has changed.
+func (r *VaultStaticSecretReconciler) OnEvent(ctx context.Context, ev vault.Event) {
+	logger := log.FromContext(ctx).WithValues("eventType", ev.EventType, "path", ev.Path)
+
+	var l secretsv1alpha1.VaultStaticSecretList
+	if err := r.Client.List(ctx, &l); err != nil {
+		logger.Error(err, "Failed to list VaultStaticSecrets")
+		return
+	}
+
+	for _, item := range l.Items {
+		if item.Spec.Type != consts.KVSecretTypeV2 || !isEventForSecret(ev, item.Spec.Mount, item.Spec.Name) {
+			continue
+		}
+
+		logger.V(consts.LogLevelDebug).Info("Enqueuing for Vault event", "secret", client.ObjectKeyFromObject(&item))
+		o := &secretsv1alpha1.VaultStaticSecret{}
+		o.SetNamespace(item.Namespace)
+		o.SetName(item.Name)
+		select {
+		case r.eventCh <- event.GenericEvent{Object: o}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// isEventForSecret returns true if the kv-v2 event is for the secret name in mount.
+func isEventForSecret(ev vault.Event, mount, name string) bool {
+	mount = strings.Trim(mount, "/")
+	if mount != ev.MountPath {
+		return false
+	}
+
+	return ev.Path == path.Join(mount, "data", strings.Trim(name, "/"))
+}
+
+// isSecretVersionCurrent returns true if the current version of the kv-v2 secret, as per its metadata, is the
+// one that was last synced, and neither the inputsHash, nor the destination Secret, have changed since.
+// In which case the secret's data does not need to be read from Vault. Errors reading the metadata are ignored,
+// since the secret may be readable without its metadata being so.
+func (r *VaultStaticSecretReconciler) isSecretVersionCurrent(ctx context.Context, o *secretsv1alpha1.VaultStaticSecret, w vault.KVv2Reader, inputsHash string) bool {
+	logger := log.FromContext(ctx)
+	last := o.Status.SecretVersion
+	if last == nil || inputsHash == "" || o.Status.SyncInputsHash != inputsHash {
+		return false
+	}
+
+	md, err := w.GetMetadata(ctx, o.Spec.Name)
+	if err != nil {
+		logger.V(consts.LogLevelDebug).Info("Failed to read the Vault secret metadata", "err", err)
+		return false
+	}
+	if md.CurrentVersion != last.Version {
+		logger.V(consts.LogLevelDebug).Info("Vault secret version changed",
+			"lastVersion", last.Version, "currentVersion", md.CurrentVersion)
+		return false
+	}
+
+	// deleting, or destroying, the current version does not result in a new version of the secret.
+	if v, ok := md.Versions[strconv.Itoa(md.CurrentVersion)]; ok {
+		if v.DeletionTime.IsZero() != (last.DeletionTime == nil) || v.Destroyed != last.Destroyed {
+			logger.V(consts.LogLevelDebug).Info("Vault secret version deleted",
+				"version", md.CurrentVersion, "deletionTime", v.DeletionTime, "destroyed", v.Destroyed)
+			return false
+		}
+	}
+
+	cur, ok, _ := helpers.GetSecret(ctx, r.Client, o)
+	if !ok {
+		return false
+	}
+
+	// custom_metadata changes do not result in a new version of the secret.
+	labels, annotations, err := getCustomMetadata(o, md.CustomMetadata)
+	if err != nil || r.customMetadataChanged(ctx, o, cur, labels, annotations) {
+		return false
+	}
+
+	if !o.Spec.HMACSecretData {
+		return true
+	}
+
+	if o.Status.SecretMAC == "" {
+		return false
+	}
+
+	curMessage, err := json.Marshal(cur.Data)
+	if err != nil {
+		return false
+	}
+
+	lastMAC, err := base64.StdEncoding.DecodeString(o.Status.SecretMAC)
+	if err != nil {
+		return false
+	}
+
+	valid, _, err := r.ValidateMACFunc(ctx, r.Client, curMessage, lastMAC)
+	if err != nil {
+		return false
+	}
+	if !valid {
+		logger.V(consts.LogLevelDebug).Info("Secret data drift detected", "lastMAC", lastMAC)
+	}
+
+	return valid
+}
+
+// customMetadataChanged returns true if the labels or annotations of the destination Secret cur do not match
+// the ones derived from the secret's custom_metadata. The destination Secret is fetched if cur is nil.
+func (r *VaultStaticSecretReconciler) customMetadataChanged(ctx context.Context, o *secretsv1alpha1.VaultStaticSecret,
+	cur *corev1.Secret, labels, annotations map[string]string,
+) bool {
+	if o.Spec.CustomMetadata == nil || !o.Spec.Destination.Create {
+		return false
+	}
+
+	if cur == nil {
+		var ok bool
+		if cur, ok, _ = helpers.GetSecret(ctx, r.Client, o); !ok {
+			return true
+		}
+	}
+
+	meta, err := helpers.NewSyncableSecretMetaData(o)
+	if err != nil {
+		return true
+	}
+
+	return !equality.Semantic.DeepEqual(cur.Labels, helpers.SecretLabels(meta, labels)) ||
+		!equality.Semantic.DeepEqual(cur.Annotations, helpers.SecretAnnotations(meta, annotations))
+}
+
 // handleSecretHMAC compares the HMAC of data to its previously computed value stored in o.Status.SecretHMAC,
 // returning true if they are equal. The computed new-MAC will be returned so that o.Status.SecretHMAC can be updated.
 func (r *VaultStaticSecretReconciler) handleSecretHMAC(ctx context.Context, o *secretsv1alpha1.VaultStaticSecret, data map[string][]byte) (bool, []byte, error) {
@@ -239,7 +538,85 @@ func (r *VaultStaticSecretReconciler) handleSecretHMAC(ctx context.Context, o *s
 	return macsEqual, newMAC, nil
 }
 
-// SetupWithManager sets up the controller with the Manager.
+// getCustomMetadata returns the labels and annotations for the destination Secret, from the keys of the kv-v2
+// secret's customMetadata that are configured in o.Spec.CustomMetadata.
+func getCustomMetadata(o *secretsv1alpha1.VaultStaticSecret, customMetadata map[string]any) (map[string]string, map[string]string, error) {
+	m := o.Spec.CustomMetadata
+	if m == nil || !o.Spec.Destination.Create {
+		return nil, nil, nil
+	}
+
+	labels, err := mapCustomMetadata(customMetadata, m.Labels, m.Prefix, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	annotations, err := mapCustomMetadata(customMetadata, m.Annotations, m.Prefix, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return labels, annotations, nil
+}
+
+// mapCustomMetadata returns the values of keys in customMetadata, keyed by the prefixed key.
+// Both the keys and values must be valid for labels if isLabel is set, otherwise the keys must be
+// valid for annotations.
+func mapCustomMetadata(customMetadata map[string]any, keys []string, prefix string, isLabel bool) (map[string]string, error) {
+	var errs error
+	result := make(map[string]string)
+	for _, k := range keys {
+		v, ok := customMetadata[k]
+		if !ok {
+			continue
+		}
+
+		value, ok := v.(string)
+		if !ok {
+			value = fmt.Sprintf("%v", v)
+		}
+
+		key := prefix + k
+		if msgs := validation.IsQualifiedName(key); len(msgs) > 0 {
+			errs = errors.Join(errs, fmt.Errorf("invalid key %q: %s", key, strings.Join(msgs, "; ")))
+			continue
+		}
+		if isLabel {
+			if msgs := validation.IsValidLabelValue(value); len(msgs) > 0 {
+				errs = errors.Join(errs, fmt.Errorf("invalid label value for key %q: %s", key, strings.Join(msgs, "; ")))
+				continue
+			}
+		}
+
+		result[key] = value
+	}
+
+	return result, errs
+}
+
+// getSecretVersion returns the version metadata of a kv-v2 secret, nil if there is none.
+func getSecretVersion(vaultSecret *api.KVSecret) *secretsv1alpha1.VaultSecretVersion {
+	m := vaultSecret.VersionMetadata
+	if m == nil {
+		return nil
+	}
+
+	v := &secretsv1alpha1.VaultSecretVersion{
+		Version:   m.Version,
+		Destroyed: m.Destroyed,
+	}
+	if !m.CreatedTime.IsZero() {
+		t := metav1.NewTime(m.CreatedTime)
+		v.CreatedTime = &t
+	}
+	if !m.DeletionTime.IsZero() {
+		t := metav1.NewTime(m.DeletionTime)
+		v.DeletionTime = &t
+	}
+
+	return v
+}
+
 func makeK8sSecret(vaultSecret *api.KVSecret) (map[string][]byte, error) {
 	if vaultSecret.Raw == nil {
 		return nil, fmt.Errorf("raw portion of vault secret was nil")
@@ -271,9 +648,16 @@ func makeK8sSecret(vaultSecret *api.KVSecret) (map[string][]byte, error) {
 	return k8sSecretData, nil
 }
 
+// SetupWithManager sets up the controller with the Manager.
 func (r *VaultStaticSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&secretsv1alpha1.VaultStaticSecret{}).
-		WithEventFilter(predicate.GenerationChangedPredicate{}).
+	r.eventCh = make(chan event.GenericEvent)
+	b := ctrl.NewControllerManagedBy(mgr).
+		For(&secretsv1alpha1.VaultStaticSecret{},
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Channel{Source: r.eventCh}, &handler.EnqueueRequestForObject{})
+	b = watchDependencies(b, r.Client, func() client.ObjectList {
+		return &secretsv1alpha1.VaultStaticSecretList{}
+	})
+	return watchDestinationSecrets(b, r.SecretsCache, &secretsv1alpha1.VaultStaticSecret{}).
 		Complete(r)
 }
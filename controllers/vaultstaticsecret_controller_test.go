@@ -4,11 +4,34 @@
 package controllers
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+	"github.com/hashicorp/vault-secrets-operator/internal/consts"
+	"github.com/hashicorp/vault-secrets-operator/internal/helpers"
+	"github.com/hashicorp/vault-secrets-operator/internal/vault"
 )
 
 func Test_makeK8sSecret(t *testing.T) {
@@ -119,3 +142,713 @@ func Test_makeK8sSecret(t *testing.T) {
 		})
 	}
 }
+
+// stubKVClient returns KV clients for the Vault API client.
+// Any other vault.Client method will panic.
+type stubKVClient struct {
+	vault.Client
+	client *api.Client
+	// connObj is returned by GetVaultConnectionObj, an empty VaultConnection is returned when unset.
+	connObj *secretsv1alpha1.VaultConnection
+}
+
+func (c *stubKVClient) GetVaultAuthObj() *secretsv1alpha1.VaultAuth {
+	return &secretsv1alpha1.VaultAuth{}
+}
+
+func (c *stubKVClient) GetVaultConnectionObj() *secretsv1alpha1.VaultConnection {
+	if c.connObj == nil {
+		return &secretsv1alpha1.VaultConnection{}
+	}
+	return c.connObj
+}
+
+func (c *stubKVClient) KVv1(mount string) vault.KVv1Reader {
+	return c.client.KVv1(mount)
+}
+
+func (c *stubKVClient) KVv2(mount string) vault.KVv2Reader {
+	return c.client.KVv2(mount)
+}
+
+// kvSecret served by newKVv2Server.
+type kvSecret struct {
+	// versions of the secret, the last version being the current one.
+	versions       []kvVersion
+	customMetadata map[string]any
+	// metadataDenied denies reading the secret's metadata.
+	metadataDenied bool
+}
+
+// kvVersion of a kvSecret.
+type kvVersion struct {
+	data         map[string]any
+	createdTime  time.Time
+	deletionTime time.Time
+	destroyed    bool
+}
+
+// formatTime formats t as Vault does, the zero time is formatted as an empty string.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
+// newKVv2Server serves a single KV v2 secret. All requests are recorded in requests.
+func newKVv2Server(t *testing.T, secret kvSecret, requests *[]string) *api.Client {
+	t.Helper()
+
+	versions := secret.versions
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RequestURI())
+		w.Header().Set("Content-Type", "application/json")
+
+		if strings.HasPrefix(r.URL.Path, "/v1/kv/metadata/") {
+			if secret.metadataDenied {
+				w.WriteHeader(http.StatusForbidden)
+				_, _ = w.Write([]byte(`{"errors": ["permission denied"]}`))
+				return
+			}
+			mdVersions := make(map[string]any, len(versions))
+			for i, kv := range versions {
+				mdVersions[strconv.Itoa(i+1)] = map[string]any{
+					"created_time":  kv.createdTime.Format(time.RFC3339Nano),
+					"deletion_time": formatTime(kv.deletionTime),
+					"destroyed":     kv.destroyed,
+				}
+			}
+			require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+				"data": map[string]any{
+					"current_version": len(versions),
+					"custom_metadata": secret.customMetadata,
+					"versions":        mdVersions,
+				},
+			}))
+			return
+		}
+
+		version := len(versions)
+		if v := r.URL.Query().Get("version"); v != "" {
+			require.NoError(t, json.Unmarshal([]byte(v), &version))
+		}
+		if version < 1 || version > len(versions) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		kv := versions[version-1]
+		data := kv.data
+		if !kv.deletionTime.IsZero() || kv.destroyed {
+			data = nil
+			w.WriteHeader(http.StatusNotFound)
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"data": data,
+				"metadata": map[string]any{
+					"created_time":    kv.createdTime.Format(time.RFC3339Nano),
+					"custom_metadata": secret.customMetadata,
+					"deletion_time":   formatTime(kv.deletionTime),
+					"destroyed":       kv.destroyed,
+					"version":         version,
+				},
+			},
+		}))
+	}))
+	t.Cleanup(server.Close)
+
+	config := api.DefaultConfig()
+	config.Address = server.URL
+	c, err := api.NewClient(config)
+	require.NoError(t, err)
+	return c
+}
+
+func TestVaultStaticSecretReconciler_Reconcile_version(t *testing.T) {
+	ctx := context.Background()
+	created := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	deleted := created.Add(time.Hour)
+	versions := []kvVersion{
+		{data: map[string]any{"password": "v1"}, createdTime: created, deletionTime: deleted},
+		{data: map[string]any{"password": "v2"}, createdTime: created.Add(time.Minute)},
+		{data: map[string]any{"password": "v3"}, createdTime: created.Add(2 * time.Minute)},
+	}
+
+	tests := []struct {
+		name         string
+		secretType   string
+		version      int
+		wantRequests []string
+		wantVersion  *secretsv1alpha1.VaultSecretVersion
+		wantData     []byte
+		wantReason   string
+		wantErr      string
+	}{
+		{
+			name:         "latest",
+			secretType:   consts.KVSecretTypeV2,
+			wantRequests: []string{"/v1/kv/data/app"},
+			wantVersion: &secretsv1alpha1.VaultSecretVersion{
+				Version:     3,
+				CreatedTime: &metav1.Time{Time: created.Add(2 * time.Minute)},
+			},
+			wantData:   []byte("v3"),
+			wantReason: consts.ReasonSecretSynced,
+		},
+		{
+			name:         "pinned",
+			secretType:   consts.KVSecretTypeV2,
+			version:      2,
+			wantRequests: []string{"/v1/kv/data/app?version=2"},
+			wantVersion: &secretsv1alpha1.VaultSecretVersion{
+				Version:     2,
+				CreatedTime: &metav1.Time{Time: created.Add(time.Minute)},
+			},
+			wantData:   []byte("v2"),
+			wantReason: consts.ReasonSecretSynced,
+		},
+		{
+			name:         "pinned-deleted",
+			secretType:   consts.KVSecretTypeV2,
+			version:      1,
+			wantRequests: []string{"/v1/kv/data/app?version=1"},
+			wantVersion: &secretsv1alpha1.VaultSecretVersion{
+				Version:      1,
+				CreatedTime:  &metav1.Time{Time: created},
+				DeletionTime: &metav1.Time{Time: deleted},
+			},
+			wantReason: consts.ReasonSecretVersionDeleted,
+		},
+		{
+			name:       "kv-v1-version",
+			secretType: consts.KVSecretTypeV1,
+			version:    1,
+			wantReason: consts.ReasonInvalidConfiguration,
+			wantErr:    `version is not supported by the secret type "kv-v1"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			vaultClient := newKVv2Server(t, kvSecret{versions: versions}, &requests)
+
+			o := &secretsv1alpha1.VaultStaticSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vss",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.VaultStaticSecretSpec{
+					Mount:   "kv",
+					Name:    "app",
+					Type:    tt.secretType,
+					Version: tt.version,
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: true,
+					},
+				},
+			}
+
+			c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o).Build()
+			r := &VaultStaticSecretReconciler{
+				Client:        c,
+				Recorder:      record.NewFakeRecorder(10),
+				ClientFactory: &stubClientFactory{client: &stubKVClient{client: vaultClient}},
+			}
+
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantRequests, requests)
+
+			var got secretsv1alpha1.VaultStaticSecret
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+			if tt.wantVersion != nil {
+				require.NotNil(t, got.Status.SecretVersion)
+				assert.Equal(t, tt.wantVersion.Version, got.Status.SecretVersion.Version)
+				assert.True(t, tt.wantVersion.CreatedTime.Equal(got.Status.SecretVersion.CreatedTime))
+				assert.True(t, tt.wantVersion.DeletionTime.Equal(got.Status.SecretVersion.DeletionTime))
+			} else {
+				assert.Nil(t, got.Status.SecretVersion)
+			}
+
+			cond := meta.FindStatusCondition(got.Status.Conditions, consts.ConditionTypeSynced)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.wantReason, cond.Reason)
+
+			var dest corev1.Secret
+			err = c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "dest"}, &dest)
+			if tt.wantData == nil {
+				assert.True(t, apierrors.IsNotFound(err))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantData, dest.Data["password"])
+			}
+		})
+	}
+}
+
+func TestVaultStaticSecretReconciler_Reconcile_versionCheck(t *testing.T) {
+	ctx := context.Background()
+	versions := []kvVersion{
+		{data: map[string]any{"password": "v1"}},
+		{data: map[string]any{"password": "v2"}},
+	}
+	// the MAC of a Secret's data is its JSON encoding, for the purpose of this test.
+	hmacFunc := func(_ context.Context, _ client.Client, message []byte) ([]byte, error) {
+		return message, nil
+	}
+	validateMACFunc := func(_ context.Context, _ client.Client, message, messageMAC []byte) (bool, []byte, error) {
+		return string(message) == string(messageMAC), message, nil
+	}
+	syncedData := map[string][]byte{"password": []byte("v2")}
+	syncedMAC, err := json.Marshal(syncedData)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name           string
+		hmac           bool
+		lastVersion    int
+		destData       map[string][]byte
+		metadataDenied bool
+		inputsChanged  bool
+		currentDeleted bool
+		currentDestroy bool
+		wantRequests   []string
+		wantDeleted    bool
+	}{
+		{
+			name:         "initial-sync",
+			wantRequests: []string{"/v1/kv/data/app"},
+		},
+		{
+			name:         "unchanged",
+			lastVersion:  2,
+			destData:     syncedData,
+			wantRequests: []string{"/v1/kv/metadata/app"},
+		},
+		{
+			name:         "unchanged-destination-missing",
+			lastVersion:  2,
+			wantRequests: []string{"/v1/kv/metadata/app", "/v1/kv/data/app"},
+		},
+		{
+			name:         "version-changed",
+			lastVersion:  1,
+			destData:     syncedData,
+			wantRequests: []string{"/v1/kv/metadata/app", "/v1/kv/data/app"},
+		},
+		{
+			name:           "metadata-denied",
+			lastVersion:    2,
+			destData:       syncedData,
+			metadataDenied: true,
+			wantRequests:   []string{"/v1/kv/metadata/app", "/v1/kv/data/app"},
+		},
+		{
+			name:         "hmac-unchanged",
+			hmac:         true,
+			lastVersion:  2,
+			destData:     syncedData,
+			wantRequests: []string{"/v1/kv/metadata/app"},
+		},
+		{
+			name:         "hmac-drift",
+			hmac:         true,
+			lastVersion:  2,
+			destData:     map[string][]byte{"password": []byte("changed")},
+			wantRequests: []string{"/v1/kv/metadata/app", "/v1/kv/data/app"},
+		},
+		{
+			name:          "inputs-changed",
+			lastVersion:   2,
+			destData:      syncedData,
+			inputsChanged: true,
+			wantRequests:  []string{"/v1/kv/data/app"},
+		},
+		{
+			name:           "current-version-deleted",
+			lastVersion:    2,
+			destData:       syncedData,
+			currentDeleted: true,
+			wantRequests:   []string{"/v1/kv/metadata/app", "/v1/kv/data/app"},
+			wantDeleted:    true,
+		},
+		{
+			name:           "current-version-destroyed",
+			lastVersion:    2,
+			destData:       syncedData,
+			currentDestroy: true,
+			wantRequests:   []string{"/v1/kv/metadata/app", "/v1/kv/data/app"},
+			wantDeleted:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []string
+			secretVersions := append([]kvVersion{}, versions...)
+			if tt.currentDeleted {
+				secretVersions[1].deletionTime = time.Now()
+			}
+			secretVersions[1].destroyed = tt.currentDestroy
+			vaultClient := newKVv2Server(t, kvSecret{
+				versions:       secretVersions,
+				metadataDenied: tt.metadataDenied,
+			}, &requests)
+			kvClient := &stubKVClient{client: vaultClient}
+
+			o := &secretsv1alpha1.VaultStaticSecret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "vss",
+					Namespace: "default",
+				},
+				Spec: secretsv1alpha1.VaultStaticSecretSpec{
+					Mount:          "kv",
+					Name:           "app",
+					Type:           consts.KVSecretTypeV2,
+					HMACSecretData: tt.hmac,
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: true,
+					},
+				},
+			}
+			if tt.lastVersion > 0 {
+				o.Status.SecretVersion = &secretsv1alpha1.VaultSecretVersion{Version: tt.lastVersion}
+				if tt.hmac {
+					o.Status.SecretMAC = base64.StdEncoding.EncodeToString(syncedMAC)
+				}
+				inputsHash, err := computeSyncInputsHash(ctx, nil, o, kvClient)
+				require.NoError(t, err)
+				o.Status.SyncInputsHash = inputsHash
+				if tt.inputsChanged {
+					o.Spec.Destination.Labels = map[string]string{"app": "demo"}
+				}
+			}
+
+			builder := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o)
+			if tt.destData != nil {
+				builder = builder.WithObjects(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "dest",
+						Namespace: "default",
+						Labels:    helpers.OwnerLabels,
+					},
+					Data: tt.destData,
+				})
+			}
+			c := builder.Build()
+			r := &VaultStaticSecretReconciler{
+				Client:          c,
+				Recorder:        record.NewFakeRecorder(10),
+				ClientFactory:   &stubClientFactory{client: kvClient},
+				HMACFunc:        hmacFunc,
+				ValidateMACFunc: validateMACFunc,
+			}
+
+			_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRequests, requests)
+
+			var got secretsv1alpha1.VaultStaticSecret
+			require.NoError(t, c.Get(ctx, client.ObjectKeyFromObject(o), &got))
+			require.NotNil(t, got.Status.SecretVersion)
+			assert.Equal(t, 2, got.Status.SecretVersion.Version)
+			if tt.wantDeleted {
+				cond := meta.FindStatusCondition(got.Status.Conditions, consts.ConditionTypeSynced)
+				require.NotNil(t, cond)
+				assert.Equal(t, metav1.ConditionFalse, cond.Status)
+				assert.Equal(t, consts.ReasonSecretVersionDeleted, cond.Reason)
+				assert.Empty(t, got.Status.SyncInputsHash)
+			} else {
+				assert.True(t, meta.IsStatusConditionTrue(got.Status.Conditions, consts.ConditionTypeSynced))
+				assert.NotEmpty(t, got.Status.SyncInputsHash)
+			}
+		})
+	}
+}
+
+func Test_computeSyncInputsHash(t *testing.T) {
+	ctx := context.Background()
+	st := &secretsv1alpha1.SecretTransformation{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "common",
+			Namespace: "default",
+		},
+		Spec: secretsv1alpha1.SecretTransformationSpec{
+			Templates: map[string]string{"url": "https://{{ .Secrets.host }}"},
+		},
+	}
+	o := &secretsv1alpha1.VaultStaticSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vss",
+			Namespace: "default",
+		},
+		Spec: secretsv1alpha1.VaultStaticSecretSpec{
+			Mount: "kv",
+			Name:  "app",
+			Type:  consts.KVSecretTypeV2,
+			Destination: secretsv1alpha1.Destination{
+				Name: "dest",
+				Transformation: &secretsv1alpha1.Transformation{
+					TransformationRefs: []secretsv1alpha1.TransformationRef{{Name: "common"}},
+				},
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(st).Build()
+	vc := &stubKVClient{
+		connObj: &secretsv1alpha1.VaultConnection{
+			Spec: secretsv1alpha1.VaultConnectionSpec{Address: "https://vault.example.com"},
+		},
+	}
+
+	want, err := computeSyncInputsHash(ctx, c, o, vc)
+	require.NoError(t, err)
+	got, err := computeSyncInputsHash(ctx, c, o, vc)
+	require.NoError(t, err)
+	assert.Equal(t, want, got, "hash must be stable")
+
+	// the spec changed
+	o.Spec.Destination.Labels = map[string]string{"app": "demo"}
+	got, err = computeSyncInputsHash(ctx, c, o, vc)
+	require.NoError(t, err)
+	assert.NotEqual(t, want, got)
+	want = got
+
+	// the VaultConnection changed
+	vc.connObj.Spec.Address = "https://vault-2.example.com"
+	got, err = computeSyncInputsHash(ctx, c, o, vc)
+	require.NoError(t, err)
+	assert.NotEqual(t, want, got)
+	want = got
+
+	// the referenced SecretTransformation changed
+	st.Spec.Templates["url"] = "https://{{ .Secrets.host }}:8200"
+	require.NoError(t, c.Update(ctx, st))
+	got, err = computeSyncInputsHash(ctx, c, o, vc)
+	require.NoError(t, err)
+	assert.NotEqual(t, want, got)
+
+	// the referenced SecretTransformation is missing
+	require.NoError(t, c.Delete(ctx, st))
+	_, err = computeSyncInputsHash(ctx, c, o, vc)
+	assert.Error(t, err)
+}
+
+func Test_getCustomMetadata(t *testing.T) {
+	customMetadata := map[string]any{
+		"owner":          "team-a",
+		"classification": "confidential",
+		"rotation":       "every 90 days",
+		"retention":      30,
+	}
+
+	tests := []struct {
+		name            string
+		mapping         *secretsv1alpha1.CustomMetadataMapping
+		create          bool
+		wantLabels      map[string]string
+		wantAnnotations map[string]string
+		wantErr         string
+	}{
+		{
+			name:   "no-mapping",
+			create: true,
+		},
+		{
+			name: "not-created",
+			mapping: &secretsv1alpha1.CustomMetadataMapping{
+				Labels: []string{"owner"},
+			},
+		},
+		{
+			name:   "prefixed",
+			create: true,
+			mapping: &secretsv1alpha1.CustomMetadataMapping{
+				Labels:      []string{"owner", "classification", "missing"},
+				Annotations: []string{"rotation", "retention"},
+				Prefix:      "vault.example.com/",
+			},
+			wantLabels: map[string]string{
+				"vault.example.com/owner":          "team-a",
+				"vault.example.com/classification": "confidential",
+			},
+			wantAnnotations: map[string]string{
+				"vault.example.com/rotation":  "every 90 days",
+				"vault.example.com/retention": "30",
+			},
+		},
+		{
+			name:   "invalid-label-value",
+			create: true,
+			mapping: &secretsv1alpha1.CustomMetadataMapping{
+				Labels: []string{"rotation"},
+			},
+			wantErr: `invalid label value for key "rotation"`,
+		},
+		{
+			name:   "invalid-key",
+			create: true,
+			mapping: &secretsv1alpha1.CustomMetadataMapping{
+				Annotations: []string{"owner"},
+				Prefix:      "invalid prefix/",
+			},
+			wantErr: `invalid key "invalid prefix/owner"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &secretsv1alpha1.VaultStaticSecret{
+				Spec: secretsv1alpha1.VaultStaticSecretSpec{
+					CustomMetadata: tt.mapping,
+					Destination: secretsv1alpha1.Destination{
+						Name:   "dest",
+						Create: tt.create,
+					},
+				},
+			}
+
+			labels, annotations, err := getCustomMetadata(o, customMetadata)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			if tt.wantLabels == nil {
+				assert.Empty(t, labels)
+			} else {
+				assert.Equal(t, tt.wantLabels, labels)
+			}
+			if tt.wantAnnotations == nil {
+				assert.Empty(t, annotations)
+			} else {
+				assert.Equal(t, tt.wantAnnotations, annotations)
+			}
+		})
+	}
+}
+
+func TestVaultStaticSecretReconciler_Reconcile_customMetadata(t *testing.T) {
+	ctx := context.Background()
+	secret := kvSecret{
+		versions: []kvVersion{
+			{data: map[string]any{"password": "v1"}},
+		},
+		customMetadata: map[string]any{
+			"owner":          "team-a",
+			"classification": "confidential",
+		},
+	}
+	var requests []string
+	vaultClient := newKVv2Server(t, secret, &requests)
+
+	o := &secretsv1alpha1.VaultStaticSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vss",
+			Namespace: "default",
+		},
+		Spec: secretsv1alpha1.VaultStaticSecretSpec{
+			Mount: "kv",
+			Name:  "app",
+			Type:  consts.KVSecretTypeV2,
+			CustomMetadata: &secretsv1alpha1.CustomMetadataMapping{
+				Labels:      []string{"classification"},
+				Annotations: []string{"owner"},
+				Prefix:      "vault.example.com/",
+			},
+			Destination: secretsv1alpha1.Destination{
+				Name:   "dest",
+				Create: true,
+				Labels: map[string]string{
+					"vault.example.com/classification": "public",
+					"app":                              "demo",
+				},
+			},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(o).Build()
+	r := &VaultStaticSecretReconciler{
+		Client:        c,
+		Recorder:      record.NewFakeRecorder(10),
+		ClientFactory: &stubClientFactory{client: &stubKVClient{client: vaultClient}},
+	}
+
+	_, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+	require.NoError(t, err)
+
+	var dest corev1.Secret
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "dest"}, &dest))
+	// the Destination's labels take precedence
+	assert.Equal(t, "public", dest.Labels["vault.example.com/classification"])
+	assert.Equal(t, "demo", dest.Labels["app"])
+	assert.Equal(t, map[string]string{"vault.example.com/owner": "team-a"}, dest.Annotations)
+
+	// a custom_metadata change is synced, even if the secret's version is unchanged
+	secret.customMetadata["owner"] = "team-b"
+	requests = nil
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/v1/kv/metadata/app", "/v1/kv/data/app"}, requests)
+	require.NoError(t, c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "dest"}, &dest))
+	assert.Equal(t, map[string]string{"vault.example.com/owner": "team-b"}, dest.Annotations)
+
+	// nothing has changed since
+	requests = nil
+	_, err = r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(o)})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/v1/kv/metadata/app"}, requests)
+}
+
+func TestVaultStaticSecretReconciler_OnEvent(t *testing.T) {
+	ctx := context.Background()
+	newVSS := func(namespace, name, mount, secretName, secretType string) *secretsv1alpha1.VaultStaticSecret {
+		return &secretsv1alpha1.VaultStaticSecret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+			},
+			Spec: secretsv1alpha1.VaultStaticSecretSpec{
+				Mount: mount,
+				Name:  secretName,
+				Type:  secretType,
+			},
+		}
+	}
+
+	c := fake.NewClientBuilder().WithScheme(newTestScheme(t)).WithObjects(
+		newVSS("default", "app", "kv", "app", consts.KVSecretTypeV2),
+		newVSS("other", "app", "/kv/", "/app", consts.KVSecretTypeV2),
+		newVSS("default", "app-nested", "kv", "app/nested", consts.KVSecretTypeV2),
+		newVSS("default", "app-other-mount", "secret", "app", consts.KVSecretTypeV2),
+		newVSS("default", "app-kv-v1", "kv", "app", consts.KVSecretTypeV1),
+	).Build()
+	r := &VaultStaticSecretReconciler{
+		Client:  c,
+		eventCh: make(chan event.GenericEvent, 5),
+	}
+
+	r.OnEvent(ctx, vault.Event{
+		EventType: vault.EventTypeKVv2DataWrite,
+		MountPath: "kv",
+		Path:      "kv/data/app",
+	})
+	close(r.eventCh)
+
+	var got []client.ObjectKey
+	for e := range r.eventCh {
+		got = append(got, client.ObjectKeyFromObject(e.Object))
+	}
+	assert.ElementsMatch(t, []client.ObjectKey{
+		{Namespace: "default", Name: "app"},
+		{Namespace: "other", Name: "app"},
+	}, got)
+}
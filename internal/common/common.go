@@ -37,50 +37,52 @@ func init() {
 }
 
 func GetVaultAuthAndTarget(ctx context.Context, c client.Client, obj client.Object) (*secretsv1alpha1.VaultAuth, types.NamespacedName, error) {
+	authName, err := GetVaultAuthNamespacedName(obj)
+	if err != nil {
+		return nil, types.NamespacedName{}, err
+	}
+
+	target := types.NamespacedName{
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+	authObj, err := GetVaultAuthWithRetry(ctx, c, authName, time.Millisecond*500, 60)
+	if err != nil {
+		return nil, types.NamespacedName{}, err
+	}
+	return authObj, target, nil
+}
+
+// GetVaultAuthNamespacedName returns the NamespacedName of the VaultAuth that obj references.
+// If its vaultAuthRef is empty then the 'default' VaultAuth in the Operator's namespace is returned.
+func GetVaultAuthNamespacedName(obj client.Object) (types.NamespacedName, error) {
 	var authRef string
-	var target types.NamespacedName
 	switch o := obj.(type) {
 	case *secretsv1alpha1.VaultPKISecret:
 		authRef = o.Spec.VaultAuthRef
-		target = types.NamespacedName{
-			Namespace: o.Namespace,
-			Name:      o.Name,
-		}
 	case *secretsv1alpha1.VaultStaticSecret:
 		authRef = o.Spec.VaultAuthRef
-		target = types.NamespacedName{
-			Namespace: o.Namespace,
-			Name:      o.Name,
-		}
 	case *secretsv1alpha1.VaultDynamicSecret:
 		authRef = o.Spec.VaultAuthRef
-		target = types.NamespacedName{
-			Namespace: o.Namespace,
-			Name:      o.Name,
-		}
+	case *secretsv1alpha1.VaultSecretBundle:
+		authRef = o.Spec.VaultAuthRef
 	default:
-		return nil, types.NamespacedName{}, fmt.Errorf("unsupported type %T", o)
+		return types.NamespacedName{}, fmt.Errorf("unsupported type %T", o)
 	}
 
-	var authName types.NamespacedName
 	if authRef == "" {
 		// if no authRef configured we try and grab the 'default' from the
 		// Operator's current namespace.
-		authName = types.NamespacedName{
+		return types.NamespacedName{
 			Namespace: OperatorNamespace,
 			Name:      consts.NameDefault,
-		}
-	} else {
-		authName = types.NamespacedName{
-			Namespace: target.Namespace,
-			Name:      authRef,
-		}
-	}
-	authObj, err := GetVaultAuthWithRetry(ctx, c, authName, time.Millisecond*500, 60)
-	if err != nil {
-		return nil, types.NamespacedName{}, err
+		}, nil
 	}
-	return authObj, target, nil
+
+	return types.NamespacedName{
+		Namespace: obj.GetNamespace(),
+		Name:      authRef,
+	}, nil
 }
 
 func GetVaultConnection(ctx context.Context, c client.Client, key types.NamespacedName) (*secretsv1alpha1.VaultConnection, error) {
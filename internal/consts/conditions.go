@@ -0,0 +1,18 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package consts
+
+const (
+	// ConditionTypeAuthValid is set on a resource to report whether it is able to authenticate to Vault.
+	ConditionTypeAuthValid = "AuthValid"
+	// ConditionTypeHealthy is set on a VaultConnection to report the health of its Vault server.
+	ConditionTypeHealthy = "Healthy"
+	// ConditionTypeReady is set on all resources, it is True once all the resource's other conditions are True.
+	ConditionTypeReady = "Ready"
+	// ConditionTypeSynced is set on a secret resource to report whether the Vault secret has been synced
+	// to its Destination.
+	ConditionTypeSynced = "Synced"
+	// ConditionTypeVaultReachable is set on a VaultConnection to report whether its Vault server is reachable.
+	ConditionTypeVaultReachable = "VaultReachable"
+)
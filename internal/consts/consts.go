@@ -8,4 +8,8 @@ const (
 
 	KVSecretTypeV2 = "kv-v2"
 	KVSecretTypeV1 = "kv-v1"
+
+	ConflictPolicyError = "Error"
+	ConflictPolicyFirst = "First"
+	ConflictPolicyLast  = "Last"
 )
@@ -5,20 +5,34 @@ package consts
 
 const (
 	ReasonAccepted                = "Accepted"
+	ReasonClientCertRotated       = "ClientCertRotated"
 	ReasonInvalidConfiguration    = "InvalidConfiguration"
 	ReasonInvalidResourceRef      = "InvalidResourceRef"
 	ReasonK8sClientError          = "K8sClientError"
+	ReasonReconciling             = "Reconciling"
 	ReasonRolloutRestartFailed    = "RolloutRestartFailed"
 	ReasonRolloutRestartTriggered = "RolloutRestartTriggered"
+	ReasonSecretDrifted           = "SecretDrifted"
 	ReasonSecretLeaseRenewal      = "SecretLeaseRenewal"
 	ReasonSecretLeaseRenewalError = "SecretLeaseRenewalError"
+	ReasonSecretLeaseRevoke       = "SecretLeaseRevoke"
+	ReasonSecretLeaseRevokeError  = "SecretLeaseRevokeError"
 	ReasonSecretRotated           = "SecretRotated"
 	ReasonSecretSync              = "SecretSync"
 	ReasonSecretSyncError         = "SecretSyncError"
 	ReasonSecretSynced            = "SecretSynced"
+	ReasonSecretVersionDeleted    = "SecretVersionDeleted"
 	ReasonStatusUpdateError       = "StatusUpdateError"
+	ReasonTransformationChanged   = "TransformationChanged"
+	ReasonTransformationError     = "TransformationError"
 	ReasonUnrecoverable           = "Unrecoverable"
+	ReasonVaultAuthChanged        = "VaultAuthChanged"
 	ReasonVaultClientConfigError  = "VaultClientConfigError"
 	ReasonVaultClientError        = "VaultClientError"
+	ReasonVaultHealthy            = "VaultHealthy"
+	ReasonVaultSealed             = "VaultSealed"
+	ReasonVaultStandby            = "VaultStandby"
+	ReasonVaultUninitialized      = "VaultUninitialized"
+	ReasonVaultUnreachable        = "VaultUnreachable"
 	ReasonVaultStaticSecret       = "VaultStaticSecretError"
 )
@@ -24,34 +24,36 @@ import (
 const AnnotationRestartedAt = "vso.secrets.hashicorp.com/restartedAt"
 
 // HandleRolloutRestarts for all v1alpha1.RolloutRestartTarget(s) configured for obj.
-// Supported objs are: v1alpha1.VaultDynamicSecret, v1alpha1.VaultStaticSecret, v1alpha1.VaultPKISecret
+// Supported objs are: v1alpha1.VaultDynamicSecret, v1alpha1.VaultStaticSecret, v1alpha1.VaultPKISecret,
+// v1alpha1.VaultSecretBundle
 // Please note the following:
 // - a rollout-restart will be triggered for each configured v1alpha1.RolloutRestartTarget
 // - the rollout-restart action has no support for roll-back
 // - does not wait for the action to complete
 //
-// Returns all errors encountered.
-func HandleRolloutRestarts(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Object, recorder record.EventRecorder) error {
+// Returns the status of each target that was successfully restarted, along with all errors encountered.
+func HandleRolloutRestarts(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Object, recorder record.EventRecorder) ([]v1alpha1.RolloutRestartTargetStatus, error) {
 	logger := log.FromContext(ctx)
 
-	var targets []v1alpha1.RolloutRestartTarget
-	switch t := obj.(type) {
-	case *v1alpha1.VaultDynamicSecret:
-		targets = t.Spec.RolloutRestartTargets
-	case *v1alpha1.VaultStaticSecret:
-		targets = t.Spec.RolloutRestartTargets
-	case *v1alpha1.VaultPKISecret:
-		targets = t.Spec.RolloutRestartTargets
-	default:
-		return fmt.Errorf("unsupported type %T", t)
+	targets, err := getRolloutRestartTargets(obj)
+	if err != nil {
+		return nil, err
 	}
+
 	var errs error
+	var statuses []v1alpha1.RolloutRestartTargetStatus
 	for _, target := range targets {
-		if err := RolloutRestart(ctx, obj.GetNamespace(), target, client); err != nil {
+		generation, err := RolloutRestart(ctx, obj.GetNamespace(), target, client)
+		if err != nil {
 			errs = errors.Join(err)
 			recorder.Eventf(obj, corev1.EventTypeWarning, consts.ReasonRolloutRestartFailed,
 				"Rollout restart failed for target %#v: err=%s", target, err)
 		} else {
+			statuses = append(statuses, v1alpha1.RolloutRestartTargetStatus{
+				Kind:       target.Kind,
+				Name:       target.Name,
+				Generation: generation,
+			})
 			recorder.Eventf(obj, corev1.EventTypeNormal, consts.ReasonRolloutRestartTriggered,
 				"Rollout restart triggered for %v", target)
 		}
@@ -63,14 +65,114 @@ func HandleRolloutRestarts(ctx context.Context, client ctrlclient.Client, obj ct
 		logger.V(consts.LogLevelDebug).Info("Rollout restart succeeded", "total", len(targets))
 	}
 
-	return errs
+	return statuses, errs
+}
+
+// RolloutRestartsComplete returns true once the rollouts of all v1alpha1.RolloutRestartTarget(s)
+// configured for obj have completed. The statuses are those returned by HandleRolloutRestarts,
+// a target without a status was never restarted, so its rollout is never considered to be complete.
+// See HandleRolloutRestarts for the supported types of obj.
+func RolloutRestartsComplete(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Object, statuses []v1alpha1.RolloutRestartTargetStatus) (bool, error) {
+	targets, err := getRolloutRestartTargets(obj)
+	if err != nil {
+		return false, err
+	}
+
+	for _, target := range targets {
+		var generation int64
+		for _, s := range statuses {
+			if s.Kind == target.Kind && s.Name == target.Name {
+				generation = s.Generation
+				break
+			}
+		}
+		if generation == 0 {
+			return false, nil
+		}
+
+		complete, err := RolloutRestartComplete(ctx, obj.GetNamespace(), target, generation, client)
+		if err != nil || !complete {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// RolloutRestartComplete returns true if the rollout of the target in namespace has completed,
+// that being its status has observed the generation that resulted from the rollout-restart,
+// and all of its replicas are updated and available.
+// Supported target Kinds are: DaemonSet, Deployment, StatefulSet
+func RolloutRestartComplete(ctx context.Context, namespace string, target v1alpha1.RolloutRestartTarget, generation int64, client ctrlclient.Client) (bool, error) {
+	objKey := ctrlclient.ObjectKey{
+		Namespace: namespace,
+		Name:      target.Name,
+	}
+
+	switch target.Kind {
+	case "DaemonSet":
+		var o appsv1.DaemonSet
+		if err := client.Get(ctx, objKey, &o); err != nil {
+			return false, fmt.Errorf("failed to Get object for objKey %s, err=%w", objKey, err)
+		}
+		return o.Status.ObservedGeneration >= generation &&
+			o.Status.ObservedGeneration >= o.Generation &&
+			o.Status.UpdatedNumberScheduled == o.Status.DesiredNumberScheduled &&
+			o.Status.NumberAvailable == o.Status.DesiredNumberScheduled, nil
+	case "Deployment":
+		var o appsv1.Deployment
+		if err := client.Get(ctx, objKey, &o); err != nil {
+			return false, fmt.Errorf("failed to Get object for objKey %s, err=%w", objKey, err)
+		}
+		replicas := int32(1)
+		if o.Spec.Replicas != nil {
+			replicas = *o.Spec.Replicas
+		}
+		return o.Status.ObservedGeneration >= generation &&
+			o.Status.ObservedGeneration >= o.Generation &&
+			o.Status.UpdatedReplicas == replicas &&
+			o.Status.Replicas == replicas &&
+			o.Status.AvailableReplicas == replicas, nil
+	case "StatefulSet":
+		var o appsv1.StatefulSet
+		if err := client.Get(ctx, objKey, &o); err != nil {
+			return false, fmt.Errorf("failed to Get object for objKey %s, err=%w", objKey, err)
+		}
+		replicas := int32(1)
+		if o.Spec.Replicas != nil {
+			replicas = *o.Spec.Replicas
+		}
+		return o.Status.ObservedGeneration >= generation &&
+			o.Status.ObservedGeneration >= o.Generation &&
+			o.Status.UpdatedReplicas == replicas &&
+			o.Status.ReadyReplicas == replicas &&
+			o.Status.CurrentRevision == o.Status.UpdateRevision, nil
+	default:
+		return false, fmt.Errorf("unsupported Kind %q for %T", target.Kind, target)
+	}
+}
+
+func getRolloutRestartTargets(obj ctrlclient.Object) ([]v1alpha1.RolloutRestartTarget, error) {
+	switch t := obj.(type) {
+	case *v1alpha1.VaultDynamicSecret:
+		return t.Spec.RolloutRestartTargets, nil
+	case *v1alpha1.VaultStaticSecret:
+		return t.Spec.RolloutRestartTargets, nil
+	case *v1alpha1.VaultPKISecret:
+		return t.Spec.RolloutRestartTargets, nil
+	case *v1alpha1.VaultSecretBundle:
+		return t.Spec.RolloutRestartTargets, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", t)
+	}
 }
 
 // RolloutRestart patches the target in namespace for rollout-restart.
+// It returns the generation of the target after it was patched.
 // Supported target Kinds are: DaemonSet, Deployment, StatefulSet
-func RolloutRestart(ctx context.Context, namespace string, target v1alpha1.RolloutRestartTarget, client ctrlclient.Client) error {
+func RolloutRestart(ctx context.Context, namespace string, target v1alpha1.RolloutRestartTarget, client ctrlclient.Client) (int64, error) {
 	if namespace == "" {
-		return fmt.Errorf("namespace cannot be empty")
+		return 0, fmt.Errorf("namespace cannot be empty")
 	}
 
 	objectMeta := v1.ObjectMeta{
@@ -93,10 +195,14 @@ func RolloutRestart(ctx context.Context, namespace string, target v1alpha1.Rollo
 			ObjectMeta: objectMeta,
 		}
 	default:
-		return fmt.Errorf("unsupported Kind %q for %T", target.Kind, target)
+		return 0, fmt.Errorf("unsupported Kind %q for %T", target.Kind, target)
+	}
+
+	if err := patchForRolloutRestart(ctx, obj, client); err != nil {
+		return 0, err
 	}
 
-	return patchForRolloutRestart(ctx, obj, client)
+	return obj.GetGeneration(), nil
 }
 
 func patchForRolloutRestart(ctx context.Context, obj ctrlclient.Object, client ctrlclient.Client) error {
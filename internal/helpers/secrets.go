@@ -46,7 +46,7 @@ type SyncableSecretMetaData struct {
 // NewSyncableSecretMetaData returns SyncableSecretMetaData if obj is a supported type.
 // An error will be returned of obj is not a supported type.
 //
-// Supported types for obj are: VaultDynamicSecret, VaultStaticSecret. VaultPKISecret
+// Supported types for obj are: VaultDynamicSecret, VaultStaticSecret, VaultPKISecret, VaultSecretBundle
 func NewSyncableSecretMetaData(obj ctrlclient.Object) (*SyncableSecretMetaData, error) {
 	switch t := obj.(type) {
 	case *secretsv1alpha1.VaultDynamicSecret:
@@ -67,6 +67,12 @@ func NewSyncableSecretMetaData(obj ctrlclient.Object) (*SyncableSecretMetaData,
 			APIVersion:  t.APIVersion,
 			Kind:        t.Kind,
 		}, nil
+	case *secretsv1alpha1.VaultSecretBundle:
+		return &SyncableSecretMetaData{
+			Destination: &t.Spec.Destination,
+			APIVersion:  t.APIVersion,
+			Kind:        t.Kind,
+		}, nil
 	default:
 		return nil, fmt.Errorf("unsupported type %T", t)
 	}
@@ -77,6 +83,15 @@ func NewSyncableSecretMetaData(obj ctrlclient.Object) (*SyncableSecretMetaData,
 //
 // See NewSyncableSecretMetaData for the supported types for obj.
 func SyncSecret(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Object, data map[string][]byte) error {
+	return SyncSecretWithMetadata(ctx, client, obj, data, nil, nil)
+}
+
+// SyncSecretWithMetadata is SyncSecret, additionally applying labels and annotations to a Secret that is
+// created by obj. They are merged with the ones configured in the object's Spec.Destination,
+// which take precedence.
+func SyncSecretWithMetadata(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Object, data map[string][]byte,
+	labels, annotations map[string]string,
+) error {
 	meta, err := NewSyncableSecretMetaData(obj)
 	if err != nil {
 		return err
@@ -151,20 +166,10 @@ func SyncSecret(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Ob
 	}
 
 	// common setup/updates
-	// set any labels configured in meta.Destination.Labels
-	labels := make(map[string]string)
-	for k, v := range meta.Destination.Labels {
-		labels[k] = v
-	}
-	// always add the "owner" labels last to guard against intersections with meta.Destination.Labels
-	for k, v := range OwnerLabels {
-		labels[k] = v
-	}
-	// add any annotations configured in meta.Destination.Labels
 	dest.Data = data
 	dest.Type = secretType
-	dest.SetAnnotations(meta.Destination.Annotations)
-	dest.SetLabels(labels)
+	dest.SetAnnotations(SecretAnnotations(meta, annotations))
+	dest.SetLabels(SecretLabels(meta, labels))
 	dest.SetOwnerReferences(references)
 
 	if exists {
@@ -176,6 +181,41 @@ func SyncSecret(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Ob
 	return client.Create(ctx, &dest)
 }
 
+// SecretLabels returns the labels of a destination Secret that is created by the object of meta.
+// The labels configured in meta.Destination.Labels take precedence over extraLabels.
+func SecretLabels(meta *SyncableSecretMetaData, extraLabels map[string]string) map[string]string {
+	labels := make(map[string]string)
+	for k, v := range extraLabels {
+		labels[k] = v
+	}
+	// set any labels configured in meta.Destination.Labels
+	for k, v := range meta.Destination.Labels {
+		labels[k] = v
+	}
+	// always add the "owner" labels last to guard against intersections with meta.Destination.Labels
+	for k, v := range OwnerLabels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// SecretAnnotations returns the annotations of a destination Secret that is created by the object of meta.
+// The annotations configured in meta.Destination.Annotations take precedence over extraAnnotations.
+func SecretAnnotations(meta *SyncableSecretMetaData, extraAnnotations map[string]string) map[string]string {
+	if len(extraAnnotations) == 0 {
+		return meta.Destination.Annotations
+	}
+
+	annotations := make(map[string]string)
+	for k, v := range extraAnnotations {
+		annotations[k] = v
+	}
+	for k, v := range meta.Destination.Annotations {
+		annotations[k] = v
+	}
+	return annotations
+}
+
 // CheckSecretExists checks if the Secret configured on obj exists.
 // Returns true if the secret exists, false if the secret was not found.
 // If any error, other than apierrors.IsNotFound, is encountered,
@@ -188,11 +228,11 @@ func CheckSecretExists(ctx context.Context, client ctrlclient.Client, obj ctrlcl
 }
 
 // GetSecret
-func GetSecret(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Object) (*corev1.Secret, bool, error) {
+func GetSecret(ctx context.Context, client ctrlclient.Reader, obj ctrlclient.Object) (*corev1.Secret, bool, error) {
 	return getSecretExists(ctx, client, obj)
 }
 
-func getSecretExists(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Object) (*corev1.Secret, bool, error) {
+func getSecretExists(ctx context.Context, client ctrlclient.Reader, obj ctrlclient.Object) (*corev1.Secret, bool, error) {
 	meta, err := NewSyncableSecretMetaData(obj)
 	if err != nil {
 		return nil, false, err
@@ -0,0 +1,154 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"text/template"
+
+	sprig "github.com/go-task/slim-sprig"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+// SecretInput is the data that is available to the templates of a Transformation.
+type SecretInput struct {
+	// Secrets is the Vault secret's data, e.g. {{ .Secrets.username }}.
+	Secrets map[string]any
+}
+
+// TransformSecretData returns data transformed as per the Destination.Transformation of obj, data is
+// returned as is if none is configured. The templates are rendered with secrets as their input,
+// secrets should be the Vault secret's data prior to it being marshalled into data, so that values
+// other than strings keep their types. If secrets is nil, the input is the string value of each of
+// the keys in data, apart from "_raw".
+//
+// See NewSyncableSecretMetaData for the supported types for obj.
+func TransformSecretData(ctx context.Context, client ctrlclient.Client, obj ctrlclient.Object,
+	data map[string][]byte, secrets map[string]any,
+) (map[string][]byte, error) {
+	meta, err := NewSyncableSecretMetaData(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	t := meta.Destination.Transformation
+	if t == nil {
+		return data, nil
+	}
+
+	templates, err := GetTransformationTemplates(ctx, client, obj.GetNamespace(), t)
+	if err != nil {
+		return nil, err
+	}
+
+	excludes := make([]*regexp.Regexp, 0, len(t.Excludes))
+	for _, e := range t.Excludes {
+		re, err := regexp.Compile(e)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exclude %q: %w", e, err)
+		}
+		excludes = append(excludes, re)
+	}
+
+	if secrets == nil {
+		secrets = make(map[string]any, len(data))
+		for k, v := range data {
+			if k != "_raw" {
+				secrets[k] = string(v)
+			}
+		}
+	}
+
+	result := make(map[string][]byte, len(data)+len(templates))
+	for k, v := range data {
+		if !matchesAny(excludes, k) {
+			result[k] = v
+		}
+	}
+
+	input := &SecretInput{Secrets: secrets}
+	keys := make([]string, 0, len(templates))
+	for k := range templates {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b, err := renderTemplate(k, templates[k], input)
+		if err != nil {
+			return nil, err
+		}
+		result[k] = b
+	}
+
+	return result, nil
+}
+
+// GetTransformationTemplates returns the templates of t, including the ones of its TransformationRefs.
+// The TransformationRefs always refer to a SecretTransformation in namespace, i.e. the namespace of the
+// referring resource, so that a resource can never render the templates of another namespace.
+func GetTransformationTemplates(ctx context.Context, client ctrlclient.Client, namespace string,
+	t *secretsv1alpha1.Transformation,
+) (map[string]string, error) {
+	templates := make(map[string]string)
+	for _, ref := range t.TransformationRefs {
+		key := ctrlclient.ObjectKey{Namespace: namespace, Name: ref.Name}
+		var st secretsv1alpha1.SecretTransformation
+		if err := client.Get(ctx, key, &st); err != nil {
+			return nil, fmt.Errorf("failed to get SecretTransformation %s: %w", key, err)
+		}
+		for k, v := range st.Spec.Templates {
+			templates[k] = v
+		}
+	}
+
+	for k, v := range t.Templates {
+		templates[k] = v
+	}
+
+	return templates, nil
+}
+
+// renderTemplate renders text with input, referencing a key that is missing from input is an error.
+func renderTemplate(name, text string, input *SecretInput) ([]byte, error) {
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(templateFuncMap()).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the template for key %q: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, input); err != nil {
+		return nil, fmt.Errorf("failed to render the template for key %q: %w", name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// templateFuncMap returns the Sprig template functions, apart from the ones that could leak the
+// Operator's environment, or make network requests, and the ones whose output size is only bounded by
+// their arguments, e.g. {{ repeat 1000000000 "x" }}, which could exhaust the Operator's memory.
+func templateFuncMap() template.FuncMap {
+	m := sprig.TxtFuncMap()
+	for _, k := range []string{"env", "expandenv", "getHostByName", "repeat", "until", "untilStep"} {
+		delete(m, k)
+	}
+	return m
+}
+
+func matchesAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
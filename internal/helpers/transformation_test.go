@@ -0,0 +1,180 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package helpers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+func TestTransformSecretData(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, secretsv1alpha1.AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&secretsv1alpha1.SecretTransformation{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "jdbc"},
+			Spec: secretsv1alpha1.SecretTransformationSpec{
+				Templates: map[string]string{
+					"url":  "jdbc:postgresql://db:5432/app?user={{ .Secrets.username }}",
+					"port": "5432",
+				},
+			},
+		},
+		&secretsv1alpha1.SecretTransformation{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "upper"},
+			Spec: secretsv1alpha1.SecretTransformationSpec{
+				Templates: map[string]string{
+					"user": "{{ .Secrets.username | upper }}",
+				},
+			},
+		},
+		&secretsv1alpha1.SecretTransformation{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "private"},
+			Spec: secretsv1alpha1.SecretTransformationSpec{
+				Templates: map[string]string{
+					"password": "{{ .Secrets.password }}",
+				},
+			},
+		},
+	).Build()
+
+	data := map[string][]byte{
+		"_raw":     []byte(`{"password":"secret","ports":[5432,5433],"username":"app"}`),
+		"password": []byte("secret"),
+		"ports":    []byte("[5432,5433]"),
+		"username": []byte("app"),
+	}
+	secrets := map[string]any{
+		"password": "secret",
+		"ports":    []any{5432, 5433},
+		"username": "app",
+	}
+
+	tests := []struct {
+		name           string
+		transformation *secretsv1alpha1.Transformation
+		secrets        map[string]any
+		want           map[string][]byte
+		wantErr        string
+	}{
+		{
+			name: "none",
+			want: data,
+		},
+		{
+			name: "inline",
+			transformation: &secretsv1alpha1.Transformation{
+				Templates: map[string]string{
+					"config.yaml": "user: {{ .Secrets.username | quote }}\nport: {{ first .Secrets.ports }}",
+					"password":    "{{ .Secrets.password | b64enc }}",
+				},
+			},
+			secrets: secrets,
+			want: map[string][]byte{
+				"_raw":        data["_raw"],
+				"config.yaml": []byte("user: \"app\"\nport: 5432"),
+				"password":    []byte("c2VjcmV0"),
+				"ports":       data["ports"],
+				"username":    data["username"],
+			},
+		},
+		{
+			name: "refs-and-excludes",
+			transformation: &secretsv1alpha1.Transformation{
+				Templates: map[string]string{
+					"port": "{{ .Secrets.ports }}",
+				},
+				TransformationRefs: []secretsv1alpha1.TransformationRef{
+					{Name: "jdbc"},
+					{Name: "upper"},
+				},
+				Excludes: []string{"^_raw$", "^p"},
+			},
+			want: map[string][]byte{
+				"port":     []byte("[5432,5433]"),
+				"url":      []byte("jdbc:postgresql://db:5432/app?user=app"),
+				"user":     []byte("APP"),
+				"username": data["username"],
+			},
+		},
+		{
+			name: "missing-key",
+			transformation: &secretsv1alpha1.Transformation{
+				Templates: map[string]string{"user": "{{ .Secrets.user }}"},
+			},
+			wantErr: `failed to render the template for key "user"`,
+		},
+		{
+			name: "env-func-removed",
+			transformation: &secretsv1alpha1.Transformation{
+				Templates: map[string]string{"home": `{{ env "HOME" }}`},
+			},
+			wantErr: `failed to parse the template for key "home"`,
+		},
+		{
+			name: "repeat-func-removed",
+			transformation: &secretsv1alpha1.Transformation{
+				Templates: map[string]string{"big": `{{ repeat 1000000000 "x" }}`},
+			},
+			wantErr: `failed to parse the template for key "big"`,
+		},
+		{
+			name: "until-func-removed",
+			transformation: &secretsv1alpha1.Transformation{
+				Templates: map[string]string{"big": `{{ range until 1000000000 }}x{{ end }}`},
+			},
+			wantErr: `failed to parse the template for key "big"`,
+		},
+		{
+			name: "invalid-exclude",
+			transformation: &secretsv1alpha1.Transformation{
+				Excludes: []string{"("},
+			},
+			wantErr: `invalid exclude "("`,
+		},
+		{
+			name: "ref-not-found",
+			transformation: &secretsv1alpha1.Transformation{
+				TransformationRefs: []secretsv1alpha1.TransformationRef{{Name: "unknown"}},
+			},
+			wantErr: "failed to get SecretTransformation default/unknown",
+		},
+		{
+			name: "ref-in-other-namespace",
+			transformation: &secretsv1alpha1.Transformation{
+				TransformationRefs: []secretsv1alpha1.TransformationRef{{Name: "private"}},
+			},
+			wantErr: "failed to get SecretTransformation default/private",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &secretsv1alpha1.VaultStaticSecret{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "vss"},
+				Spec: secretsv1alpha1.VaultStaticSecretSpec{
+					Destination: secretsv1alpha1.Destination{
+						Name:           "dest",
+						Transformation: tt.transformation,
+					},
+				},
+			}
+			got, err := TransformSecretData(context.Background(), c, obj, data, tt.secrets)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -27,8 +27,10 @@ const (
 	OperationPurge      = "purge"
 	OperationLogin      = "login"
 	OperationRenew      = "renew"
+	OperationRevoke     = "revoke"
 	OperationRead       = "read"
 	OperationWrite      = "write"
+	OperationSubscribe  = "subscribe"
 
 	NameConfig                = "config"
 	NameLength                = "length"
@@ -77,12 +77,9 @@ func (c *clientCache) Add(client Client) (bool, error) {
 // Remove a Client from the cache. The key can be had by calling Client.GetCacheKey(), or
 // by computing it from computeClientCacheKey().
 // Returns true if the key was present in the cache.
-// If it was present then Client.Close() will be called.
+// If it was present then the cache's onEvictCallbackFunc will be called,
+// which is responsible for closing the Client.
 func (c *clientCache) Remove(key ClientCacheKey) bool {
-	if v, ok := c.cache.Peek(key); ok {
-		v.(Client).Close()
-	}
-
 	return c.cache.Remove(key)
 }
 
@@ -92,7 +89,6 @@ func (c *clientCache) Prune(filterFunc ClientCachePruneFilterFunc) []ClientCache
 		if v, ok := c.cache.Peek(k); ok {
 			vc := v.(Client)
 			if filterFunc(vc) {
-				vc.Close()
 				if ok := c.cache.Remove(k); ok {
 					pruned = append(pruned, k.(ClientCacheKey))
 				}
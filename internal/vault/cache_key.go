@@ -8,6 +8,7 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+	"strings"
 
 	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
 
@@ -76,7 +77,7 @@ func ComputeClientCacheKeyFromObj(ctx context.Context, client ctrlclient.Client,
 // from three different sources as inputs.
 //
 // The resulting key will resemble something like: kubernetes-2a8108711ae49ac0faa724, where the prefix
-// is the VaultAuth.Spec.Method, and the remainder is the concatenation of the
+// is the lowercased VaultAuth.Spec.Method, and the remainder is the concatenation of the
 // first 7 and last 4 bytes of the computed SHA256 check-sum in hex.
 //
 // The key is included in the name of the corev1.Secrets created by the ClientCacheStorage,
@@ -87,7 +88,8 @@ func ComputeClientCacheKeyFromObj(ctx context.Context, client ctrlclient.Client,
 // or if any of the inputs do not coform in any way, and error will be returned.
 func computeClientCacheKey(authObj *secretsv1alpha1.VaultAuth, connObj *secretsv1alpha1.VaultConnection, providerUID types.UID) (ClientCacheKey, error) {
 	var errs error
-	method := authObj.Spec.Method
+	// the key must be valid in a Kubernetes resource name, which cannot contain uppercase characters, e.g. appRole.
+	method := strings.ToLower(authObj.Spec.Method)
 	if method == "" {
 		errs = errors.Join(errs, fmt.Errorf("auth method is empty"))
 	}
@@ -13,7 +13,9 @@ import (
 	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
@@ -56,6 +58,27 @@ func Test_computeClientCacheKey(t *testing.T) {
 			want:        "ical-" + computedHash,
 			wantErr:     assert.NoError,
 		},
+		{
+			name: "valid-mixed-case-method",
+			authObj: &secretsv1alpha1.VaultAuth{
+				ObjectMeta: metav1.ObjectMeta{
+					UID:        authUID,
+					Generation: 0,
+				},
+				Spec: secretsv1alpha1.VaultAuthSpec{
+					Method: providerMethodAppRole,
+				},
+			},
+			connObj: &secretsv1alpha1.VaultConnection{
+				ObjectMeta: metav1.ObjectMeta{
+					UID:        connUID,
+					Generation: 0,
+				},
+			},
+			providerUID: providerUID,
+			want:        "approle-" + computedHash,
+			wantErr:     assert.NoError,
+		},
 		{
 			name: "valid-key-at-max-length",
 			authObj: &secretsv1alpha1.VaultAuth{
@@ -178,6 +201,25 @@ func Test_computeClientCacheKey(t *testing.T) {
 	}
 }
 
+func Test_computeClientCacheKey_storageSecretName(t *testing.T) {
+	for _, method := range providerMethodsSupported {
+		t.Run(method, func(t *testing.T) {
+			key, err := computeClientCacheKey(
+				&secretsv1alpha1.VaultAuth{
+					ObjectMeta: metav1.ObjectMeta{UID: authUID},
+					Spec:       secretsv1alpha1.VaultAuthSpec{Method: method},
+				},
+				&secretsv1alpha1.VaultConnection{
+					ObjectMeta: metav1.ObjectMeta{UID: connUID},
+				},
+				providerUID,
+			)
+			require.NoError(t, err)
+			assert.Empty(t, validation.IsDNS1123Subdomain(NamePrefixVCC+key.String()))
+		})
+	}
+}
+
 func TestComputeClientCacheKeyFromClient(t *testing.T) {
 	tests := []computeClientCacheKeyTest{
 		{
@@ -5,10 +5,17 @@ package vault
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
 	"sync"
 	"time"
 
+	"golang.org/x/net/websocket"
+
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	"github.com/hashicorp/vault/api"
@@ -20,14 +27,39 @@ import (
 	"github.com/hashicorp/vault-secrets-operator/internal/metrics"
 )
 
+const (
+	// revokeTimeout is the maximum amount of time to wait on a token revocation request.
+	revokeTimeout = 10 * time.Second
+	// subscribeTimeout is the maximum amount of time to wait on establishing an event subscription's websocket.
+	subscribeTimeout = 30 * time.Second
+	// DefaultReLoginFraction is the default fraction of a non-renewable token's TTL
+	// after which the Client will log in to Vault again.
+	DefaultReLoginFraction = 0.75
+)
+
 type ClientOptions struct {
 	SkipRenewal bool
+	// ReLoginFraction of the token TTL after which a Client with a non-renewable token,
+	// e.g. a batch token, will log in to Vault again. Must be greater than 0 and less than 1.
+	ReLoginFraction float64
+	// OnReLogin is called after a Client has logged in to Vault again on its own, e.g. so that its new
+	// token can be persisted.
+	OnReLogin func(Client)
 }
 
 func defaultClientOptions() *ClientOptions {
 	return &ClientOptions{
-		SkipRenewal: false,
+		SkipRenewal:     false,
+		ReLoginFraction: DefaultReLoginFraction,
+	}
+}
+
+// ValidateReLoginFraction ensures that fraction is within the supported range.
+func ValidateReLoginFraction(fraction float64) error {
+	if fraction <= 0 || fraction >= 1 {
+		return fmt.Errorf("invalid re-login fraction %v, must be greater than 0 and less than 1", fraction)
 	}
+	return nil
 }
 
 // NewClient returns a Client specific to obj.
@@ -110,7 +142,7 @@ func NewClientFromStorageEntry(ctx context.Context, client ctrlclient.Client, en
 		return nil, err
 	}
 
-	if err := c.Restore(ctx, entry.VaultSecret); err != nil {
+	if err := c.Restore(ctx, client, entry.VaultSecret); err != nil {
 		return nil, err
 	}
 
@@ -133,7 +165,7 @@ type Client interface {
 	Init(context.Context, ctrlclient.Client, *secretsv1alpha1.VaultAuth, *secretsv1alpha1.VaultConnection, string, *ClientOptions) error
 	Login(context.Context, ctrlclient.Client) error
 	Read(context.Context, string) (*api.Secret, error)
-	Restore(context.Context, *api.Secret) error
+	Restore(context.Context, ctrlclient.Client, *api.Secret) error
 	Write(context.Context, string, map[string]any) (*api.Secret, error)
 	GetTokenSecret() *api.Secret
 	CheckExpiry(int64) (bool, error)
@@ -141,9 +173,10 @@ type Client interface {
 	GetVaultConnectionObj() *secretsv1alpha1.VaultConnection
 	GetCredentialProvider() CredentialProvider
 	GetCacheKey() (ClientCacheKey, error)
-	KVv1(string) (*api.KVv1, error)
-	KVv2(string) (*api.KVv2, error)
-	Close()
+	KVv1(string) KVv1Reader
+	KVv2(string) KVv2Reader
+	SubscribeEvents(context.Context, string) (*websocket.Conn, error)
+	Close(bool)
 }
 
 var _ Client = (*defaultClient)(nil)
@@ -159,6 +192,10 @@ type defaultClient struct {
 	credentialProvider CredentialProvider
 	watcher            *api.LifetimeWatcher
 	lastWatcherErr     error
+	reLoginFraction    float64
+	reLoginTimer       *time.Timer
+	onReLogin          func(Client)
+	addresses          []string
 	once               sync.Once
 	mu                 sync.RWMutex
 }
@@ -167,12 +204,162 @@ func (c *defaultClient) GetCredentialProvider() CredentialProvider {
 	return c.credentialProvider
 }
 
-func (c *defaultClient) KVv1(mount string) (*api.KVv1, error) {
-	return c.client.KVv1(mount), nil
+// KVv1Reader reads the secrets of a KV version 1 mount.
+type KVv1Reader interface {
+	Get(context.Context, string) (*api.KVSecret, error)
+}
+
+// KVv2Reader reads the secrets, and their metadata, of a KV version 2 mount.
+type KVv2Reader interface {
+	Get(context.Context, string) (*api.KVSecret, error)
+	GetVersion(context.Context, string, int) (*api.KVSecret, error)
+	GetMetadata(context.Context, string) (*api.KVMetadata, error)
+}
+
+// KVv1 returns the KVv1Reader for mount. Like Read and Write, its requests fail over to the
+// Client's fallback addresses when the Vault server at its current address is unavailable.
+func (c *defaultClient) KVv1(mount string) KVv1Reader {
+	return &kvv1Reader{c: c, mount: mount}
+}
+
+// KVv2 returns the KVv2Reader for mount. Like Read and Write, its requests fail over to the
+// Client's fallback addresses when the Vault server at its current address is unavailable.
+func (c *defaultClient) KVv2(mount string) KVv2Reader {
+	return &kvv2Reader{c: c, mount: mount}
+}
+
+var _ KVv1Reader = (*kvv1Reader)(nil)
+
+type kvv1Reader struct {
+	c     *defaultClient
+	mount string
+}
+
+func (r *kvv1Reader) Get(ctx context.Context, path string) (secret *api.KVSecret, err error) {
+	err = r.c.doWithFailover(ctx, func() error {
+		secret, err = r.c.client.KVv1(r.mount).Get(ctx, path)
+		return err
+	})
+	return secret, err
+}
+
+var _ KVv2Reader = (*kvv2Reader)(nil)
+
+type kvv2Reader struct {
+	c     *defaultClient
+	mount string
+}
+
+func (r *kvv2Reader) Get(ctx context.Context, path string) (secret *api.KVSecret, err error) {
+	err = r.c.doWithFailover(ctx, func() error {
+		secret, err = r.c.client.KVv2(r.mount).Get(ctx, path)
+		return err
+	})
+	return secret, err
+}
+
+func (r *kvv2Reader) GetVersion(ctx context.Context, path string, version int) (secret *api.KVSecret, err error) {
+	err = r.c.doWithFailover(ctx, func() error {
+		secret, err = r.c.client.KVv2(r.mount).GetVersion(ctx, path, version)
+		return err
+	})
+	return secret, err
+}
+
+func (r *kvv2Reader) GetMetadata(ctx context.Context, path string) (md *api.KVMetadata, err error) {
+	err = r.c.doWithFailover(ctx, func() error {
+		md, err = r.c.client.KVv2(r.mount).GetMetadata(ctx, path)
+		return err
+	})
+	return md, err
+}
+
+// SubscribeEvents opens a websocket to Vault's sys/events/subscribe endpoint, over which the
+// notifications for eventType are received as JSON. Unlike Read and Write, it does not fail over
+// to the fallback addresses, it is up to the caller to subscribe again.
+func (c *defaultClient) SubscribeEvents(ctx context.Context, eventType string) (conn *websocket.Conn, err error) {
+	defer func() {
+		c.incrementOperationCounter(metrics.OperationSubscribe, err)
+	}()
+
+	addr := c.client.Address()
+	u, err := url.Parse(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	default:
+		return nil, fmt.Errorf("unsupported Vault address scheme %q", u.Scheme)
+	}
+	u.Path = path.Join(u.Path, "/v1/sys/events/subscribe", eventType)
+	u.RawQuery = url.Values{"json": []string{"true"}}.Encode()
+
+	config, err := websocket.NewConfig(u.String(), addr)
+	if err != nil {
+		return nil, err
+	}
+
+	config.Header = c.client.Headers()
+	config.Header.Set(api.AuthHeaderName, c.client.Token())
+	if t, ok := c.client.CloneConfig().HttpClient.Transport.(*http.Transport); ok && t.TLSClientConfig != nil {
+		config.TlsConfig = t.TLSClientConfig.Clone()
+	}
+
+	return dialWebsocket(ctx, config, subscribeTimeout)
 }
 
-func (c *defaultClient) KVv2(mount string) (*api.KVv2, error) {
-	return c.client.KVv2(mount), nil
+// dialWebsocket establishes the websocket described by config. Both the connection and the websocket
+// handshake must complete within timeout, or before ctx is done.
+func dialWebsocket(ctx context.Context, config *websocket.Config, timeout time.Duration) (*websocket.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	addr := config.Location.Host
+	if config.Location.Port() == "" {
+		port := "80"
+		if config.Location.Scheme == "wss" {
+			port = "443"
+		}
+		addr = net.JoinHostPort(config.Location.Hostname(), port)
+	}
+
+	var err error
+	var conn net.Conn
+	if config.Location.Scheme == "wss" {
+		dialer := &tls.Dialer{Config: config.TlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	} else {
+		dialer := &net.Dialer{}
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// the deadline only applies to the handshake, the websocket is long-lived.
+	deadline, _ := ctx.Deadline()
+	if err := conn.SetDeadline(deadline); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	ws, err := websocket.NewClient(config, conn)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	if err := conn.SetDeadline(time.Time{}); err != nil {
+		_ = ws.Close()
+		return nil, err
+	}
+
+	return ws, nil
 }
 
 func (c *defaultClient) GetCacheKey() (ClientCacheKey, error) {
@@ -183,14 +370,17 @@ func (c *defaultClient) GetCacheKey() (ClientCacheKey, error) {
 }
 
 // Restore self from the provided api.Secret (should have an Auth configured).
-// The provided Client Token will be renewed as well.
-func (c *defaultClient) Restore(ctx context.Context, secret *api.Secret) error {
+// The provided Client Token will be renewed as well. Since secret does not record when its token was issued,
+// the token is looked up in Vault, and the time of its last renewal is derived from the TTL that it has left.
+// Otherwise, a new login will be scheduled after the ReLoginFraction of the token's TTL, as for Login.
+func (c *defaultClient) Restore(ctx context.Context, client ctrlclient.Client, secret *api.Secret) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
 	if c.watcher != nil {
 		c.watcher.Stop()
 	}
+	c.stopReLoginTimer()
 
 	if secret == nil {
 		return fmt.Errorf("api.Secret is nil")
@@ -203,15 +393,53 @@ func (c *defaultClient) Restore(ctx context.Context, secret *api.Secret) error {
 	c.authSecret = secret
 	c.client.SetToken(secret.Auth.ClientToken)
 
+	lastRenewal, err := c.lookupLastRenewal(ctx)
+	if err != nil {
+		return err
+	}
+	c.lastRenewal = lastRenewal
+
 	if secret.Auth.Renewable {
 		if err := c.startLifetimeWatcher(ctx); err != nil {
 			return err
 		}
+	} else {
+		c.scheduleReLogin(client)
 	}
 
 	return nil
 }
 
+// lookupLastRenewal returns the time, in Unix seconds, at which the Client's token was last renewed, or issued,
+// per the TTL that the token has left in Vault.
+// Should be called from a write locked method only.
+func (c *defaultClient) lookupLastRenewal(ctx context.Context) (int64, error) {
+	var resp *api.Secret
+	if err := c.doWithFailover(ctx, func() error {
+		var err error
+		resp, err = c.client.Auth().Token().LookupSelfWithContext(ctx)
+		return err
+	}); err != nil {
+		return 0, fmt.Errorf("failed to look up the restored token: %w", err)
+	}
+
+	remaining, err := resp.TokenTTL()
+	if err != nil {
+		return 0, err
+	}
+
+	ttl, err := c.getTokenTTL()
+	if err != nil {
+		return 0, err
+	}
+
+	if ttl <= 0 || remaining > ttl {
+		return time.Now().Unix(), nil
+	}
+
+	return time.Now().Add(remaining - ttl).Unix(), nil
+}
+
 func (c *defaultClient) Init(ctx context.Context, client ctrlclient.Client, authObj *secretsv1alpha1.VaultAuth,
 	connObj *secretsv1alpha1.VaultConnection, providerNamespace string, opts *ClientOptions,
 ) error {
@@ -266,16 +494,44 @@ func (c *defaultClient) GetTokenSecret() *api.Secret {
 }
 
 // Close un-initializes this Client, stopping its LifetimeWatcher in the process.
-// It is safe to be called multiple times.
-func (c *defaultClient) Close() {
+// If revoke is true, the Client's Vault token will be revoked in the background, so that a slow
+// Vault does not hold up the caller, e.g. the ClientCache's evict callback, which runs under the
+// cache's lock. It is safe to be called multiple times.
+func (c *defaultClient) Close(revoke bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	log.FromContext(nil).Info("Calling Client.Close()")
+	logger := log.FromContext(nil)
+	logger.Info("Calling Client.Close()", "revoke", revoke)
 	if c.watcher != nil {
 		c.watcher.Stop()
 	}
+	c.stopReLoginTimer()
+
+	if revoke && c.client != nil && c.authSecret != nil {
+		// the api.Client is no longer referenced by c once it is closed, so it is safe to use without the lock.
+		go c.revoke(c.client)
+	}
+
 	c.client = nil
+	c.authSecret = nil
+}
+
+// revoke the Vault token of client, errors are logged.
+func (c *defaultClient) revoke(client *api.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), revokeTimeout)
+	defer cancel()
+
+	var err error
+	startTS := time.Now()
+	defer func() {
+		c.observeTime(startTS, metrics.OperationRevoke)
+		c.incrementOperationCounter(metrics.OperationRevoke, err)
+	}()
+
+	if _, err = client.Logical().WriteWithContext(ctx, "auth/token/revoke-self", nil); err != nil {
+		log.FromContext(nil).Error(err, "Failed to revoke the Vault token")
+	}
 }
 
 // startLifetimeWatcher starts an api.LifetimeWatcher in a Go routine for this Client.
@@ -337,10 +593,15 @@ func (c *defaultClient) startLifetimeWatcher(ctx context.Context) error {
 
 // Login the Client to Vault. Upon success, if the auth token is renewable,
 // an api.LifetimeWatcher will be started to ensure that the token is periodically renewed.
+// Otherwise, a new login will be scheduled after the ReLoginFraction of the token's TTL has elapsed.
 func (c *defaultClient) Login(ctx context.Context, client ctrlclient.Client) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.client == nil {
+		return fmt.Errorf("cannot login, client is closed")
+	}
+
 	var errs error
 	startTS := time.Now()
 	defer func() {
@@ -350,6 +611,7 @@ func (c *defaultClient) Login(ctx context.Context, client ctrlclient.Client) err
 	if c.watcher != nil {
 		c.watcher.Stop()
 	}
+	c.stopReLoginTimer()
 
 	creds, err := c.credentialProvider.GetCreds(ctx, client)
 	if err != nil {
@@ -367,7 +629,7 @@ func (c *defaultClient) Login(ctx context.Context, client ctrlclient.Client) err
 	}
 
 	path := fmt.Sprintf("auth/%s/login", c.authObj.Spec.Mount)
-	resp, err := c.Write(ctx, path, creds)
+	resp, err := c.Write(ctx, path, mergeAuthParams(creds, c.authObj.Spec.Params))
 	if err != nil {
 		errs = err
 		return errs
@@ -383,11 +645,57 @@ func (c *defaultClient) Login(ctx context.Context, client ctrlclient.Client) err
 			errs = err
 			return errs
 		}
+	} else {
+		c.scheduleReLogin(client)
 	}
 
 	return nil
 }
 
+// scheduleReLogin schedules a new Login once the reLoginFraction of the current token's TTL has elapsed since its
+// last renewal. This ensures that Clients with non-renewable tokens, e.g. batch tokens, are re-authenticated before their token expires.
+// Tokens without a TTL are never re-logged in. The re-login outlives the request that scheduled it,
+// so it does not use its context.
+func (c *defaultClient) scheduleReLogin(client ctrlclient.Client) {
+	if c.skipRenewal {
+		return
+	}
+
+	ttl, err := c.getTokenTTL()
+	if err != nil || ttl <= 0 {
+		return
+	}
+
+	d := time.Until(time.Unix(c.lastRenewal, 0).Add(time.Duration(float64(ttl) * c.reLoginFraction)))
+	if d < 0 {
+		d = 0
+	}
+	logger := log.FromContext(nil).V(consts.LogLevelDebug).WithName("reLogin").WithValues(
+		"entityID", c.authSecret.Auth.EntityID)
+	logger.Info("Scheduling re-login for non-renewable token", "ttl", ttl, "delay", d)
+	c.reLoginTimer = time.AfterFunc(d, func() {
+		if err := c.Login(context.Background(), client); err != nil {
+			logger.Error(err, "Re-login failed")
+			c.mu.Lock()
+			c.lastWatcherErr = err
+			c.mu.Unlock()
+			return
+		}
+		logger.Info("Successfully re-logged in the client")
+		if c.onReLogin != nil {
+			c.onReLogin(c)
+		}
+	})
+}
+
+// stopReLoginTimer stops any scheduled re-login.
+func (c *defaultClient) stopReLoginTimer() {
+	if c.reLoginTimer != nil {
+		c.reLoginTimer.Stop()
+		c.reLoginTimer = nil
+	}
+}
+
 func (c *defaultClient) GetVaultAuthObj() *secretsv1alpha1.VaultAuth {
 	return c.authObj
 }
@@ -405,7 +713,11 @@ func (c *defaultClient) Read(ctx context.Context, path string) (*api.Secret, err
 	}()
 
 	var secret *api.Secret
-	secret, err = c.client.Logical().ReadWithContext(ctx, path)
+	err = c.doWithFailover(ctx, func() error {
+		var err error
+		secret, err = c.client.Logical().ReadWithContext(ctx, path)
+		return err
+	})
 	return secret, err
 }
 
@@ -418,10 +730,44 @@ func (c *defaultClient) Write(ctx context.Context, path string, m map[string]any
 	}()
 
 	var secret *api.Secret
-	secret, err = c.client.Logical().WriteWithContext(ctx, path, m)
+	err = c.doWithFailover(ctx, func() error {
+		var err error
+		secret, err = c.client.Logical().WriteWithContext(ctx, path, m)
+		return err
+	})
 	return secret, err
 }
 
+// doWithFailover calls fn, if it fails because the current Vault server is unavailable,
+// fn will be retried against each of the Client's other Vault server addresses, in order,
+// until it succeeds, or all addresses have been tried.
+func (c *defaultClient) doWithFailover(ctx context.Context, fn func() error) error {
+	err := fn()
+	if err == nil || len(c.addresses) < 2 || !isFailoverError(err) {
+		return err
+	}
+
+	logger := log.FromContext(ctx).WithName("failover")
+	current := c.client.Address()
+	for _, addr := range c.addresses {
+		if addr == current {
+			continue
+		}
+
+		logger.Info("Vault server unavailable, failing over", "from", current, "to", addr, "err", err)
+		if err := c.client.SetAddress(addr); err != nil {
+			return err
+		}
+
+		if err = fn(); err == nil || !isFailoverError(err) {
+			return err
+		}
+		current = addr
+	}
+
+	return err
+}
+
 func (c *defaultClient) renew(ctx context.Context) error {
 	// should be called from a write locked method only
 	var errs error
@@ -457,13 +803,32 @@ func (c *defaultClient) renew(ctx context.Context) error {
 }
 
 func (c *defaultClient) init(ctx context.Context, client ctrlclient.Client, authObj *secretsv1alpha1.VaultAuth, connObj *secretsv1alpha1.VaultConnection, providerNamespace string, opts *ClientOptions) error {
+	reLoginFraction := opts.ReLoginFraction
+	if reLoginFraction == 0 {
+		reLoginFraction = DefaultReLoginFraction
+	}
+	if err := ValidateReLoginFraction(reLoginFraction); err != nil {
+		return err
+	}
+
 	cfg := &ClientConfig{
-		Address:         connObj.Spec.Address,
-		SkipTLSVerify:   connObj.Spec.SkipTLSVerify,
-		TLSServerName:   connObj.Spec.TLSServerName,
-		VaultNamespace:  authObj.Spec.Namespace,
-		CACertSecretRef: connObj.Spec.CACertSecretRef,
-		K8sNamespace:    providerNamespace,
+		Address:           connObj.Spec.Address,
+		FallbackAddresses: connObj.Spec.FallbackAddresses,
+		SkipTLSVerify:     connObj.Spec.SkipTLSVerify,
+		TLSServerName:     connObj.Spec.TLSServerName,
+		VaultNamespace:    authObj.Spec.Namespace,
+		CACertSecretRef:   connObj.Spec.CACertSecretRef,
+		K8sNamespace:      providerNamespace,
+	}
+
+	// the cert auth method's client certificate takes precedence over the VaultConnection's,
+	// since it is what identifies the Client to Vault.
+	if authObj.Spec.Method == providerMethodCert && authObj.Spec.Cert != nil {
+		cfg.ClientCertSecretRef = authObj.Spec.Cert.SecretRef
+		cfg.ClientCertNamespace = providerNamespace
+	} else if connObj.Spec.ClientCertSecretRef != "" {
+		cfg.ClientCertSecretRef = connObj.Spec.ClientCertSecretRef
+		cfg.ClientCertNamespace = connObj.Namespace
 	}
 
 	vc, err := MakeVaultClient(ctx, cfg, client)
@@ -477,8 +842,11 @@ func (c *defaultClient) init(ctx context.Context, client ctrlclient.Client, auth
 	}
 
 	c.skipRenewal = opts.SkipRenewal
+	c.reLoginFraction = reLoginFraction
+	c.onReLogin = opts.OnReLogin
 	c.credentialProvider = credentialProvider
 	c.client = vc
+	c.addresses = cfg.addresses()
 	c.authObj = authObj
 	c.connObj = connObj
 
@@ -493,8 +861,9 @@ func (c *defaultClient) observeTime(ts time.Time, operation string) {
 
 func (c *defaultClient) incrementOperationCounter(operation string, err error) {
 	vaultConn := ctrlclient.ObjectKeyFromObject(c.connObj).String()
-	clientOperationErrors.WithLabelValues(operation, vaultConn).Inc()
 	if err != nil {
+		clientOperationErrors.WithLabelValues(operation, vaultConn).Inc()
+	} else {
 		clientOperations.WithLabelValues(operation, vaultConn).Inc()
 	}
 }
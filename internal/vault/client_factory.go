@@ -58,6 +58,8 @@ type cachingClientFactory struct {
 	recorder           record.EventRecorder
 	persist            bool
 	encryptionRequired bool
+	revokeTokens       bool
+	clientOptions      *ClientOptions
 	// clientCacheKeyEncrypt is a member of the ClientCache, it is instantiated whenever the ClientCacheStorage has enforceEncryption enabled.
 	clientCacheKeyEncrypt  ClientCacheKey
 	logger                 logr.Logger
@@ -120,10 +122,11 @@ func (m *cachingClientFactory) pruneStorage(ctx context.Context, client ctrlclie
 
 // onClientEvict should be called whenever an eviction from the ClientCache occurs.
 // It should always call Client.Close() to prevent leaking Go routines.
+// If revokeTokens is enabled, the Client's Vault token will be revoked, since it is no longer held by the factory.
 func (m *cachingClientFactory) onClientEvict(ctx context.Context, client ctrlclient.Client, cacheKey ClientCacheKey, c Client) {
 	logger := m.logger.WithValues("cacheKey", cacheKey)
-	logger.Info("Handling client cache eviction")
-	c.Close()
+	logger.Info("Handling client cache eviction", "revokeTokens", m.revokeTokens)
+	c.Close(m.revokeTokens)
 	if m.persist && m.storage != nil {
 		if count, err := m.pruneStorage(ctx, client, cacheKey); err != nil {
 			logger.Error(err, "Failed to remove Client from storage")
@@ -252,23 +255,32 @@ func (m *cachingClientFactory) Get(ctx context.Context, client ctrlclient.Client
 	// try and fetch the client from the in-memory Client cache
 	c, ok := m.cache.Get(cacheKey)
 	if ok {
-		// return the Client from the cache if it is not expired.
-		if expired, err := c.CheckExpiry(0); !expired && err == nil {
+		// return the Client from the cache if it is not about to expire.
+		if !m.isExpiring(c) {
 			return c, nil
 		}
 	}
 
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	if !ok && (m.persist && m.storage != nil) {
+	if ok {
+		// the cached Client is about to expire, remove it from the cache, so that it can be replaced.
+		// This also removes it from the storage cache.
+		logger.Info("Replacing expiring client", "cacheKey", cacheKey)
+		m.replaceClient(cacheKey, c)
+	} else if m.persist && m.storage != nil {
 		// try and restore from Client storage cache, if properly configured to do so.
 		if restored, err := m.restoreClientFromCacheKey(ctx, client, cacheKey); err == nil {
-			return restored, nil
+			if !m.isExpiring(restored) {
+				return restored, nil
+			}
+			logger.Info("Restored client is about to expire, replacing it", "cacheKey", cacheKey)
+			m.replaceClient(cacheKey, restored)
 		}
 	}
 
 	// if we couldn't produce a valid Client, create a new one, log it in, and cache it
-	c, err = NewClientWithLogin(ctx, client, obj, nil)
+	c, err = NewClientWithLogin(ctx, client, obj, m.clientOptions)
 	if err != nil {
 		logger.Error(err, "Failed to get NewClientWithLogin")
 		errs = errors.Join(err)
@@ -291,6 +303,61 @@ func (m *cachingClientFactory) Get(ctx context.Context, client ctrlclient.Client
 	return c, errs
 }
 
+// replaceClient removes the expiring Client c from the cache. Its token is never revoked, since c may
+// still be held by other callers, e.g. the LeaseManager, until they get its replacement.
+// Must be called with m.mu held.
+func (m *cachingClientFactory) replaceClient(cacheKey ClientCacheKey, c Client) {
+	// closing c first makes the Close() from onClientEvict a no-op.
+	c.Close(false)
+	m.cache.Remove(cacheKey)
+}
+
+// isExpiring returns true if the Client's token has expired, or for a non-renewable token, if less than half
+// of the time that remains after its re-login, per the factory's ClientOptions.ReLoginFraction, is left.
+// This is strictly later than the Client's own re-login, so a Client is only replaced once its re-login has failed.
+// Renewable tokens are renewed by the Client's LifetimeWatcher, so they are only replaced once expired.
+func (m *cachingClientFactory) isExpiring(c Client) bool {
+	var offset int64
+	if secret := c.GetTokenSecret(); secret != nil && secret.Auth != nil && !secret.Auth.Renewable {
+		if ttl, err := secret.TokenTTL(); err == nil {
+			offset = int64(ttl.Seconds() * (1 - m.clientOptions.ReLoginFraction) / 2)
+		}
+	}
+
+	expired, err := c.CheckExpiry(offset)
+	if err != nil {
+		// the expiry cannot be checked, e.g. for a Client that has never logged in, which is not a sign that
+		// its token is expiring, so it is not replaced on that account.
+		m.logger.V(consts.LogLevelDebug).Info("Failed to check the client's token expiry", "err", err)
+		return false
+	}
+
+	return expired
+}
+
+// onClientReLogin persists the new token of a Client that has logged in to Vault again on its own, so
+// that it is not restored with its previous token. Clients that are no longer cached are ignored.
+func (m *cachingClientFactory) onClientReLogin(ctx context.Context, client ctrlclient.Client, c Client) {
+	if !m.persist || m.storage == nil {
+		return
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cacheKey, err := c.GetCacheKey()
+	if err != nil {
+		return
+	}
+	if cur, ok := m.cache.Get(cacheKey); !ok || cur != c {
+		return
+	}
+
+	if err := m.storeClient(ctx, client, c); err != nil {
+		m.logger.Error(err, "Failed to store the client after re-login", "cacheKey", cacheKey)
+	}
+}
+
 func (m *cachingClientFactory) storeClient(ctx context.Context, client ctrlclient.Client, c Client) error {
 	var errs error
 	defer func() {
@@ -361,7 +428,7 @@ func (m *cachingClientFactory) restoreClient(ctx context.Context, client ctrlcli
 		return nil, fmt.Errorf("restoration impossible, storage is not enabled")
 	}
 
-	c, err := NewClientFromStorageEntry(ctx, client, entry, nil)
+	c, err := NewClientFromStorageEntry(ctx, client, entry, m.clientOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -404,7 +471,7 @@ func (m *cachingClientFactory) storageEncryptionClient(ctx context.Context, clie
 		}
 
 		// if we couldn't produce a valid Client, create a new one, log it in, and cache it
-		vc, err := NewClientWithLogin(ctx, client, encryptionVaultAuth, nil)
+		vc, err := NewClientWithLogin(ctx, client, encryptionVaultAuth, m.clientOptions)
 		if err != nil {
 			return nil, err
 		}
@@ -472,14 +539,27 @@ func (c *cachingClientFactory) incrementRequestCounter(operation string, err err
 // The ClientCache's onEvictCallback is registered with the factory's onClientEvict(),
 // to ensure any evictions are handled by the factory (this is very important).
 func NewCachingClientFactory(ctx context.Context, client ctrlclient.Client, cacheStorage ClientCacheStorage, config *CachingClientFactoryConfig) (CachingClientFactory, error) {
+	reLoginFraction := config.ReLoginFraction
+	if reLoginFraction == 0 {
+		reLoginFraction = DefaultReLoginFraction
+	}
+	if err := ValidateReLoginFraction(reLoginFraction); err != nil {
+		return nil, err
+	}
+
 	factory := &cachingClientFactory{
 		storage:            cacheStorage,
 		recorder:           config.Recorder,
 		persist:            config.Persist,
 		encryptionRequired: config.StorageConfig.EnforceEncryption,
+		revokeTokens:       config.RevokeTokens,
+		clientOptions: &ClientOptions{
+			ReLoginFraction: reLoginFraction,
+		},
 		logger: zap.New().WithName("clientCacheFactory").WithValues(
 			"persist", config.Persist,
 			"enforceEncryption", config.StorageConfig.EnforceEncryption,
+			"revokeTokens", config.RevokeTokens,
 		),
 		requestCounterVec: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
@@ -526,6 +606,9 @@ func NewCachingClientFactory(ctx context.Context, client ctrlclient.Client, cach
 	}
 
 	factory.cache = cache
+	factory.clientOptions.OnReLogin = func(c Client) {
+		factory.onClientReLogin(ctx, client, c)
+	}
 	return factory, nil
 }
 
@@ -537,6 +620,13 @@ type CachingClientFactoryConfig struct {
 	CollectClientCacheMetrics bool
 	Recorder                  record.EventRecorder
 	MetricsRegistry           prometheus.Registerer
+	// RevokeTokens controls whether the Vault token of a Client is revoked
+	// when the Client is evicted or pruned from the ClientCache.
+	RevokeTokens bool
+	// ReLoginFraction of the token TTL after which a Client with a non-renewable token
+	// will log in again. Cached Clients whose re-login has failed are replaced on Get(),
+	// once half of the time that remains after this point has elapsed.
+	ReLoginFraction float64
 }
 
 // DefaultCachingClientFactoryConfig provides the default configuration for a CachingClientFactory instance.
@@ -546,6 +636,7 @@ func DefaultCachingClientFactoryConfig() *CachingClientFactoryConfig {
 		ClientCacheSize: 10000,
 		Recorder:        &nullEventRecorder{},
 		MetricsRegistry: ctrlmetrics.Registry,
+		ReLoginFraction: DefaultReLoginFraction,
 	}
 }
 
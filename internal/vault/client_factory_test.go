@@ -0,0 +1,335 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+func Test_cachingClientFactory_revokeTokens(t *testing.T) {
+	tests := []struct {
+		name         string
+		revokeTokens bool
+		wantRevoked  int
+	}{
+		{
+			name:         "enabled",
+			revokeTokens: true,
+			wantRevoked:  2,
+		},
+		{
+			name:         "disabled",
+			revokeTokens: false,
+			wantRevoked:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			server, revoked := newRevokeTestServer(t)
+
+			config := DefaultCachingClientFactoryConfig()
+			config.MetricsRegistry = nil
+			config.ClientCacheSize = 1
+			config.RevokeTokens = tt.revokeTokens
+			f, err := NewCachingClientFactory(ctx, fake.NewClientBuilder().Build(), nil, config)
+			require.NoError(t, err)
+			factory := f.(*cachingClientFactory)
+
+			c1 := newTestDefaultClient(t, server.URL)
+			_, err = factory.cacheClient(c1)
+			require.NoError(t, err)
+
+			// exceeding the cache size evicts c1
+			c2 := newTestDefaultClient(t, server.URL)
+			c2.authObj.Name = "other"
+			c2.authObj.UID = "1f5d4a8c-2fd6-4d8a-9d3e-5e3b1d1c0f7e"
+			_, err = factory.cacheClient(c2)
+			require.NoError(t, err)
+			assert.Equal(t, 1, factory.cache.Len())
+			assert.Nil(t, c1.client)
+			assert.NotNil(t, c2.client)
+
+			// pruning c2
+			count, err := factory.Prune(ctx, nil, c2.authObj, CachingClientFactoryPruneRequest{
+				FilterFunc: func(cur, other ctrlclient.Object) bool {
+					return cur.GetUID() == other.GetUID()
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+			assert.Equal(t, 0, factory.cache.Len())
+			assert.Nil(t, c2.client)
+
+			assertRevoked(t, revoked, tt.wantRevoked)
+		})
+	}
+}
+
+func Test_cachingClientFactory_Get_replacesExpiring(t *testing.T) {
+	ctx := context.Background()
+
+	var logins int
+	var revoked atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/auth/approle/login":
+			logins++
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = fmt.Fprintf(w, `{"auth": {"client_token": "b.token-%d", "renewable": false, "lease_duration": 60}}`, logins)
+		case "/v1/auth/token/revoke-self":
+			revoked.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, vss := newTestAppRoleObjects(t, server.URL)
+
+	config := DefaultCachingClientFactoryConfig()
+	config.MetricsRegistry = nil
+	config.ReLoginFraction = 0.5
+	config.RevokeTokens = true
+	factory, err := NewCachingClientFactory(ctx, client, nil, config)
+	require.NoError(t, err)
+
+	c1, err := factory.Get(ctx, client, vss)
+	require.NoError(t, err)
+	assert.Equal(t, 1, logins)
+	t.Cleanup(func() {
+		c1.Close(false)
+	})
+
+	// the cached client is returned while it is within the re-login fraction of its TTL
+	c, err := factory.Get(ctx, client, vss)
+	require.NoError(t, err)
+	assert.Same(t, c1, c)
+	assert.Equal(t, 1, logins)
+
+	// past the re-login fraction of its TTL, the client is left to its own re-login.
+	dc := c1.(*defaultClient)
+	dc.mu.Lock()
+	dc.lastRenewal = time.Now().Unix() - 40
+	dc.mu.Unlock()
+
+	c, err = factory.Get(ctx, client, vss)
+	require.NoError(t, err)
+	assert.Same(t, c1, c)
+	assert.Equal(t, 1, logins)
+
+	// simulate a failed re-login, with less than half of the remaining time left.
+	dc.mu.Lock()
+	dc.lastRenewal = time.Now().Unix() - 50
+	dc.mu.Unlock()
+
+	c2, err := factory.Get(ctx, client, vss)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c2.Close(false)
+	})
+	assert.NotSame(t, c1, c2)
+	assert.Equal(t, 2, logins)
+	assert.Equal(t, "b.token-2", c2.GetTokenSecret().Auth.ClientToken)
+	// the replaced client should have been closed, without revoking its token.
+	assert.Nil(t, dc.client)
+	assertRevoked(t, &revoked, 0)
+}
+
+func Test_cachingClientFactory_Get_restoresFromStorage(t *testing.T) {
+	ctx := context.Background()
+
+	var logins int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v1/auth/approle/login":
+			logins++
+			_, _ = fmt.Fprintf(w, `{"auth": {"client_token": "b.token-%d", "renewable": false, "lease_duration": 60}}`, logins)
+		case "/v1/auth/token/lookup-self":
+			// the token was issued 10 seconds ago.
+			_, _ = fmt.Fprint(w, `{"data": {"ttl": 50}}`)
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, vss := newTestAppRoleObjects(t, server.URL)
+	storage, err := NewDefaultClientCacheStorage(ctx, client, nil, nil)
+	require.NoError(t, err)
+
+	config := DefaultCachingClientFactoryConfig()
+	config.MetricsRegistry = nil
+	config.Persist = true
+	config.ReLoginFraction = 0.5
+	factory, err := NewCachingClientFactory(ctx, client, storage, config)
+	require.NoError(t, err)
+
+	c1, err := factory.Get(ctx, client, vss)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c1.Close(false)
+	})
+	assert.Equal(t, 1, logins)
+	count, err := storage.Len(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	// a new factory, e.g. after the Operator restarts, restores the stored client rather than logging in again.
+	restoring, err := NewCachingClientFactory(ctx, client, storage, config)
+	require.NoError(t, err)
+
+	c2, err := restoring.Get(ctx, client, vss)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c2.Close(false)
+	})
+	assert.NotSame(t, c1, c2)
+	assert.Equal(t, 1, logins)
+	assert.Equal(t, "b.token-1", c2.GetTokenSecret().Auth.ClientToken)
+
+	dc := c2.(*defaultClient)
+	dc.mu.RLock()
+	assert.InDelta(t, time.Now().Unix()-10, dc.lastRenewal, 2)
+	assert.NotNil(t, dc.reLoginTimer, "a re-login should be scheduled for the restored non-renewable token")
+	dc.mu.RUnlock()
+
+	// the restored client is reused, and its storage entry is kept.
+	c, err := restoring.Get(ctx, client, vss)
+	require.NoError(t, err)
+	assert.Same(t, c2, c)
+	assert.Equal(t, 1, logins)
+	count, err = storage.Len(ctx, client)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+// newTestAppRoleObjects returns a ctrlclient.Client that holds a VaultStaticSecret, along with the
+// VaultAuth, VaultConnection, and AppRole Secret that it references, for the Vault server at address.
+func newTestAppRoleObjects(t *testing.T, address string) (ctrlclient.Client, *secretsv1alpha1.VaultStaticSecret) {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, secretsv1alpha1.AddToScheme(scheme))
+
+	vss := &secretsv1alpha1.VaultStaticSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "vss",
+			Namespace: "tenant-ns",
+		},
+		Spec: secretsv1alpha1.VaultStaticSecretSpec{
+			VaultAuthRef: "auth",
+		},
+	}
+	client := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		vss,
+		&secretsv1alpha1.VaultConnection{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "conn",
+				Namespace: "tenant-ns",
+				UID:       connUID,
+			},
+			Spec: secretsv1alpha1.VaultConnectionSpec{
+				Address: address,
+			},
+		},
+		&secretsv1alpha1.VaultAuth{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "auth",
+				Namespace: "tenant-ns",
+				UID:       authUID,
+			},
+			Spec: secretsv1alpha1.VaultAuthSpec{
+				VaultConnectionRef: "conn",
+				Method:             providerMethodAppRole,
+				Mount:              "approle",
+				AppRole: &secretsv1alpha1.VaultAuthConfigAppRole{
+					RoleID:    "role-id",
+					SecretRef: "approle",
+				},
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "approle",
+				Namespace: "tenant-ns",
+				UID:       providerUID,
+			},
+			Data: map[string][]byte{
+				"id": []byte("secret-id"),
+			},
+		},
+	).Build()
+
+	return client, vss
+}
+
+func TestNewCachingClientFactory_reLoginFraction(t *testing.T) {
+	for _, fraction := range []float64{-0.1, 1, 1.5} {
+		config := DefaultCachingClientFactoryConfig()
+		config.MetricsRegistry = nil
+		config.ReLoginFraction = fraction
+		_, err := NewCachingClientFactory(context.Background(), fake.NewClientBuilder().Build(), nil, config)
+		assert.EqualError(t, err,
+			fmt.Sprintf("invalid re-login fraction %v, must be greater than 0 and less than 1", fraction))
+	}
+}
+
+type stubClientCacheStorage struct {
+	ClientCacheStorage
+	stored []Client
+}
+
+func (s *stubClientCacheStorage) Store(_ context.Context, _ ctrlclient.Client, req ClientCacheStorageStoreRequest) (*corev1.Secret, error) {
+	s.stored = append(s.stored, req.Client)
+	return &corev1.Secret{}, nil
+}
+
+func Test_cachingClientFactory_onClientReLogin(t *testing.T) {
+	ctx := context.Background()
+	storage := &stubClientCacheStorage{}
+
+	config := DefaultCachingClientFactoryConfig()
+	config.MetricsRegistry = nil
+	config.Persist = true
+	f, err := NewCachingClientFactory(ctx, fake.NewClientBuilder().Build(), storage, config)
+	require.NoError(t, err)
+	factory := f.(*cachingClientFactory)
+
+	cached := newTestDefaultClient(t, "http://127.0.0.1:8200")
+	_, err = factory.cacheClient(cached)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		cached.Close(false)
+	})
+
+	// the re-logged in Client is persisted through the factory's ClientOptions.
+	factory.clientOptions.OnReLogin(cached)
+	assert.Equal(t, []Client{cached}, storage.stored)
+
+	// a Client that has since been replaced in the cache is not persisted.
+	replaced := newTestDefaultClient(t, "http://127.0.0.1:8200")
+	factory.clientOptions.OnReLogin(replaced)
+	assert.Equal(t, []Client{cached}, storage.stored)
+}
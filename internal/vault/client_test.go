@@ -4,14 +4,137 @@
 package vault
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/hashicorp/vault/api"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
 )
 
+var _ CredentialProvider = (*stubCredentialProvider)(nil)
+
+// stubCredentialProvider returns a fixed set of credentials.
+type stubCredentialProvider struct {
+	creds map[string]interface{}
+}
+
+func (p *stubCredentialProvider) Init(_ context.Context, _ ctrlclient.Client, _ *secretsv1alpha1.VaultAuth, _ string) error {
+	return nil
+}
+
+func (p *stubCredentialProvider) GetUID() types.UID {
+	return providerUID
+}
+
+func (p *stubCredentialProvider) GetNamespace() string {
+	return "tenant-ns"
+}
+
+func (p *stubCredentialProvider) GetCreds(_ context.Context, _ ctrlclient.Client) (map[string]interface{}, error) {
+	return p.creds, nil
+}
+
+func Test_defaultClient_Login(t *testing.T) {
+	tests := []struct {
+		name   string
+		creds  map[string]interface{}
+		params map[string]string
+		want   map[string]interface{}
+	}{
+		{
+			name: "without-params",
+			creds: map[string]interface{}{
+				"role": "role1",
+				"jwt":  "token",
+			},
+			want: map[string]interface{}{
+				"role": "role1",
+				"jwt":  "token",
+			},
+		},
+		{
+			name: "with-params",
+			creds: map[string]interface{}{
+				"role": "role1",
+				"jwt":  "token",
+			},
+			params: map[string]string{
+				"audience": "vault",
+				"nonce":    "foo",
+			},
+			want: map[string]interface{}{
+				"role":     "role1",
+				"jwt":      "token",
+				"audience": "vault",
+				"nonce":    "foo",
+			},
+		},
+		{
+			name: "creds-take-precedence",
+			creds: map[string]interface{}{
+				"role": "role1",
+			},
+			params: map[string]string{
+				"role": "role2",
+			},
+			want: map[string]interface{}{
+				"role": "role1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got map[string]interface{}
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/auth/custom/login", r.URL.Path)
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"auth": {"client_token": "s.token", "renewable": false}}`))
+			}))
+			t.Cleanup(server.Close)
+
+			config := api.DefaultConfig()
+			config.Address = server.URL
+			vc, err := api.NewClient(config)
+			require.NoError(t, err)
+
+			c := &defaultClient{
+				client: vc,
+				authObj: &secretsv1alpha1.VaultAuth{
+					Spec: secretsv1alpha1.VaultAuthSpec{
+						Mount:  "custom",
+						Params: tt.params,
+					},
+				},
+				connObj: &secretsv1alpha1.VaultConnection{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "default",
+						Namespace: "vso",
+					},
+				},
+				credentialProvider: &stubCredentialProvider{creds: tt.creds},
+			}
+
+			require.NoError(t, c.Login(context.Background(), fake.NewClientBuilder().Build()))
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "s.token", vc.Token())
+		})
+	}
+}
+
 func Test_defaultClient_CheckExpiry(t *testing.T) {
 	type fields struct {
 		lastResp    *api.Secret
@@ -156,3 +279,285 @@ func Test_defaultClient_CheckExpiry(t *testing.T) {
 		})
 	}
 }
+
+// newRevokeTestServer returns an httptest.Server that counts the number of
+// token revocation requests that it has received.
+func newRevokeTestServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var revoked atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/auth/token/revoke-self" {
+			revoked.Add(1)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+
+	return server, &revoked
+}
+
+// assertRevoked asserts that the number of revoked tokens settles at want, since tokens are revoked in the background.
+func assertRevoked(t *testing.T, revoked *atomic.Int32, want int) {
+	t.Helper()
+
+	assert.Eventually(t, func() bool {
+		return revoked.Load() == int32(want)
+	}, time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(want), revoked.Load())
+}
+
+func newTestDefaultClient(t *testing.T, address string) *defaultClient {
+	t.Helper()
+
+	config := api.DefaultConfig()
+	config.Address = address
+	vc, err := api.NewClient(config)
+	require.NoError(t, err)
+	vc.SetToken("s.token")
+
+	return &defaultClient{
+		client: vc,
+		authObj: &secretsv1alpha1.VaultAuth{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "default",
+				UID:  authUID,
+			},
+			Spec: secretsv1alpha1.VaultAuthSpec{
+				Method: providerMethodKubernetes,
+			},
+		},
+		connObj: &secretsv1alpha1.VaultConnection{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "default",
+				Namespace: "vso",
+				UID:       connUID,
+			},
+		},
+		authSecret: &api.Secret{
+			Auth: &api.SecretAuth{
+				ClientToken: "s.token",
+			},
+		},
+		credentialProvider: &stubCredentialProvider{},
+	}
+}
+
+func Test_defaultClient_Close(t *testing.T) {
+	tests := []struct {
+		name        string
+		revoke      bool
+		wantRevoked int
+	}{
+		{
+			name:        "with-revoke",
+			revoke:      true,
+			wantRevoked: 1,
+		},
+		{
+			name:        "without-revoke",
+			revoke:      false,
+			wantRevoked: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, revoked := newRevokeTestServer(t)
+			c := newTestDefaultClient(t, server.URL)
+
+			c.Close(tt.revoke)
+			assert.Nil(t, c.client)
+			assert.Nil(t, c.authSecret)
+			assertRevoked(t, revoked, tt.wantRevoked)
+
+			// closing again should be a no-op
+			c.Close(tt.revoke)
+			assertRevoked(t, revoked, tt.wantRevoked)
+		})
+	}
+}
+
+func Test_defaultClient_Login_reLogin(t *testing.T) {
+	tests := []struct {
+		name        string
+		skipRenewal bool
+		wantLogins  int
+	}{
+		{
+			name:       "non-renewable",
+			wantLogins: 3,
+		},
+		{
+			name:        "non-renewable-skip-renewal",
+			skipRenewal: true,
+			wantLogins:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var logins atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/v1/auth/custom/login" {
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				logins.Add(1)
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(`{"auth": {"client_token": "b.token", "renewable": false, "lease_duration": 1}}`))
+			}))
+			t.Cleanup(server.Close)
+
+			var reLogins atomic.Int32
+			c := newTestDefaultClient(t, server.URL)
+			c.authObj.Spec.Mount = "custom"
+			c.reLoginFraction = 0.1
+			c.skipRenewal = tt.skipRenewal
+			c.onReLogin = func(Client) {
+				reLogins.Add(1)
+			}
+			t.Cleanup(func() {
+				c.Close(false)
+			})
+
+			// the re-login must outlive the context of the initial login.
+			ctx, cancel := context.WithCancel(context.Background())
+			require.NoError(t, c.Login(ctx, fake.NewClientBuilder().Build()))
+			cancel()
+			if tt.wantLogins > 1 {
+				assert.Eventually(t, func() bool {
+					return logins.Load() >= int32(tt.wantLogins) && reLogins.Load() >= int32(tt.wantLogins-1)
+				}, 2*time.Second, 10*time.Millisecond)
+			} else {
+				time.Sleep(300 * time.Millisecond)
+				assert.Equal(t, int32(tt.wantLogins), logins.Load())
+				assert.Equal(t, int32(0), reLogins.Load())
+			}
+		})
+	}
+}
+
+func Test_defaultClient_failover(t *testing.T) {
+	newServer := func(status int, body string) (*httptest.Server, *int) {
+		var requests int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return server, &requests
+	}
+
+	healthy, healthyRequests := newServer(http.StatusOK, `{"data": {"foo": "bar"}}`)
+	sealed, _ := newServer(http.StatusServiceUnavailable, `{"errors": ["Vault is sealed"]}`)
+	forbidden, _ := newServer(http.StatusForbidden, `{"errors": ["permission denied"]}`)
+	rateLimited, _ := newServer(http.StatusTooManyRequests, `{"errors": ["request path \"secret/foo\": rate limit quota exceeded"]}`)
+	unreachable, _ := newServer(http.StatusOK, "")
+	unreachable.Close()
+
+	tests := []struct {
+		name        string
+		addresses   []string
+		wantAddress string
+		wantErr     bool
+	}{
+		{
+			name:        "primary-sealed",
+			addresses:   []string{sealed.URL, healthy.URL},
+			wantAddress: healthy.URL,
+		},
+		{
+			name:        "primary-unreachable",
+			addresses:   []string{unreachable.URL, sealed.URL, healthy.URL},
+			wantAddress: healthy.URL,
+		},
+		{
+			name:        "no-failover-on-other-errors",
+			addresses:   []string{forbidden.URL, healthy.URL},
+			wantAddress: forbidden.URL,
+			wantErr:     true,
+		},
+		{
+			name:        "no-failover-on-rate-limit",
+			addresses:   []string{rateLimited.URL, healthy.URL},
+			wantAddress: rateLimited.URL,
+			wantErr:     true,
+		},
+		{
+			name:        "all-unavailable",
+			addresses:   []string{sealed.URL, unreachable.URL},
+			wantAddress: unreachable.URL,
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			*healthyRequests = 0
+
+			c := newTestDefaultClient(t, tt.addresses[0])
+			c.client.SetMaxRetries(0)
+			c.addresses = tt.addresses
+
+			resp, err := c.Read(context.Background(), "secret/foo")
+			assert.Equal(t, tt.wantAddress, c.client.Address())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, map[string]interface{}{"foo": "bar"}, resp.Data)
+			assert.Equal(t, 1, *healthyRequests)
+
+			// subsequent requests should go to the active address.
+			_, err = c.Read(context.Background(), "secret/foo")
+			require.NoError(t, err)
+			assert.Equal(t, 2, *healthyRequests)
+		})
+	}
+}
+
+func Test_defaultClient_KVv2_failover(t *testing.T) {
+	newServer := func(status int) (*httptest.Server, *int, *int) {
+		var reads, healthChecks int
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			if r.URL.Path == "/v1/sys/health" {
+				healthChecks++
+				return
+			}
+			reads++
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"data": {"data": {"foo": "bar"}, "metadata": {"version": 1}}}`))
+		}))
+		t.Cleanup(server.Close)
+		return server, &reads, &healthChecks
+	}
+
+	active, activeReads, activeHealthChecks := newServer(http.StatusOK)
+	sealed, sealedReads, sealedHealthChecks := newServer(http.StatusServiceUnavailable)
+
+	c := newTestDefaultClient(t, sealed.URL)
+	c.client.SetMaxRetries(0)
+	c.addresses = []string{sealed.URL, active.URL}
+
+	ctx := context.Background()
+	resp, err := c.KVv2("kv").Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"foo": "bar"}, resp.Data)
+	assert.Equal(t, active.URL, c.client.Address())
+	assert.Equal(t, 1, *activeReads)
+	assert.Equal(t, 1, *sealedReads)
+
+	// subsequent requests go to the active address, without probing the health of either server.
+	_, err = c.KVv2("kv").Get(ctx, "foo")
+	require.NoError(t, err)
+	assert.Equal(t, 2, *activeReads)
+	assert.Equal(t, 1, *sealedReads)
+	assert.Equal(t, 0, *activeHealthChecks)
+	assert.Equal(t, 0, *sealedHealthChecks)
+}
@@ -5,7 +5,12 @@ package vault
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
+	"net/http"
+	"time"
 
 	"github.com/hashicorp/vault/api"
 	"k8s.io/api/core/v1"
@@ -14,6 +19,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
+// healthCheckTimeout is the maximum amount of time to wait on a Vault server's health check.
+const healthCheckTimeout = 5 * time.Second
+
 // ClientConfig contains the connection and auth information to construct a
 // Vault Client.
 type ClientConfig struct {
@@ -21,10 +29,20 @@ type ClientConfig struct {
 	// "ca.crt" that holds a CA cert that can be used to validate the
 	// certificate presented by the Vault server
 	CACertSecretRef string
-	// K8sNamespace the namespace of the CACertSecretRef secret
+	// ClientCertSecretRef is the name of a k8s secret of type kubernetes.io/tls
+	// that holds the client certificate and key that will be presented to the
+	// Vault server during the TLS handshake.
+	ClientCertSecretRef string
+	// ClientCertNamespace the namespace of the ClientCertSecretRef secret,
+	// defaults to K8sNamespace.
+	ClientCertNamespace string
+	// K8sNamespace the namespace of the CACertSecretRef and ClientCertSecretRef secrets
 	K8sNamespace string
 	// Address is the URL of the Vault server
 	Address string
+	// FallbackAddresses are the URLs of the Vault servers to fail over to, in order,
+	// should the Vault server at Address be unavailable.
+	FallbackAddresses []string
 	// SkipTLSVerify controls whether the Vault server's TLS certificate is
 	// verified
 	SkipTLSVerify bool
@@ -37,6 +55,11 @@ type ClientConfig struct {
 	// AuthLogin auth.AuthLogin
 }
 
+// addresses returns Address followed by all FallbackAddresses.
+func (c *ClientConfig) addresses() []string {
+	return append([]string{c.Address}, c.FallbackAddresses...)
+}
+
 // MakeVaultClient creates a Vault api.Client from a ClientConfig.
 func MakeVaultClient(ctx context.Context, cfg *ClientConfig, client ctrlclient.Client) (*api.Client, error) {
 	l := log.FromContext(ctx)
@@ -59,6 +82,27 @@ func MakeVaultClient(ctx context.Context, cfg *ClientConfig, client ctrlclient.C
 		}
 	}
 
+	var clientCert *tls.Certificate
+	if cfg.ClientCertSecretRef != "" {
+		ns := cfg.ClientCertNamespace
+		if ns == "" {
+			ns = cfg.K8sNamespace
+		}
+		s := &v1.Secret{}
+		if err := client.Get(ctx, types.NamespacedName{
+			Namespace: ns,
+			Name:      cfg.ClientCertSecretRef,
+		}, s); err != nil {
+			return nil, err
+		}
+		cert, err := tls.X509KeyPair(s.Data[v1.TLSCertKey], s.Data[v1.TLSPrivateKeyKey])
+		if err != nil {
+			return nil, fmt.Errorf("invalid client certificate in secret %s/%s: %w",
+				ns, cfg.ClientCertSecretRef, err)
+		}
+		clientCert = &cert
+	}
+
 	config := api.DefaultConfig()
 
 	config.Address = cfg.Address
@@ -70,14 +114,104 @@ func MakeVaultClient(ctx context.Context, cfg *ClientConfig, client ctrlclient.C
 		return nil, err
 	}
 
+	if clientCert != nil {
+		// api.TLSConfig only supports loading the client certificate from disk,
+		// so we set it on the transport's TLS config directly.
+		transport, ok := config.HttpClient.Transport.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("unsupported HTTP transport %T", config.HttpClient.Transport)
+		}
+		transport.TLSClientConfig.Certificates = []tls.Certificate{*clientCert}
+	}
+
 	c, err := api.NewClient(config)
 	if err != nil {
 		l.Error(err, "error setting up Vault API ctrlclient")
 		return nil, err
 	}
+
+	if len(cfg.FallbackAddresses) > 0 {
+		if err := selectAddress(ctx, c, cfg.addresses()); err != nil {
+			return nil, err
+		}
+	}
+
 	if cfg.VaultNamespace != "" {
 		c.SetNamespace(cfg.VaultNamespace)
 	}
 
 	return c, nil
 }
+
+// selectAddress sets the address of c to the first of addresses whose Vault server is healthy.
+// If none of the Vault servers are healthy, the first address will be used.
+func selectAddress(ctx context.Context, c *api.Client, addresses []string) error {
+	logger := log.FromContext(ctx).WithName("selectAddress")
+	for _, addr := range addresses {
+		if err := c.SetAddress(addr); err != nil {
+			return err
+		}
+
+		if err := checkHealth(ctx, c); err != nil {
+			logger.Info("Vault server unavailable, trying the next address", "address", addr, "err", err)
+			continue
+		}
+
+		return nil
+	}
+
+	logger.Info("No healthy Vault server found, using the primary address", "address", addresses[0])
+	return c.SetAddress(addresses[0])
+}
+
+var (
+	// ErrVaultUninitialized is returned by CheckHealthResponse for an uninitialized Vault server.
+	ErrVaultUninitialized = errors.New("vault server is not initialized")
+	// ErrVaultSealed is returned by CheckHealthResponse for a sealed Vault server.
+	ErrVaultSealed = errors.New("vault server is sealed")
+	// ErrVaultStandby is returned by CheckHealthResponse for a Vault server in standby.
+	ErrVaultStandby = errors.New("vault server is in standby")
+)
+
+// CheckHealthResponse returns an error if resp is that of a Vault server that is unable to service
+// requests, i.e. that is uninitialized, sealed, or in standby.
+// Performance standbys are considered to be healthy, since they are able to service requests.
+func CheckHealthResponse(resp *api.HealthResponse) error {
+	switch {
+	case !resp.Initialized:
+		return ErrVaultUninitialized
+	case resp.Sealed:
+		return ErrVaultSealed
+	case resp.Standby && !resp.PerformanceStandby:
+		return ErrVaultStandby
+	}
+
+	return nil
+}
+
+// checkHealth returns an error if the Vault server of c is unreachable, or unhealthy
+// as defined by CheckHealthResponse.
+func checkHealth(ctx context.Context, c *api.Client) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	resp, err := c.Sys().HealthWithContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return CheckHealthResponse(resp)
+}
+
+// isFailoverError returns true if err was the result of the Vault server being unavailable,
+// either because it could not be reached, or because it responded as being sealed or in standby.
+// Rate limited requests (429) are not failed over, since the other servers share the same quotas.
+func isFailoverError(err error) bool {
+	var respErr *api.ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.StatusCode == http.StatusServiceUnavailable
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -5,8 +5,10 @@ package vault
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
+	"github.com/google/uuid"
 	authv1 "k8s.io/api/authentication/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -21,9 +23,29 @@ import (
 const (
 	tokenGenerateName               = "vso-"
 	providerMethodKubernetes string = "kubernetes"
+	providerMethodJWT        string = "jwt"
+	providerMethodAppRole    string = "appRole"
+	providerMethodAWS        string = "aws"
+	providerMethodCert       string = "cert"
 )
 
-var providerMethodsSupported = []string{providerMethodKubernetes}
+var providerMethodsSupported = []string{providerMethodKubernetes, providerMethodJWT, providerMethodAppRole, providerMethodAWS, providerMethodCert}
+
+// providerOwnedParams are the login parameters that are set by each CredentialProvider.
+// They cannot be set from VaultAuthSpec.Params.
+var providerOwnedParams = map[string][]string{
+	providerMethodKubernetes: {"role", "jwt"},
+	providerMethodJWT:        {"role", "jwt"},
+	providerMethodAppRole:    {"role_id", "secret_id"},
+	providerMethodAWS: {
+		"role",
+		"iam_http_request_method",
+		"iam_request_url",
+		"iam_request_headers",
+		"iam_request_body",
+	},
+	providerMethodCert: {"name"},
+}
 
 type CredentialProvider interface {
 	Init(ctx context.Context, client ctrlclient.Client, object *secretsv1alpha1.VaultAuth, providerNamespace string) error
@@ -49,6 +71,10 @@ func (l *kubernetesCredentialProvider) GetUID() types.UID {
 }
 
 func (l *kubernetesCredentialProvider) Init(ctx context.Context, client ctrlclient.Client, authObj *secretsv1alpha1.VaultAuth, providerNamespace string) error {
+	if authObj.Spec.Kubernetes == nil {
+		return fmt.Errorf("kubernetes auth method not configured")
+	}
+
 	l.authObj = authObj
 	l.providerNamespace = providerNamespace
 
@@ -67,11 +93,7 @@ func (l *kubernetesCredentialProvider) getServiceAccount(ctx context.Context, cl
 		Namespace: l.providerNamespace,
 		Name:      l.authObj.Spec.Kubernetes.ServiceAccount,
 	}
-	sa := &corev1.ServiceAccount{}
-	if err := client.Get(ctx, key, sa); err != nil {
-		return nil, err
-	}
-	return sa, nil
+	return getServiceAccount(ctx, client, key)
 }
 
 func (l *kubernetesCredentialProvider) GetCreds(ctx context.Context, client ctrlclient.Client) (map[string]interface{}, error) {
@@ -83,7 +105,8 @@ func (l *kubernetesCredentialProvider) GetCreds(ctx context.Context, client ctrl
 		return nil, err
 	}
 
-	tr, err := l.requestSAToken(ctx, client, sa)
+	tr, err := requestSAToken(ctx, client, sa,
+		l.authObj.Spec.Kubernetes.TokenExpirationSeconds, l.authObj.Spec.Kubernetes.TokenAudiences)
 	if err != nil {
 		logger.Error(err, "Failed to get service account token")
 		return nil, err
@@ -96,15 +119,42 @@ func (l *kubernetesCredentialProvider) GetCreds(ctx context.Context, client ctrl
 	}, nil
 }
 
+func getServiceAccount(ctx context.Context, client ctrlclient.Client, key ctrlclient.ObjectKey) (*corev1.ServiceAccount, error) {
+	sa := &corev1.ServiceAccount{}
+	if err := client.Get(ctx, key, sa); err != nil {
+		return nil, err
+	}
+	return sa, nil
+}
+
+func getSecret(ctx context.Context, client ctrlclient.Client, key ctrlclient.ObjectKey) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	if err := client.Get(ctx, key, secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// secretProviderUID returns a UID that is unique to the current contents of secret.
+// It is derived from the Secret's UID and its data, so any change to the data, e.g. a credential
+// rotation, results in a new UID, and therefore a new ClientCacheKey. Changes to the Secret's
+// metadata, e.g. its labels or annotations, do not.
+func secretProviderUID(secret *corev1.Secret) types.UID {
+	// the keys of a map are encoded in sorted order, so the encoding of the same data never differs.
+	data, _ := json.Marshal(secret.Data)
+	return types.UID(uuid.NewSHA1(uuid.NameSpaceOID,
+		append([]byte(fmt.Sprintf("%s.", secret.UID)), data...)).String())
+}
+
 // requestSAToken for the provided ServiceAccount.
-func (l *kubernetesCredentialProvider) requestSAToken(ctx context.Context, client ctrlclient.Client, sa *corev1.ServiceAccount) (*authv1.TokenRequest, error) {
+func requestSAToken(ctx context.Context, client ctrlclient.Client, sa *corev1.ServiceAccount, expirationSeconds int64, audiences []string) (*authv1.TokenRequest, error) {
 	tr := &authv1.TokenRequest{
 		ObjectMeta: metav1.ObjectMeta{
 			GenerateName: tokenGenerateName,
 		},
 		Spec: authv1.TokenRequestSpec{
-			ExpirationSeconds: pointer.Int64(l.authObj.Spec.Kubernetes.TokenExpirationSeconds),
-			Audiences:         l.authObj.Spec.Kubernetes.TokenAudiences,
+			ExpirationSeconds: pointer.Int64(expirationSeconds),
+			Audiences:         audiences,
 		},
 		Status: authv1.TokenRequestStatus{},
 	}
@@ -116,7 +166,39 @@ func (l *kubernetesCredentialProvider) requestSAToken(ctx context.Context, clien
 	return tr, nil
 }
 
+// validateAuthParams ensures that none of the VaultAuthSpec.Params conflict
+// with the login parameters owned by the auth method's CredentialProvider.
+func validateAuthParams(authObj *secretsv1alpha1.VaultAuth) error {
+	var conflicts []string
+	for _, k := range providerOwnedParams[authObj.Spec.Method] {
+		if _, ok := authObj.Spec.Params[k]; ok {
+			conflicts = append(conflicts, k)
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("params %v are reserved for the %s auth method", conflicts, authObj.Spec.Method)
+	}
+	return nil
+}
+
+// mergeAuthParams returns the login payload for creds and the VaultAuthSpec.Params.
+// The credentials returned by the CredentialProvider always take precedence over params.
+func mergeAuthParams(creds map[string]interface{}, params map[string]string) map[string]interface{} {
+	result := make(map[string]interface{}, len(creds)+len(params))
+	for k, v := range params {
+		result[k] = v
+	}
+	for k, v := range creds {
+		result[k] = v
+	}
+	return result
+}
+
 func NewCredentialProvider(ctx context.Context, client ctrlclient.Client, authObj *secretsv1alpha1.VaultAuth, providerNamespace string) (CredentialProvider, error) {
+	if err := validateAuthParams(authObj); err != nil {
+		return nil, err
+	}
+
 	switch authObj.Spec.Method {
 	case providerMethodKubernetes:
 		provider := &kubernetesCredentialProvider{}
@@ -124,6 +206,30 @@ func NewCredentialProvider(ctx context.Context, client ctrlclient.Client, authOb
 			return nil, err
 		}
 		return provider, nil
+	case providerMethodJWT:
+		provider := &jwtCredentialProvider{}
+		if err := provider.Init(ctx, client, authObj, providerNamespace); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	case providerMethodAppRole:
+		provider := &appRoleCredentialProvider{}
+		if err := provider.Init(ctx, client, authObj, providerNamespace); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	case providerMethodAWS:
+		provider := &awsCredentialProvider{}
+		if err := provider.Init(ctx, client, authObj, providerNamespace); err != nil {
+			return nil, err
+		}
+		return provider, nil
+	case providerMethodCert:
+		provider := &certCredentialProvider{}
+		if err := provider.Init(ctx, client, authObj, providerNamespace); err != nil {
+			return nil, err
+		}
+		return provider, nil
 	default:
 		return nil, fmt.Errorf("unsupported authentication method %s", authObj.Spec.Method)
 	}
@@ -0,0 +1,95 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+// appRoleSecretIDKey is the key in the Secret referenced by VaultAuthConfigAppRole.SecretRef
+// that holds the AppRole Role's SecretID.
+const appRoleSecretIDKey = "id"
+
+var _ CredentialProvider = (*appRoleCredentialProvider)(nil)
+
+// appRoleCredentialProvider provides the credentials needed for Vault's AppRole auth backend.
+// The SecretID is read from a Secret in the provider namespace.
+type appRoleCredentialProvider struct {
+	authObj           *secretsv1alpha1.VaultAuth
+	providerNamespace string
+	uid               types.UID
+}
+
+func (l *appRoleCredentialProvider) GetNamespace() string {
+	return l.providerNamespace
+}
+
+// GetUID returns a UID derived from the SecretID Secret. It changes whenever the Secret is updated,
+// so that rotating the SecretID forces a new login.
+func (l *appRoleCredentialProvider) GetUID() types.UID {
+	return l.uid
+}
+
+func (l *appRoleCredentialProvider) Init(ctx context.Context, client ctrlclient.Client, authObj *secretsv1alpha1.VaultAuth, providerNamespace string) error {
+	if authObj.Spec.AppRole == nil {
+		return fmt.Errorf("AppRole auth method not configured")
+	}
+	if authObj.Spec.AppRole.RoleID == "" {
+		return fmt.Errorf("roleId must be set for the AppRole auth method")
+	}
+	if authObj.Spec.AppRole.SecretRef == "" {
+		return fmt.Errorf("secretRef must be set for the AppRole auth method")
+	}
+
+	l.authObj = authObj
+	l.providerNamespace = providerNamespace
+
+	secret, err := l.getSecret(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	l.uid = secretProviderUID(secret)
+
+	return nil
+}
+
+func (l *appRoleCredentialProvider) getSecret(ctx context.Context, client ctrlclient.Client) (*corev1.Secret, error) {
+	key := ctrlclient.ObjectKey{
+		Namespace: l.providerNamespace,
+		Name:      l.authObj.Spec.AppRole.SecretRef,
+	}
+	return getSecret(ctx, client, key)
+}
+
+func (l *appRoleCredentialProvider) GetCreds(ctx context.Context, client ctrlclient.Client) (map[string]interface{}, error) {
+	logger := log.FromContext(ctx)
+
+	secret, err := l.getSecret(ctx, client)
+	if err != nil {
+		logger.Error(err, "Failed to get AppRole SecretID secret")
+		return nil, err
+	}
+
+	b, ok := secret.Data[appRoleSecretIDKey]
+	if !ok || len(b) == 0 {
+		err := fmt.Errorf("no key %q found in secret %s", appRoleSecretIDKey, ctrlclient.ObjectKeyFromObject(secret))
+		logger.Error(err, "Failed to get SecretID from secret")
+		return nil, err
+	}
+
+	// credentials needed for AppRole auth
+	return map[string]interface{}{
+		"role_id":   l.authObj.Spec.AppRole.RoleID,
+		"secret_id": string(b),
+	}, nil
+}
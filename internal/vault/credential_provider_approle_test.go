@@ -0,0 +1,168 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+func Test_appRoleCredentialProvider(t *testing.T) {
+	tests := map[string]struct {
+		appRoleConfig *secretsv1alpha1.VaultAuthConfigAppRole
+		secretData    map[string][]byte
+		wantCreds     map[string]interface{}
+		wantInitErr   string
+		wantCredsErr  string
+	}{
+		"valid": {
+			appRoleConfig: &secretsv1alpha1.VaultAuthConfigAppRole{
+				RoleID:    "role-id-1",
+				SecretRef: "approle-secret",
+			},
+			secretData: map[string][]byte{
+				"id": []byte("secret-id-1"),
+			},
+			wantCreds: map[string]interface{}{
+				"role_id":   "role-id-1",
+				"secret_id": "secret-id-1",
+			},
+		},
+		"missing-key": {
+			appRoleConfig: &secretsv1alpha1.VaultAuthConfigAppRole{
+				RoleID:    "role-id-1",
+				SecretRef: "approle-secret",
+			},
+			secretData:   map[string][]byte{},
+			wantCredsErr: `no key "id" found in secret tenant-ns/approle-secret`,
+		},
+		"secret-not-found": {
+			appRoleConfig: &secretsv1alpha1.VaultAuthConfigAppRole{
+				RoleID:    "role-id-1",
+				SecretRef: "other",
+			},
+			wantInitErr: `secrets "other" not found`,
+		},
+		"not-configured": {
+			wantInitErr: "AppRole auth method not configured",
+		},
+		"no-role-id": {
+			appRoleConfig: &secretsv1alpha1.VaultAuthConfigAppRole{
+				SecretRef: "approle-secret",
+			},
+			wantInitErr: "roleId must be set for the AppRole auth method",
+		},
+		"no-secret-ref": {
+			appRoleConfig: &secretsv1alpha1.VaultAuthConfigAppRole{
+				RoleID: "role-id-1",
+			},
+			wantInitErr: "secretRef must be set for the AppRole auth method",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "approle-secret",
+					Namespace: "tenant-ns",
+					UID:       providerUID,
+				},
+				Data: tc.secretData,
+			}).Build()
+
+			authObj := &secretsv1alpha1.VaultAuth{
+				Spec: secretsv1alpha1.VaultAuthSpec{
+					Method:  providerMethodAppRole,
+					Mount:   "approle",
+					AppRole: tc.appRoleConfig,
+				},
+			}
+
+			p, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+			if tc.wantInitErr != "" {
+				assert.EqualError(t, err, tc.wantInitErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, p.GetUID(), 36)
+			assert.NotEqual(t, providerUID, p.GetUID())
+
+			creds, err := p.GetCreds(ctx, client)
+			if tc.wantCredsErr != "" {
+				assert.EqualError(t, err, tc.wantCredsErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCreds, creds)
+		})
+	}
+}
+
+func Test_appRoleCredentialProvider_rotation(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "approle-secret",
+			Namespace: "tenant-ns",
+			UID:       providerUID,
+		},
+		Data: map[string][]byte{
+			"id": []byte("secret-id-1"),
+		},
+	}
+	client := fake.NewClientBuilder().WithObjects(secret).Build()
+
+	authObj := &secretsv1alpha1.VaultAuth{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: authUID,
+		},
+		Spec: secretsv1alpha1.VaultAuthSpec{
+			Method: providerMethodAppRole,
+			Mount:  "approle",
+			AppRole: &secretsv1alpha1.VaultAuthConfigAppRole{
+				RoleID:    "role-id-1",
+				SecretRef: "approle-secret",
+			},
+		},
+	}
+	connObj := &secretsv1alpha1.VaultConnection{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: connUID,
+		},
+	}
+
+	cacheKey := func() (types.UID, ClientCacheKey) {
+		p, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+		require.NoError(t, err)
+		key, err := computeClientCacheKey(authObj, connObj, p.GetUID())
+		require.NoError(t, err)
+		return p.GetUID(), key
+	}
+
+	uid1, key1 := cacheKey()
+	uidUnchanged, keyUnchanged := cacheKey()
+	assert.Equal(t, uid1, uidUnchanged)
+	assert.Equal(t, key1, keyUnchanged)
+
+	// rotate the SecretID
+	require.NoError(t, client.Get(ctx, ctrlclient.ObjectKeyFromObject(secret), secret))
+	secret.Data["id"] = []byte("secret-id-2")
+	require.NoError(t, client.Update(ctx, secret))
+
+	uid2, key2 := cacheKey()
+	assert.NotEqual(t, uid1, uid2)
+	assert.NotEqual(t, key1, key2)
+}
@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+	"github.com/google/uuid"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+const (
+	awsDefaultRegion          = "us-east-1"
+	awsGlobalSTSEndpoint      = "https://sts.amazonaws.com"
+	awsIAMServerIDHeader      = "X-Vault-AWS-IAM-Server-ID"
+	awsGetCallerIdentityBody  = "Action=GetCallerIdentity&Version=2011-06-15"
+	awsGetCallerIdentityCType = "application/x-www-form-urlencoded; charset=utf-8"
+)
+
+var _ CredentialProvider = (*awsCredentialProvider)(nil)
+
+// awsCredentialProvider provides the credentials needed for Vault's AWS IAM auth backend.
+// The login payload is a signed sts:GetCallerIdentity request, the AWS credentials are taken from
+// the Operator's environment, this includes the web identity token file configured by IRSA.
+type awsCredentialProvider struct {
+	authObj           *secretsv1alpha1.VaultAuth
+	providerNamespace string
+	uid               types.UID
+	// credentials used for signing the request, if nil they will be resolved from the environment.
+	credentials *credentials.Credentials
+	// now returns the signing time, can be overridden for testing purposes.
+	now func() time.Time
+}
+
+func (l *awsCredentialProvider) GetNamespace() string {
+	return l.providerNamespace
+}
+
+func (l *awsCredentialProvider) GetUID() types.UID {
+	return l.uid
+}
+
+func (l *awsCredentialProvider) Init(_ context.Context, _ ctrlclient.Client, authObj *secretsv1alpha1.VaultAuth, providerNamespace string) error {
+	if authObj.Spec.AWS == nil {
+		return fmt.Errorf("AWS auth method not configured")
+	}
+
+	l.authObj = authObj
+	l.providerNamespace = providerNamespace
+	l.now = time.Now
+
+	// there is no Kubernetes object backing the AWS credentials, so we derive the UID from
+	// the VaultAuth, the provider namespace, and the AWS identity found in the environment.
+	identity := os.Getenv("AWS_ROLE_ARN")
+	if identity == "" {
+		identity = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	l.uid = types.UID(uuid.NewSHA1(uuid.NameSpaceOID,
+		[]byte(fmt.Sprintf("%s.%s.%s", authObj.UID, providerNamespace, identity))).String())
+
+	return nil
+}
+
+func (l *awsCredentialProvider) GetCreds(ctx context.Context, _ ctrlclient.Client) (map[string]interface{}, error) {
+	logger := log.FromContext(ctx)
+
+	region, endpoint := awsSTSRegionAndEndpoint(l.authObj.Spec.AWS)
+	creds := l.credentials
+	if creds == nil {
+		// the default credential chain handles the environment variables,
+		// the IRSA web identity token file, and shared credentials.
+		sess, err := session.NewSession(&aws.Config{
+			Region: aws.String(region),
+		})
+		if err != nil {
+			logger.Error(err, "Failed to create AWS session")
+			return nil, err
+		}
+		creds = sess.Config.Credentials
+	}
+
+	loginData, err := generateAWSLoginData(creds, region, endpoint, l.authObj.Spec.AWS.HeaderValue, l.now())
+	if err != nil {
+		logger.Error(err, "Failed to generate AWS login data")
+		return nil, err
+	}
+
+	// credentials needed for AWS IAM auth
+	loginData["role"] = l.authObj.Spec.AWS.Role
+	return loginData, nil
+}
+
+// awsSTSRegionAndEndpoint returns the signing region and the STS endpoint for cfg.
+func awsSTSRegionAndEndpoint(cfg *secretsv1alpha1.VaultAuthConfigAWS) (string, string) {
+	region := cfg.Region
+	endpoint := cfg.STSEndpoint
+	if region == "" {
+		region = awsDefaultRegion
+		if endpoint == "" {
+			endpoint = awsGlobalSTSEndpoint
+		}
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://sts.%s.amazonaws.com", region)
+	}
+
+	return region, endpoint
+}
+
+// generateAWSLoginData returns the login payload for Vault's AWS IAM auth backend.
+// It contains a sts:GetCallerIdentity request signed with creds at signTime.
+// If headerValue is set, it will be included in the signed request as the X-Vault-AWS-IAM-Server-ID header.
+func generateAWSLoginData(creds *credentials.Credentials, region, endpoint, headerValue string, signTime time.Time) (map[string]interface{}, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(awsGetCallerIdentityBody))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", awsGetCallerIdentityCType)
+	if headerValue != "" {
+		req.Header.Set(awsIAMServerIDHeader, headerValue)
+	}
+
+	if _, err := v4.NewSigner(creds).Sign(req, strings.NewReader(awsGetCallerIdentityBody), "sts", region, signTime); err != nil {
+		return nil, err
+	}
+
+	headers, err := json.Marshal(req.Header)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"iam_http_request_method": req.Method,
+		"iam_request_url":         base64.StdEncoding.EncodeToString([]byte(req.URL.String())),
+		"iam_request_headers":     base64.StdEncoding.EncodeToString(headers),
+		"iam_request_body":        base64.StdEncoding.EncodeToString([]byte(awsGetCallerIdentityBody)),
+	}, nil
+}
@@ -0,0 +1,155 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+func Test_awsCredentialProvider_GetCreds(t *testing.T) {
+	signTime := time.Date(2023, 4, 1, 12, 0, 0, 0, time.UTC)
+	creds := credentials.NewStaticCredentials("AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "")
+
+	tests := map[string]struct {
+		awsConfig         *secretsv1alpha1.VaultAuthConfigAWS
+		wantURL           string
+		wantAuthorization string
+		wantHeaderValue   string
+	}{
+		"global-endpoint": {
+			awsConfig: &secretsv1alpha1.VaultAuthConfigAWS{
+				Role: "role1",
+			},
+			wantURL: "https://sts.amazonaws.com",
+			wantAuthorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20230401/us-east-1/sts/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, " +
+				"Signature=5609c2d19f185fa1a3c9d4277da1033b34ecef9874ada630a57537a9a88ec953",
+		},
+		"regional-endpoint-with-header-value": {
+			awsConfig: &secretsv1alpha1.VaultAuthConfigAWS{
+				Role:        "role1",
+				Region:      "eu-west-1",
+				HeaderValue: "vault.example.com",
+			},
+			wantURL: "https://sts.eu-west-1.amazonaws.com",
+			wantAuthorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20230401/eu-west-1/sts/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date;x-vault-aws-iam-server-id, " +
+				"Signature=42c8bd97c7dba8346b4af61590e1148e08c6c896bdc94fb64526c9a029b6fe81",
+			wantHeaderValue: "vault.example.com",
+		},
+		"custom-endpoint": {
+			awsConfig: &secretsv1alpha1.VaultAuthConfigAWS{
+				Role:        "role1",
+				Region:      "us-west-2",
+				STSEndpoint: "https://sts-fips.us-west-2.amazonaws.com",
+			},
+			wantURL: "https://sts-fips.us-west-2.amazonaws.com",
+			wantAuthorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20230401/us-west-2/sts/aws4_request, " +
+				"SignedHeaders=content-type;host;x-amz-date, " +
+				"Signature=c8eee720853117d427583984173901f5dcc5a865a676094f8acc165016b47b2a",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.NewClientBuilder().Build()
+			authObj := &secretsv1alpha1.VaultAuth{
+				ObjectMeta: metav1.ObjectMeta{
+					UID: authUID,
+				},
+				Spec: secretsv1alpha1.VaultAuthSpec{
+					Method: providerMethodAWS,
+					Mount:  "aws",
+					AWS:    tc.awsConfig,
+				},
+			}
+
+			p, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+			require.NoError(t, err)
+			assert.Len(t, p.GetUID(), 36)
+
+			provider := p.(*awsCredentialProvider)
+			provider.credentials = creds
+			provider.now = func() time.Time {
+				return signTime
+			}
+
+			got, err := provider.GetCreds(ctx, client)
+			require.NoError(t, err)
+
+			assert.Equal(t, "role1", got["role"])
+			assert.Equal(t, http.MethodPost, got["iam_http_request_method"])
+			assert.Equal(t, tc.wantURL, decodeB64(t, got["iam_request_url"]))
+			assert.Equal(t, "Action=GetCallerIdentity&Version=2011-06-15", decodeB64(t, got["iam_request_body"]))
+
+			var headers http.Header
+			require.NoError(t, json.Unmarshal([]byte(decodeB64(t, got["iam_request_headers"])), &headers))
+			assert.Equal(t, "20230401T120000Z", headers.Get("X-Amz-Date"))
+			assert.Equal(t, tc.wantHeaderValue, headers.Get("X-Vault-AWS-IAM-Server-ID"))
+			assert.Equal(t, tc.wantAuthorization, headers.Get("Authorization"))
+
+			// signing is deterministic for a fixed clock and fixed credentials.
+			again, err := provider.GetCreds(ctx, client)
+			require.NoError(t, err)
+			assert.Equal(t, got, again)
+		})
+	}
+}
+
+func Test_awsCredentialProvider_Init(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientBuilder().Build()
+	authObj := &secretsv1alpha1.VaultAuth{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: authUID,
+		},
+		Spec: secretsv1alpha1.VaultAuthSpec{
+			Method: providerMethodAWS,
+			Mount:  "aws",
+		},
+	}
+
+	_, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+	assert.EqualError(t, err, "AWS auth method not configured")
+
+	authObj.Spec.AWS = &secretsv1alpha1.VaultAuthConfigAWS{Role: "role1"}
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/vso")
+	p1, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+	require.NoError(t, err)
+	p2, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+	require.NoError(t, err)
+	assert.Equal(t, p1.GetUID(), p2.GetUID())
+
+	p3, err := NewCredentialProvider(ctx, client, authObj, "other-ns")
+	require.NoError(t, err)
+	assert.NotEqual(t, p1.GetUID(), p3.GetUID())
+
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/other")
+	p4, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+	require.NoError(t, err)
+	assert.NotEqual(t, p1.GetUID(), p4.GetUID())
+}
+
+func decodeB64(t *testing.T, v interface{}) string {
+	t.Helper()
+	s, ok := v.(string)
+	require.True(t, ok, "expected a string, got %T", v)
+	b, err := base64.StdEncoding.DecodeString(s)
+	require.NoError(t, err)
+	return string(b)
+}
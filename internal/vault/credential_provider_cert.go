@@ -0,0 +1,75 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+var _ CredentialProvider = (*certCredentialProvider)(nil)
+
+// certCredentialProvider provides the credentials needed for Vault's TLS certificate auth backend.
+// The client certificate itself is presented during the TLS handshake, it is loaded by
+// MakeVaultClient from the kubernetes.io/tls Secret referenced by VaultAuthConfigCert.SecretRef.
+type certCredentialProvider struct {
+	authObj           *secretsv1alpha1.VaultAuth
+	providerNamespace string
+	uid               types.UID
+}
+
+func (l *certCredentialProvider) GetNamespace() string {
+	return l.providerNamespace
+}
+
+// GetUID returns a UID derived from the client certificate Secret. It changes whenever the Secret is updated,
+// so that rotating the certificate forces a new client and login.
+func (l *certCredentialProvider) GetUID() types.UID {
+	return l.uid
+}
+
+func (l *certCredentialProvider) Init(ctx context.Context, client ctrlclient.Client, authObj *secretsv1alpha1.VaultAuth, providerNamespace string) error {
+	if authObj.Spec.Cert == nil {
+		return fmt.Errorf("cert auth method not configured")
+	}
+	if authObj.Spec.Cert.SecretRef == "" {
+		return fmt.Errorf("secretRef must be set for the cert auth method")
+	}
+
+	l.authObj = authObj
+	l.providerNamespace = providerNamespace
+
+	secret, err := getSecret(ctx, client, ctrlclient.ObjectKey{
+		Namespace: providerNamespace,
+		Name:      authObj.Spec.Cert.SecretRef,
+	})
+	if err != nil {
+		return err
+	}
+
+	if secret.Type != corev1.SecretTypeTLS {
+		return fmt.Errorf("secret %s must be of type %s, got %q",
+			ctrlclient.ObjectKeyFromObject(secret), corev1.SecretTypeTLS, secret.Type)
+	}
+
+	l.uid = secretProviderUID(secret)
+
+	return nil
+}
+
+func (l *certCredentialProvider) GetCreds(_ context.Context, _ ctrlclient.Client) (map[string]interface{}, error) {
+	// credentials needed for cert auth, the certificate is presented by the Vault client's TLS config.
+	creds := map[string]interface{}{}
+	if l.authObj.Spec.Cert.Role != "" {
+		creds["name"] = l.authObj.Spec.Cert.Role
+	}
+
+	return creds, nil
+}
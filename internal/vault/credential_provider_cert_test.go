@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+func Test_certCredentialProvider(t *testing.T) {
+	tests := map[string]struct {
+		certConfig  *secretsv1alpha1.VaultAuthConfigCert
+		secretType  corev1.SecretType
+		wantCreds   map[string]interface{}
+		wantInitErr string
+	}{
+		"valid-with-role": {
+			certConfig: &secretsv1alpha1.VaultAuthConfigCert{
+				Role:      "web",
+				SecretRef: "client-cert",
+			},
+			secretType: corev1.SecretTypeTLS,
+			wantCreds: map[string]interface{}{
+				"name": "web",
+			},
+		},
+		"valid-without-role": {
+			certConfig: &secretsv1alpha1.VaultAuthConfigCert{
+				SecretRef: "client-cert",
+			},
+			secretType: corev1.SecretTypeTLS,
+			wantCreds:  map[string]interface{}{},
+		},
+		"invalid-secret-type": {
+			certConfig: &secretsv1alpha1.VaultAuthConfigCert{
+				SecretRef: "client-cert",
+			},
+			secretType:  corev1.SecretTypeOpaque,
+			wantInitErr: `secret tenant-ns/client-cert must be of type kubernetes.io/tls, got "Opaque"`,
+		},
+		"secret-not-found": {
+			certConfig: &secretsv1alpha1.VaultAuthConfigCert{
+				SecretRef: "other",
+			},
+			secretType:  corev1.SecretTypeTLS,
+			wantInitErr: `secrets "other" not found`,
+		},
+		"not-configured": {
+			wantInitErr: "cert auth method not configured",
+		},
+		"no-secret-ref": {
+			certConfig:  &secretsv1alpha1.VaultAuthConfigCert{},
+			wantInitErr: "secretRef must be set for the cert auth method",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			client := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "client-cert",
+					Namespace: "tenant-ns",
+					UID:       providerUID,
+				},
+				Type: tc.secretType,
+			}).Build()
+
+			authObj := &secretsv1alpha1.VaultAuth{
+				Spec: secretsv1alpha1.VaultAuthSpec{
+					Method: providerMethodCert,
+					Mount:  "cert",
+					Cert:   tc.certConfig,
+				},
+			}
+
+			p, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+			if tc.wantInitErr != "" {
+				assert.EqualError(t, err, tc.wantInitErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, p.GetUID(), 36)
+
+			creds, err := p.GetCreds(ctx, client)
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCreds, creds)
+		})
+	}
+}
+
+func Test_certCredentialProvider_rotation(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "client-cert",
+			Namespace: "tenant-ns",
+			UID:       providerUID,
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey: []byte("cert-1"),
+		},
+	}
+	client := fake.NewClientBuilder().WithObjects(secret).Build()
+
+	authObj := &secretsv1alpha1.VaultAuth{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: authUID,
+		},
+		Spec: secretsv1alpha1.VaultAuthSpec{
+			Method: providerMethodCert,
+			Mount:  "cert",
+			Cert: &secretsv1alpha1.VaultAuthConfigCert{
+				SecretRef: "client-cert",
+			},
+		},
+	}
+	connObj := &secretsv1alpha1.VaultConnection{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: connUID,
+		},
+	}
+
+	cacheKey := func() ClientCacheKey {
+		p, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+		require.NoError(t, err)
+		key, err := computeClientCacheKey(authObj, connObj, p.GetUID())
+		require.NoError(t, err)
+		return key
+	}
+
+	key1 := cacheKey()
+	assert.Equal(t, key1, cacheKey())
+
+	// rotate the client certificate
+	require.NoError(t, client.Get(ctx, ctrlclient.ObjectKeyFromObject(secret), secret))
+	secret.Data[corev1.TLSCertKey] = []byte("cert-2")
+	require.NoError(t, client.Update(ctx, secret))
+
+	assert.NotEqual(t, key1, cacheKey())
+}
@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+// jwtSecretKey is the key in the Secret referenced by VaultAuthConfigJWT.SecretRef
+// that holds the JWT token.
+const jwtSecretKey = "jwt"
+
+var _ CredentialProvider = (*jwtCredentialProvider)(nil)
+
+// jwtCredentialProvider provides the credentials needed for Vault's JWT auth backend.
+// The JWT can either be requested for a ServiceAccount, or read from a referenced Secret.
+type jwtCredentialProvider struct {
+	authObj           *secretsv1alpha1.VaultAuth
+	providerNamespace string
+	uid               types.UID
+}
+
+func (l *jwtCredentialProvider) GetNamespace() string {
+	return l.providerNamespace
+}
+
+func (l *jwtCredentialProvider) GetUID() types.UID {
+	return l.uid
+}
+
+func (l *jwtCredentialProvider) Init(ctx context.Context, client ctrlclient.Client, authObj *secretsv1alpha1.VaultAuth, providerNamespace string) error {
+	if authObj.Spec.JWT == nil {
+		return fmt.Errorf("JWT auth method not configured")
+	}
+	if err := validateJWTConfig(authObj.Spec.JWT); err != nil {
+		return err
+	}
+
+	l.authObj = authObj
+	l.providerNamespace = providerNamespace
+
+	if authObj.Spec.JWT.SecretRef != "" {
+		secret, err := l.getSecret(ctx, client)
+		if err != nil {
+			return err
+		}
+		// the JWT may be rotated in place, so the UID must change along with the Secret's contents.
+		l.uid = secretProviderUID(secret)
+	} else {
+		sa, err := l.getServiceAccount(ctx, client)
+		if err != nil {
+			return err
+		}
+		l.uid = sa.UID
+	}
+
+	return nil
+}
+
+func (l *jwtCredentialProvider) getServiceAccount(ctx context.Context, client ctrlclient.Client) (*corev1.ServiceAccount, error) {
+	key := ctrlclient.ObjectKey{
+		Namespace: l.providerNamespace,
+		Name:      l.authObj.Spec.JWT.ServiceAccount,
+	}
+	return getServiceAccount(ctx, client, key)
+}
+
+func (l *jwtCredentialProvider) getSecret(ctx context.Context, client ctrlclient.Client) (*corev1.Secret, error) {
+	key := ctrlclient.ObjectKey{
+		Namespace: l.providerNamespace,
+		Name:      l.authObj.Spec.JWT.SecretRef,
+	}
+	return getSecret(ctx, client, key)
+}
+
+func (l *jwtCredentialProvider) GetCreds(ctx context.Context, client ctrlclient.Client) (map[string]interface{}, error) {
+	logger := log.FromContext(ctx)
+
+	var token string
+	if l.authObj.Spec.JWT.SecretRef != "" {
+		secret, err := l.getSecret(ctx, client)
+		if err != nil {
+			logger.Error(err, "Failed to get JWT secret")
+			return nil, err
+		}
+
+		b, ok := secret.Data[jwtSecretKey]
+		if !ok || len(b) == 0 {
+			err := fmt.Errorf("no key %q found in secret %s", jwtSecretKey, ctrlclient.ObjectKeyFromObject(secret))
+			logger.Error(err, "Failed to get JWT from secret")
+			return nil, err
+		}
+		token = string(b)
+	} else {
+		sa, err := l.getServiceAccount(ctx, client)
+		if err != nil {
+			logger.Error(err, "Failed to get service account")
+			return nil, err
+		}
+
+		tr, err := requestSAToken(ctx, client, sa,
+			l.authObj.Spec.JWT.TokenExpirationSeconds, l.authObj.Spec.JWT.TokenAudiences)
+		if err != nil {
+			logger.Error(err, "Failed to get service account token")
+			return nil, err
+		}
+		token = tr.Status.Token
+	}
+
+	// credentials needed for JWT auth
+	return map[string]interface{}{
+		"role": l.authObj.Spec.JWT.Role,
+		"jwt":  token,
+	}, nil
+}
+
+// validateJWTConfig ensures that exactly one JWT source is configured.
+func validateJWTConfig(cfg *secretsv1alpha1.VaultAuthConfigJWT) error {
+	if cfg.SecretRef == "" && cfg.ServiceAccount == "" {
+		return fmt.Errorf("one of secretRef or serviceAccount must be set for the JWT auth method")
+	}
+	if cfg.SecretRef != "" && cfg.ServiceAccount != "" {
+		return fmt.Errorf("secretRef and serviceAccount are mutually exclusive for the JWT auth method")
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+// tokenRequestClient creates ServiceAccount tokens, which the fake client does not support.
+type tokenRequestClient struct {
+	ctrlclient.Client
+	token    string
+	requests []*authv1.TokenRequest
+}
+
+func (c *tokenRequestClient) SubResource(subResource string) ctrlclient.SubResourceClient {
+	return &tokenSubResourceClient{SubResourceClient: c.Client.SubResource(subResource), c: c}
+}
+
+type tokenSubResourceClient struct {
+	ctrlclient.SubResourceClient
+	c *tokenRequestClient
+}
+
+func (s *tokenSubResourceClient) Create(_ context.Context, _ ctrlclient.Object, subResource ctrlclient.Object, _ ...ctrlclient.SubResourceCreateOption) error {
+	tr := subResource.(*authv1.TokenRequest)
+	tr.Status.Token = s.c.token
+	s.c.requests = append(s.c.requests, tr)
+	return nil
+}
+
+func Test_jwtCredentialProvider(t *testing.T) {
+	secretUID := types.UID("c4fad6b9-e7bb-4ed8-bc38-67fd6dc85a38")
+	saUID := types.UID("0e2b6a9c-1f7d-4c0b-9d5e-3a8f6b2c1d4e")
+	tests := map[string]struct {
+		jwtConfig     *secretsv1alpha1.VaultAuthConfigJWT
+		secretData    map[string][]byte
+		wantCreds     map[string]interface{}
+		wantUID       types.UID
+		wantInitErr   string
+		wantCredsErr  string
+		skipSecretObj bool
+	}{
+		"secret-ref": {
+			jwtConfig: &secretsv1alpha1.VaultAuthConfigJWT{
+				Role:      "role1",
+				SecretRef: "jwt-secret",
+			},
+			secretData: map[string][]byte{
+				"jwt": []byte("token1"),
+			},
+			wantCreds: map[string]interface{}{
+				"role": "role1",
+				"jwt":  "token1",
+			},
+		},
+		"service-account": {
+			jwtConfig: &secretsv1alpha1.VaultAuthConfigJWT{
+				Role:                   "role1",
+				ServiceAccount:         "default",
+				TokenExpirationSeconds: 600,
+				TokenAudiences:         []string{"vault"},
+			},
+			wantCreds: map[string]interface{}{
+				"role": "role1",
+				"jwt":  "sa-token",
+			},
+			wantUID: saUID,
+		},
+		"service-account-not-found": {
+			jwtConfig: &secretsv1alpha1.VaultAuthConfigJWT{
+				Role:           "role1",
+				ServiceAccount: "other",
+			},
+			wantInitErr: `serviceaccounts "other" not found`,
+		},
+		"secret-ref-missing-key": {
+			jwtConfig: &secretsv1alpha1.VaultAuthConfigJWT{
+				Role:      "role1",
+				SecretRef: "jwt-secret",
+			},
+			secretData: map[string][]byte{
+				"token": []byte("token1"),
+			},
+			wantCredsErr: `no key "jwt" found in secret tenant-ns/jwt-secret`,
+		},
+		"secret-ref-not-found": {
+			jwtConfig: &secretsv1alpha1.VaultAuthConfigJWT{
+				Role:      "role1",
+				SecretRef: "jwt-secret",
+			},
+			skipSecretObj: true,
+			wantInitErr:   `secrets "jwt-secret" not found`,
+		},
+		"not-configured": {
+			wantInitErr: "JWT auth method not configured",
+		},
+		"no-source": {
+			jwtConfig: &secretsv1alpha1.VaultAuthConfigJWT{
+				Role: "role1",
+			},
+			wantInitErr: "one of secretRef or serviceAccount must be set for the JWT auth method",
+		},
+		"both-sources": {
+			jwtConfig: &secretsv1alpha1.VaultAuthConfigJWT{
+				Role:           "role1",
+				SecretRef:      "jwt-secret",
+				ServiceAccount: "default",
+			},
+			wantInitErr: "secretRef and serviceAccount are mutually exclusive for the JWT auth method",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			builder := fake.NewClientBuilder().WithObjects(&corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "default",
+					Namespace: "tenant-ns",
+					UID:       saUID,
+				},
+			})
+			if !tc.skipSecretObj {
+				builder = builder.WithObjects(&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "jwt-secret",
+						Namespace: "tenant-ns",
+						UID:       secretUID,
+					},
+					Data: tc.secretData,
+				})
+			}
+			client := &tokenRequestClient{Client: builder.Build(), token: "sa-token"}
+
+			authObj := &secretsv1alpha1.VaultAuth{
+				Spec: secretsv1alpha1.VaultAuthSpec{
+					Method: providerMethodJWT,
+					Mount:  "jwt",
+					JWT:    tc.jwtConfig,
+				},
+			}
+
+			p, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+			if tc.wantInitErr != "" {
+				assert.EqualError(t, err, tc.wantInitErr)
+				return
+			}
+			require.NoError(t, err)
+			wantUID := tc.wantUID
+			if tc.jwtConfig.SecretRef != "" {
+				var secret corev1.Secret
+				require.NoError(t, client.Get(ctx, ctrlclient.ObjectKey{Namespace: "tenant-ns", Name: "jwt-secret"}, &secret))
+				wantUID = secretProviderUID(&secret)
+			}
+			assert.Equal(t, wantUID, p.GetUID())
+			assert.Equal(t, "tenant-ns", p.GetNamespace())
+
+			creds, err := p.GetCreds(ctx, client)
+			if tc.wantCredsErr != "" {
+				assert.EqualError(t, err, tc.wantCredsErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantCreds, creds)
+			if tc.jwtConfig.ServiceAccount != "" {
+				require.Len(t, client.requests, 1)
+				assert.Equal(t, tc.jwtConfig.TokenExpirationSeconds, *client.requests[0].Spec.ExpirationSeconds)
+				assert.Equal(t, tc.jwtConfig.TokenAudiences, client.requests[0].Spec.Audiences)
+			}
+		})
+	}
+}
+
+func Test_jwtCredentialProvider_rotation(t *testing.T) {
+	ctx := context.Background()
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "jwt-secret",
+			Namespace: "tenant-ns",
+			UID:       providerUID,
+		},
+		Data: map[string][]byte{
+			"jwt": []byte("token1"),
+		},
+	}
+	client := fake.NewClientBuilder().WithObjects(secret).Build()
+
+	authObj := &secretsv1alpha1.VaultAuth{
+		Spec: secretsv1alpha1.VaultAuthSpec{
+			Method: providerMethodJWT,
+			Mount:  "jwt",
+			JWT: &secretsv1alpha1.VaultAuthConfigJWT{
+				Role:      "role1",
+				SecretRef: "jwt-secret",
+			},
+		},
+	}
+
+	getUID := func() types.UID {
+		p, err := NewCredentialProvider(ctx, client, authObj, "tenant-ns")
+		require.NoError(t, err)
+		return p.GetUID()
+	}
+
+	uid1 := getUID()
+	assert.Equal(t, uid1, getUID())
+
+	// changes to the Secret's metadata do not change its UID
+	require.NoError(t, client.Get(ctx, ctrlclient.ObjectKeyFromObject(secret), secret))
+	secret.Labels = map[string]string{"team": "a"}
+	secret.Annotations = map[string]string{"note": "b"}
+	require.NoError(t, client.Update(ctx, secret))
+	assert.Equal(t, uid1, getUID())
+
+	// rotate the JWT
+	require.NoError(t, client.Get(ctx, ctrlclient.ObjectKeyFromObject(secret), secret))
+	secret.Data["jwt"] = []byte("token2")
+	require.NoError(t, client.Update(ctx, secret))
+
+	assert.NotEqual(t, uid1, getUID())
+}
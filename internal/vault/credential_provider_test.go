@@ -0,0 +1,73 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+func TestNewCredentialProvider_params(t *testing.T) {
+	tests := map[string]struct {
+		method  string
+		params  map[string]string
+		wantErr string
+	}{
+		"kubernetes-reserved": {
+			method: providerMethodKubernetes,
+			params: map[string]string{
+				"jwt":  "foo",
+				"role": "bar",
+			},
+			wantErr: "params [role jwt] are reserved for the kubernetes auth method",
+		},
+		"appRole-reserved": {
+			method: providerMethodAppRole,
+			params: map[string]string{
+				"secret_id": "foo",
+			},
+			wantErr: "params [secret_id] are reserved for the appRole auth method",
+		},
+		"aws-reserved": {
+			method: providerMethodAWS,
+			params: map[string]string{
+				"iam_request_url": "foo",
+			},
+			wantErr: "params [iam_request_url] are reserved for the aws auth method",
+		},
+		"cert-reserved": {
+			method: providerMethodCert,
+			params: map[string]string{
+				"name": "foo",
+			},
+			wantErr: "params [name] are reserved for the cert auth method",
+		},
+		"jwt-allowed": {
+			method: providerMethodJWT,
+			params: map[string]string{
+				"custom": "foo",
+			},
+			// params are valid, so the provider's own validation fails next.
+			wantErr: "JWT auth method not configured",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			authObj := &secretsv1alpha1.VaultAuth{
+				Spec: secretsv1alpha1.VaultAuthSpec{
+					Method: tc.method,
+					Params: tc.params,
+				},
+			}
+			_, err := NewCredentialProvider(context.Background(), fake.NewClientBuilder().Build(), authObj, "tenant-ns")
+			assert.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
@@ -0,0 +1,481 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+	"github.com/hashicorp/vault-secrets-operator/internal/consts"
+)
+
+const (
+	// EventTypeKVv2DataWrite is the type of the event that is sent on each write to a kv-v2 secret.
+	EventTypeKVv2DataWrite = "kv-v2/data-write"
+	// EventTypeKVv2DataDelete is the type of the event that is sent on each deletion of a kv-v2 secret.
+	EventTypeKVv2DataDelete = "kv-v2/data-delete"
+)
+
+var _ EventWatcher = (*defaultEventWatcher)(nil)
+
+// Event is a Vault event notification.
+type Event struct {
+	ID        string
+	EventType string
+	// MountPath of the secrets engine that sent the event, without any trailing slash.
+	MountPath string
+	// Path of the secret that the event is for, e.g. secret/data/foo.
+	Path string
+}
+
+// EventHandler is called for each Event received by an EventWatcher.
+type EventHandler func(ctx context.Context, event Event)
+
+// EventWatcher subscribes to the event notifications of each VaultConnection's Vault server,
+// and passes every event received to its EventHandler.
+type EventWatcher interface {
+	manager.Runnable
+	// Watch ensures that the events are subscribed to for the VaultConnection, and Vault namespace,
+	// of Client c, on behalf of obj. A subscription is kept for as long as it has dependent objects,
+	// its Client is looked up through the ClientFactory, for one of them, each time it is established.
+	Watch(obj ctrlclient.Object, c Client) error
+	// Unwatch removes the object identified by key from the dependents of its subscription.
+	// A subscription without any dependents is closed.
+	Unwatch(key ctrlclient.ObjectKey)
+	// Len returns the number of subscriptions.
+	Len() int
+}
+
+// EventWatcherConfig provides the configuration for an EventWatcher instance.
+type EventWatcherConfig struct {
+	// EventTypes to subscribe to.
+	EventTypes []string
+	// MinBackoff is the initial delay before a failed subscription is retried, it is doubled
+	// on each failure up to MaxBackoff.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// CheckInterval at which each established subscription is checked for remaining dependents,
+	// a subscription without any is closed. The check is disabled if it is zero.
+	CheckInterval time.Duration
+}
+
+// DefaultEventWatcherConfig provides the default configuration for an EventWatcher instance.
+func DefaultEventWatcherConfig() *EventWatcherConfig {
+	return &EventWatcherConfig{
+		EventTypes: []string{
+			EventTypeKVv2DataWrite,
+			EventTypeKVv2DataDelete,
+		},
+		MinBackoff:    time.Second,
+		MaxBackoff:    time.Minute * 5,
+		CheckInterval: time.Minute,
+	}
+}
+
+// NewEventWatcher returns an EventWatcher that passes the events received to handler.
+// The Client of each subscription is provided by factory.
+// No subscription is established until the EventWatcher is started.
+func NewEventWatcher(client ctrlclient.Client, factory ClientFactory, handler EventHandler, config *EventWatcherConfig) (EventWatcher, error) {
+	if client == nil {
+		return nil, errors.New("a Kubernetes client is required")
+	}
+
+	if factory == nil {
+		return nil, errors.New("a ClientFactory is required")
+	}
+
+	if handler == nil {
+		return nil, errors.New("an EventHandler is required")
+	}
+
+	if config == nil {
+		config = DefaultEventWatcherConfig()
+	}
+
+	if len(config.EventTypes) == 0 {
+		return nil, errors.New("at least one event type is required")
+	}
+
+	if config.MinBackoff <= 0 || config.MaxBackoff < config.MinBackoff {
+		return nil, fmt.Errorf("invalid backoff %s-%s", config.MinBackoff, config.MaxBackoff)
+	}
+
+	return &defaultEventWatcher{
+		client:        client,
+		factory:       factory,
+		handler:       handler,
+		config:        config,
+		subscriptions: make(map[eventSubscriptionKey]*eventSubscription),
+	}, nil
+}
+
+// errNoDependents is returned when none of a subscription's dependents need its events any longer.
+var errNoDependents = errors.New("subscription has no dependents")
+
+// eventSubscriptionKey identifies the subscriptions of a VaultConnection, events are only
+// received for the Vault namespace that they were subscribed to.
+type eventSubscriptionKey struct {
+	connObjKey ctrlclient.ObjectKey
+	namespace  string
+}
+
+// newEventSubscriptionKey returns the key of the subscription for the Client c.
+func newEventSubscriptionKey(c Client) (eventSubscriptionKey, error) {
+	connObj := c.GetVaultConnectionObj()
+	if connObj == nil {
+		return eventSubscriptionKey{}, errors.New("client has no VaultConnection")
+	}
+
+	var namespace string
+	if authObj := c.GetVaultAuthObj(); authObj != nil {
+		namespace = authObj.Spec.Namespace
+	}
+
+	return eventSubscriptionKey{
+		connObjKey: ctrlclient.ObjectKeyFromObject(connObj),
+		namespace:  namespace,
+	}, nil
+}
+
+type eventSubscription struct {
+	key eventSubscriptionKey
+	// dependents are the objects that the subscription is watched for.
+	dependents map[ctrlclient.ObjectKey]ctrlclient.Object
+	// cancel stops the subscription, it is set once the subscription is started.
+	cancel context.CancelFunc
+}
+
+type defaultEventWatcher struct {
+	client        ctrlclient.Client
+	factory       ClientFactory
+	handler       EventHandler
+	config        *EventWatcherConfig
+	subscriptions map[eventSubscriptionKey]*eventSubscription
+	// ctx is set once the EventWatcher is started.
+	ctx context.Context
+	wg  sync.WaitGroup
+	mu  sync.Mutex
+}
+
+// Start all subscriptions, it blocks until ctx is done.
+func (w *defaultEventWatcher) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("eventWatcher")
+	logger.Info("Starting the event watcher")
+
+	w.mu.Lock()
+	w.ctx = ctx
+	for _, s := range w.subscriptions {
+		w.subscribe(s)
+	}
+	w.mu.Unlock()
+
+	<-ctx.Done()
+	logger.Info("Stopping the event watcher")
+	w.wg.Wait()
+	return nil
+}
+
+func (w *defaultEventWatcher) Watch(obj ctrlclient.Object, c Client) error {
+	key, err := newEventSubscriptionKey(c)
+	if err != nil {
+		return err
+	}
+
+	objKey := ctrlclient.ObjectKeyFromObject(obj)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	// obj may have been watched for another VaultConnection, or Vault namespace, before.
+	for _, s := range w.subscriptions {
+		if s.key != key {
+			w.removeDependent(s, objKey)
+		}
+	}
+
+	if s, ok := w.subscriptions[key]; ok {
+		s.dependents[objKey] = obj.DeepCopyObject().(ctrlclient.Object)
+		return nil
+	}
+
+	s := &eventSubscription{
+		key: key,
+		dependents: map[ctrlclient.ObjectKey]ctrlclient.Object{
+			objKey: obj.DeepCopyObject().(ctrlclient.Object),
+		},
+	}
+	w.subscriptions[key] = s
+	if w.ctx != nil {
+		w.subscribe(s)
+	}
+
+	return nil
+}
+
+func (w *defaultEventWatcher) Unwatch(key ctrlclient.ObjectKey) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, s := range w.subscriptions {
+		w.removeDependent(s, key)
+	}
+}
+
+func (w *defaultEventWatcher) Len() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.subscriptions)
+}
+
+// removeDependent removes objKey from the dependents of s, s is closed if it has no dependents left.
+// Should be called from a locked method only.
+func (w *defaultEventWatcher) removeDependent(s *eventSubscription, objKey ctrlclient.ObjectKey) {
+	if _, ok := s.dependents[objKey]; !ok {
+		return
+	}
+
+	delete(s.dependents, objKey)
+	if len(s.dependents) == 0 {
+		w.close(s)
+	}
+}
+
+// close stops s, and removes it from the subscriptions. Should be called from a locked method only.
+func (w *defaultEventWatcher) close(s *eventSubscription) {
+	if w.subscriptions[s.key] == s {
+		delete(w.subscriptions, s.key)
+	}
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
+
+// subscribe starts receiving each configured event type for s. Should be called from a locked method only.
+func (w *defaultEventWatcher) subscribe(s *eventSubscription) {
+	ctx, cancel := context.WithCancel(w.ctx)
+	s.cancel = cancel
+	for _, eventType := range w.config.EventTypes {
+		w.wg.Add(1)
+		go func(eventType string) {
+			defer w.wg.Done()
+			w.run(ctx, s, eventType)
+		}(eventType)
+	}
+
+	if w.config.CheckInterval > 0 {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.checkDependents(ctx, s)
+		}()
+	}
+}
+
+// checkDependents closes s once it no longer has any dependents, checking every CheckInterval
+// until ctx is done. This covers the subscriptions whose websockets are never closed by Vault.
+func (w *defaultEventWatcher) checkDependents(ctx context.Context, s *eventSubscription) {
+	ticker := time.NewTicker(w.config.CheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.getClient(ctx, s); errors.Is(err, errNoDependents) {
+				log.FromContext(ctx).WithName("eventWatcher").Info(
+					"Closing the event subscription, it has no dependents",
+					"vaultConnection", s.key.connObjKey, "namespace", s.key.namespace)
+				w.mu.Lock()
+				w.close(s)
+				w.mu.Unlock()
+				return
+			}
+		}
+	}
+}
+
+// run receives the events of eventType for s until ctx is done, the subscription is
+// re-established with an exponential backoff whenever it fails. The subscription is closed
+// once it no longer has any dependents.
+func (w *defaultEventWatcher) run(ctx context.Context, s *eventSubscription, eventType string) {
+	logger := log.FromContext(ctx).WithName("eventWatcher").WithValues(
+		"vaultConnection", s.key.connObjKey, "namespace", s.key.namespace, "eventType", eventType)
+
+	backoff := w.config.MinBackoff
+	for {
+		var connected bool
+		c, err := w.getClient(ctx, s)
+		if errors.Is(err, errNoDependents) {
+			logger.Info("Closing the event subscription, it has no dependents")
+			w.mu.Lock()
+			w.close(s)
+			w.mu.Unlock()
+			return
+		}
+		if err == nil {
+			connected, err = w.receive(ctx, c, eventType)
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		if connected {
+			backoff = w.config.MinBackoff
+		}
+		logger.Error(err, "Event subscription failed, retrying", "backoff", backoff)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > w.config.MaxBackoff {
+			backoff = w.config.MaxBackoff
+		}
+	}
+}
+
+// getClient returns the Client of one of the dependents of s, as provided by the ClientFactory.
+// Dependents that no longer exist, or that now depend on another subscription, are removed,
+// the next dependent is tried if the Client of one cannot be provided.
+// It returns errNoDependents if the VaultConnection of s was deleted, or if s has no dependents left.
+func (w *defaultEventWatcher) getClient(ctx context.Context, s *eventSubscription) (Client, error) {
+	if err := w.client.Get(ctx, s.key.connObjKey, &secretsv1alpha1.VaultConnection{}); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, errNoDependents
+		}
+		return nil, err
+	}
+
+	w.mu.Lock()
+	dependents := make([]ctrlclient.Object, 0, len(s.dependents))
+	for _, obj := range s.dependents {
+		dependents = append(dependents, obj.DeepCopyObject().(ctrlclient.Object))
+	}
+	w.mu.Unlock()
+
+	var errs error
+	for _, obj := range dependents {
+		objKey := ctrlclient.ObjectKeyFromObject(obj)
+		if err := w.client.Get(ctx, objKey, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				w.mu.Lock()
+				w.removeDependent(s, objKey)
+				w.mu.Unlock()
+			} else {
+				errs = errors.Join(errs, err)
+			}
+			continue
+		}
+
+		c, err := w.factory.Get(ctx, w.client, obj)
+		if err != nil {
+			errs = errors.Join(errs, err)
+			continue
+		}
+
+		if key, err := newEventSubscriptionKey(c); err != nil || key != s.key {
+			w.mu.Lock()
+			w.removeDependent(s, objKey)
+			w.mu.Unlock()
+			continue
+		}
+
+		return c, nil
+	}
+
+	if errs != nil {
+		return nil, errs
+	}
+
+	return nil, errNoDependents
+}
+
+// receive subscribes to eventType with c and passes each event to the EventHandler until either ctx is done,
+// or the websocket is closed. It returns true if the subscription was established.
+func (w *defaultEventWatcher) receive(ctx context.Context, c Client, eventType string) (bool, error) {
+	logger := log.FromContext(ctx).WithName("eventWatcher")
+
+	conn, err := c.SubscribeEvents(ctx, eventType)
+	if err != nil {
+		return false, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		// unblocks the pending Receive() once ctx is done.
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = conn.Close()
+	}()
+
+	for {
+		var msg []byte
+		if err := websocket.Message.Receive(conn, &msg); err != nil {
+			return true, err
+		}
+
+		event, err := parseEvent(msg)
+		if err != nil {
+			logger.V(consts.LogLevelDebug).Info("Ignoring invalid event", "eventType", eventType, "err", err)
+			continue
+		}
+
+		w.handler(ctx, event)
+	}
+}
+
+// parseEvent parses an event notification, as sent by Vault in the CloudEvents JSON format.
+func parseEvent(msg []byte) (Event, error) {
+	var e struct {
+		ID   string `json:"id"`
+		Data struct {
+			EventType string `json:"event_type"`
+			Event     struct {
+				Metadata map[string]any `json:"metadata"`
+			} `json:"event"`
+			PluginInfo struct {
+				MountPath string `json:"mount_path"`
+			} `json:"plugin_info"`
+		} `json:"data"`
+	}
+
+	if err := json.Unmarshal(msg, &e); err != nil {
+		return Event{}, err
+	}
+
+	p, _ := e.Data.Event.Metadata["path"].(string)
+	if p == "" {
+		return Event{}, errors.New("event has no path")
+	}
+
+	mountPath := strings.Trim(e.Data.PluginInfo.MountPath, "/")
+	p = strings.TrimPrefix(p, "/")
+	// older Vault versions provide the path relative to the mount.
+	if mountPath != "" && !strings.HasPrefix(p, mountPath+"/") {
+		p = mountPath + "/" + p
+	}
+
+	return Event{
+		ID:        e.ID,
+		EventType: e.Data.EventType,
+		MountPath: mountPath,
+		Path:      p,
+	}, nil
+}
@@ -0,0 +1,400 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+const testKVv2WriteEvent = `{
+  "id": "a3be9fb1-b514-519f-5b25-b6f144a8c1ce",
+  "source": "https://vaultproject.io/",
+  "specversion": "1.0",
+  "type": "*",
+  "data": {
+    "event": {
+      "id": "a3be9fb1-b514-519f-5b25-b6f144a8c1ce",
+      "metadata": {
+        "current_version": "1",
+        "data_path": "secret/data/foo",
+        "modified": "true",
+        "oldest_version": "0",
+        "operation": "data-write",
+        "path": "secret/data/foo"
+      }
+    },
+    "event_type": "kv-v2/data-write",
+    "plugin_info": {
+      "mount_class": "secret",
+      "mount_accessor": "kv_5dc4d18e",
+      "mount_path": "secret/",
+      "plugin": "kv"
+    }
+  },
+  "datacontentype": "application/cloudevents",
+  "time": "2023-03-30T15:28:52.427734Z"
+}`
+
+func Test_parseEvent(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     string
+		want    Event
+		wantErr string
+	}{
+		{
+			name: "kv-v2-data-write",
+			msg:  testKVv2WriteEvent,
+			want: Event{
+				ID:        "a3be9fb1-b514-519f-5b25-b6f144a8c1ce",
+				EventType: EventTypeKVv2DataWrite,
+				MountPath: "secret",
+				Path:      "secret/data/foo",
+			},
+		},
+		{
+			name: "relative-path",
+			msg: `{"id": "1", "data": {"event": {"metadata": {"path": "data/foo/bar"}},
+"event_type": "kv-v2/data-delete", "plugin_info": {"mount_path": "kv/team/"}}}`,
+			want: Event{
+				ID:        "1",
+				EventType: EventTypeKVv2DataDelete,
+				MountPath: "kv/team",
+				Path:      "kv/team/data/foo/bar",
+			},
+		},
+		{
+			name:    "no-path",
+			msg:     `{"id": "1", "data": {"event_type": "kv-v2/data-write"}}`,
+			wantErr: "event has no path",
+		},
+		{
+			name:    "invalid",
+			msg:     `{`,
+			wantErr: "unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEvent([]byte(tt.msg))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+// stubEventClientFactory returns the client of each object, by its name.
+type stubEventClientFactory struct {
+	mu      sync.Mutex
+	clients map[string]Client
+}
+
+func (f *stubEventClientFactory) Get(_ context.Context, _ ctrlclient.Client, obj ctrlclient.Object) (Client, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.clients[obj.GetName()]
+	if !ok {
+		return nil, fmt.Errorf("no client for %s", obj.GetName())
+	}
+	return c, nil
+}
+
+func (f *stubEventClientFactory) set(name string, c Client) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.clients[name] = c
+}
+
+func newEventTestK8sClient(t *testing.T, objs ...ctrlclient.Object) ctrlclient.Client {
+	t.Helper()
+
+	scheme := runtime.NewScheme()
+	require.NoError(t, secretsv1alpha1.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func newEventTestClient(apiClient *api.Client, connName, namespace string) *defaultClient {
+	return &defaultClient{
+		client: apiClient,
+		connObj: &secretsv1alpha1.VaultConnection{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: connName},
+		},
+		authObj: &secretsv1alpha1.VaultAuth{
+			Spec: secretsv1alpha1.VaultAuthSpec{Namespace: namespace},
+		},
+	}
+}
+
+func newEventTestVSS(name string) *secretsv1alpha1.VaultStaticSecret {
+	return &secretsv1alpha1.VaultStaticSecret{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+	}
+}
+
+func Test_defaultEventWatcher_Start(t *testing.T) {
+	var mu sync.Mutex
+	var requests []*http.Request
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		mu.Lock()
+		requests = append(requests, conn.Request())
+		mu.Unlock()
+		// a malformed event is skipped.
+		_ = websocket.Message.Send(conn, `{"id": "invalid"}`)
+		_ = websocket.Message.Send(conn, testKVv2WriteEvent)
+		// keep the websocket open until the watcher closes it.
+		var msg []byte
+		_ = websocket.Message.Receive(conn, &msg)
+	}))
+	t.Cleanup(server.Close)
+
+	apiClient, err := api.NewClient(&api.Config{Address: server.URL})
+	require.NoError(t, err)
+	apiClient.SetToken("token")
+	apiClient.SetNamespace("team")
+	c := newEventTestClient(apiClient, "conn", "team")
+	vss := newEventTestVSS("vss")
+	k8sClient := newEventTestK8sClient(t, vss, c.connObj)
+	factory := &stubEventClientFactory{clients: map[string]Client{"vss": c}}
+
+	events := make(chan Event, 1)
+	w, err := NewEventWatcher(k8sClient, factory, func(_ context.Context, ev Event) {
+		events <- ev
+	}, &EventWatcherConfig{
+		EventTypes: []string{EventTypeKVv2DataWrite},
+		MinBackoff: time.Millisecond * 10,
+		MaxBackoff: time.Millisecond * 10,
+	})
+	require.NoError(t, err)
+
+	// watching the same VaultConnection and namespace again does not add a subscription.
+	require.NoError(t, w.Watch(vss, c))
+	require.NoError(t, w.Watch(vss, c))
+	assert.Equal(t, 1, w.Len())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- w.Start(ctx)
+	}()
+
+	select {
+	case ev := <-events:
+		assert.Equal(t, Event{
+			ID:        "a3be9fb1-b514-519f-5b25-b6f144a8c1ce",
+			EventType: EventTypeKVv2DataWrite,
+			MountPath: "secret",
+			Path:      "secret/data/foo",
+		}, ev)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the event")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 1)
+	assert.Equal(t, "/v1/sys/events/subscribe/kv-v2/data-write?json=true", requests[0].URL.RequestURI())
+	assert.Equal(t, "token", requests[0].Header.Get(api.AuthHeaderName))
+	assert.Equal(t, "team", requests[0].Header.Get(api.NamespaceHeaderName))
+}
+
+func Test_defaultEventWatcher_reconnect(t *testing.T) {
+	var mu sync.Mutex
+	var tokens []string
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		mu.Lock()
+		tokens = append(tokens, conn.Request().Header.Get(api.AuthHeaderName))
+		mu.Unlock()
+		// the websocket is closed right away, so that the watcher has to reconnect.
+	}))
+	t.Cleanup(server.Close)
+
+	newClient := func(token string) *defaultClient {
+		apiClient, err := api.NewClient(&api.Config{Address: server.URL})
+		require.NoError(t, err)
+		apiClient.SetToken(token)
+		return newEventTestClient(apiClient, "conn", "")
+	}
+
+	c := newClient("first")
+	vss := newEventTestVSS("vss")
+	k8sClient := newEventTestK8sClient(t, vss, c.connObj)
+	factory := &stubEventClientFactory{clients: map[string]Client{"vss": c}}
+	w, err := NewEventWatcher(k8sClient, factory, func(context.Context, Event) {}, &EventWatcherConfig{
+		EventTypes: []string{EventTypeKVv2DataWrite},
+		MinBackoff: time.Millisecond * 10,
+		MaxBackoff: time.Millisecond * 10,
+	})
+	require.NoError(t, err)
+	require.NoError(t, w.Watch(vss, c))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go func() {
+		_ = w.Start(ctx)
+	}()
+
+	// the client is looked up through the ClientFactory on each reconnect.
+	factory.set("vss", newClient("second"))
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(tokens) > 0 && tokens[len(tokens)-1] == "second"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_defaultEventWatcher_dependents(t *testing.T) {
+	server := httptest.NewServer(websocket.Handler(func(conn *websocket.Conn) {
+		var msg []byte
+		_ = websocket.Message.Receive(conn, &msg)
+	}))
+	t.Cleanup(server.Close)
+
+	apiClient, err := api.NewClient(&api.Config{Address: server.URL})
+	require.NoError(t, err)
+	c := newEventTestClient(apiClient, "conn", "")
+	other := newEventTestClient(apiClient, "conn", "other")
+
+	tests := []struct {
+		name    string
+		cleanup func(t *testing.T, w EventWatcher, k8sClient ctrlclient.Client)
+	}{
+		{
+			name: "unwatched",
+			cleanup: func(t *testing.T, w EventWatcher, _ ctrlclient.Client) {
+				w.Unwatch(ctrlclient.ObjectKey{Namespace: "default", Name: "vss1"})
+				assert.Equal(t, 1, w.Len())
+				w.Unwatch(ctrlclient.ObjectKey{Namespace: "default", Name: "vss2"})
+			},
+		},
+		{
+			name: "watched-for-another-namespace",
+			cleanup: func(t *testing.T, w EventWatcher, _ ctrlclient.Client) {
+				require.NoError(t, w.Watch(newEventTestVSS("vss1"), other))
+				require.NoError(t, w.Watch(newEventTestVSS("vss2"), other))
+				assert.Equal(t, 1, w.Len())
+				w.Unwatch(ctrlclient.ObjectKey{Namespace: "default", Name: "vss1"})
+				w.Unwatch(ctrlclient.ObjectKey{Namespace: "default", Name: "vss2"})
+			},
+		},
+		{
+			name: "dependents-deleted",
+			cleanup: func(t *testing.T, _ EventWatcher, k8sClient ctrlclient.Client) {
+				require.NoError(t, k8sClient.Delete(context.Background(), newEventTestVSS("vss1")))
+				require.NoError(t, k8sClient.Delete(context.Background(), newEventTestVSS("vss2")))
+			},
+		},
+		{
+			name: "connection-deleted",
+			cleanup: func(t *testing.T, _ EventWatcher, k8sClient ctrlclient.Client) {
+				require.NoError(t, k8sClient.Delete(context.Background(), c.connObj.DeepCopy()))
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := newEventTestK8sClient(t, newEventTestVSS("vss1"), newEventTestVSS("vss2"), c.connObj.DeepCopy())
+			factory := &stubEventClientFactory{clients: map[string]Client{"vss1": c, "vss2": c}}
+			w, err := NewEventWatcher(k8sClient, factory, func(context.Context, Event) {}, &EventWatcherConfig{
+				EventTypes:    []string{EventTypeKVv2DataWrite},
+				MinBackoff:    time.Millisecond * 10,
+				MaxBackoff:    time.Millisecond * 10,
+				CheckInterval: time.Millisecond * 10,
+			})
+			require.NoError(t, err)
+
+			require.NoError(t, w.Watch(newEventTestVSS("vss1"), c))
+			require.NoError(t, w.Watch(newEventTestVSS("vss2"), c))
+			assert.Equal(t, 1, w.Len())
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error)
+			go func() {
+				done <- w.Start(ctx)
+			}()
+
+			tt.cleanup(t, w, k8sClient)
+			assert.Eventually(t, func() bool {
+				return w.Len() == 0
+			}, 5*time.Second, 10*time.Millisecond)
+
+			cancel()
+			require.NoError(t, <-done)
+		})
+	}
+}
+
+func Test_dialWebsocket_timeout(t *testing.T) {
+	// the server accepts connections, but never completes the websocket handshake.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { _ = conn.Close() })
+		}
+	}()
+
+	config, err := websocket.NewConfig("ws://"+l.Addr().String()+"/v1/sys/events/subscribe/kv-v2/data-write",
+		"http://"+l.Addr().String())
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = dialWebsocket(context.Background(), config, 100*time.Millisecond)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestNewEventWatcher(t *testing.T) {
+	handler := func(context.Context, Event) {}
+	k8sClient := newEventTestK8sClient(t)
+	factory := &stubEventClientFactory{}
+
+	_, err := NewEventWatcher(nil, factory, handler, DefaultEventWatcherConfig())
+	assert.EqualError(t, err, "a Kubernetes client is required")
+
+	_, err = NewEventWatcher(k8sClient, nil, handler, DefaultEventWatcherConfig())
+	assert.EqualError(t, err, "a ClientFactory is required")
+
+	_, err = NewEventWatcher(k8sClient, factory, nil, DefaultEventWatcherConfig())
+	assert.EqualError(t, err, "an EventHandler is required")
+
+	_, err = NewEventWatcher(k8sClient, factory, handler, &EventWatcherConfig{MinBackoff: time.Second, MaxBackoff: time.Second})
+	assert.EqualError(t, err, "at least one event type is required")
+
+	_, err = NewEventWatcher(k8sClient, factory, handler, &EventWatcherConfig{
+		EventTypes: []string{EventTypeKVv2DataWrite},
+		MinBackoff: time.Minute,
+		MaxBackoff: time.Second,
+	})
+	assert.EqualError(t, err, "invalid backoff 1m0s-1s")
+}
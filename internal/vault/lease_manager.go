@@ -0,0 +1,414 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+	"github.com/hashicorp/vault-secrets-operator/internal/metrics"
+)
+
+// minLeaseRenewalHorizon is used for leases whose duration is too short to compute a renewal horizon from.
+const minLeaseRenewalHorizon = time.Second * 5
+
+var _ LeaseManager = (*defaultLeaseManager)(nil)
+
+// LeaseManager tracks the leases of dynamic secrets, renewing each of them before it expires.
+// The LeaseRenewalHandler is only asked to sync a new secret once a lease can no longer be renewed.
+type LeaseManager interface {
+	manager.Runnable
+	// Track the lease of the object identified by key, the lease was last renewed at renewedAt.
+	// Any lease that was previously tracked for the key is replaced.
+	Track(key ctrlclient.ObjectKey, lease secretsv1alpha1.VaultSecretLease, renewedAt time.Time)
+	// Untrack the lease of the object identified by key.
+	Untrack(key ctrlclient.ObjectKey)
+	// IsTracked returns true if a lease is tracked for the object identified by key.
+	IsTracked(key ctrlclient.ObjectKey) bool
+	// Len returns the number of tracked leases.
+	Len() int
+}
+
+// LeaseRenewalHandler is called by the LeaseManager to renew leases and to report on their renewal.
+type LeaseRenewalHandler interface {
+	// GetClient returns the Client that is used to renew the lease of the object identified by key.
+	GetClient(ctx context.Context, key ctrlclient.ObjectKey) (Client, error)
+	// OnRenewal is called after the lease of the object identified by key was renewed.
+	// The lease is marked as non-renewable if its renewal was truncated.
+	OnRenewal(ctx context.Context, key ctrlclient.ObjectKey, lease secretsv1alpha1.VaultSecretLease) error
+	// OnRotationNeeded is called once the lease of the object identified by key can no longer be renewed.
+	// The lease is no longer tracked by then.
+	OnRotationNeeded(ctx context.Context, key ctrlclient.ObjectKey)
+}
+
+// LeaseManagerConfig provides the configuration for a LeaseManager instance.
+type LeaseManagerConfig struct {
+	// MaxConcurrentRenewals is the maximum number of lease renewals that are in flight at any time.
+	MaxConcurrentRenewals int
+	// RenewalsPerSecond limits the rate at which leases are renewed.
+	RenewalsPerSecond float64
+	// RenewalBurst is the maximum number of renewals that may exceed RenewalsPerSecond at once.
+	RenewalBurst    int
+	MetricsRegistry prometheus.Registerer
+}
+
+// DefaultLeaseManagerConfig provides the default configuration for a LeaseManager instance.
+func DefaultLeaseManagerConfig() *LeaseManagerConfig {
+	return &LeaseManagerConfig{
+		MaxConcurrentRenewals: 10,
+		RenewalsPerSecond:     50,
+		RenewalBurst:          100,
+	}
+}
+
+// NewLeaseManager returns a LeaseManager that reports to handler. It must be started before any lease is renewed.
+// If config.MetricsRegistry is not nil, then the LeaseManager's metric collectors will be registered in it.
+func NewLeaseManager(handler LeaseRenewalHandler, config *LeaseManagerConfig) (LeaseManager, error) {
+	if handler == nil {
+		return nil, errors.New("a LeaseRenewalHandler is required")
+	}
+	if config.MaxConcurrentRenewals <= 0 {
+		return nil, fmt.Errorf("invalid MaxConcurrentRenewals %d, must be greater than 0", config.MaxConcurrentRenewals)
+	}
+	if config.RenewalsPerSecond <= 0 {
+		return nil, fmt.Errorf("invalid RenewalsPerSecond %v, must be greater than 0", config.RenewalsPerSecond)
+	}
+
+	burst := config.RenewalBurst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	m := &defaultLeaseManager{
+		handler: handler,
+		items:   make(map[ctrlclient.ObjectKey]*leaseItem),
+		wakeCh:  make(chan struct{}, 1),
+		sem:     make(chan struct{}, config.MaxConcurrentRenewals),
+		limiter: rate.NewLimiter(rate.Limit(config.RenewalsPerSecond), burst),
+		metrics: newLeaseManagerMetrics(),
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+
+	if config.MetricsRegistry != nil {
+		config.MetricsRegistry.MustRegister(m.metrics.collectors()...)
+	}
+
+	return m, nil
+}
+
+// leaseItem is a lease that is scheduled for renewal at renewAt.
+type leaseItem struct {
+	key     ctrlclient.ObjectKey
+	lease   secretsv1alpha1.VaultSecretLease
+	renewAt time.Time
+	// index of the item in the leaseQueue, -1 while it is being renewed.
+	index int
+}
+
+// leaseQueue is a heap.Interface of leaseItems, ordered by their renewal time.
+type leaseQueue []*leaseItem
+
+func (q leaseQueue) Len() int { return len(q) }
+
+func (q leaseQueue) Less(i, j int) bool { return q[i].renewAt.Before(q[j].renewAt) }
+
+func (q leaseQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *leaseQueue) Push(x any) {
+	item := x.(*leaseItem)
+	item.index = len(*q)
+	*q = append(*q, item)
+}
+
+func (q *leaseQueue) Pop() any {
+	old := *q
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	*q = old[:n-1]
+	return item
+}
+
+type defaultLeaseManager struct {
+	handler LeaseRenewalHandler
+	mu      sync.Mutex
+	queue   leaseQueue
+	// items holds all tracked leases, including the ones that are being renewed.
+	items   map[ctrlclient.ObjectKey]*leaseItem
+	wakeCh  chan struct{}
+	sem     chan struct{}
+	limiter *rate.Limiter
+	metrics *leaseManagerMetrics
+	random  *rand.Rand
+}
+
+// Start renewing the tracked leases, blocking until ctx is done.
+func (m *defaultLeaseManager) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("leaseManager")
+	logger.Info("Starting the lease manager")
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		item, wait := m.next()
+		if item == nil {
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(wait)
+			select {
+			case <-ctx.Done():
+				logger.Info("Stopping the lease manager")
+				return nil
+			case <-m.wakeCh:
+			case <-timer.C:
+			}
+			continue
+		}
+
+		if err := m.limiter.Wait(ctx); err != nil {
+			m.requeue(item)
+			return nil
+		}
+
+		select {
+		case m.sem <- struct{}{}:
+		case <-ctx.Done():
+			m.requeue(item)
+			return nil
+		}
+
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-m.sem
+				wg.Done()
+			}()
+			m.renew(ctx, item)
+		}()
+	}
+}
+
+// Track implements LeaseManager.
+func (m *defaultLeaseManager) Track(key ctrlclient.ObjectKey, lease secretsv1alpha1.VaultSecretLease, renewedAt time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeLocked(key)
+	item := &leaseItem{
+		key:     key,
+		lease:   lease,
+		renewAt: renewedAt.Add(m.renewalHorizon(lease)),
+	}
+	m.items[key] = item
+	heap.Push(&m.queue, item)
+	m.metrics.queueDepth.Set(float64(len(m.items)))
+	m.wake()
+}
+
+// Untrack implements LeaseManager.
+func (m *defaultLeaseManager) Untrack(key ctrlclient.ObjectKey) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.removeLocked(key)
+	m.metrics.queueDepth.Set(float64(len(m.items)))
+}
+
+// IsTracked implements LeaseManager.
+func (m *defaultLeaseManager) IsTracked(key ctrlclient.ObjectKey) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.items[key]
+	return ok
+}
+
+// Len implements LeaseManager.
+func (m *defaultLeaseManager) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.items)
+}
+
+// removeLocked removes the lease tracked for key, m.mu must be held.
+func (m *defaultLeaseManager) removeLocked(key ctrlclient.ObjectKey) {
+	item, ok := m.items[key]
+	if !ok {
+		return
+	}
+
+	delete(m.items, key)
+	if item.index >= 0 {
+		heap.Remove(&m.queue, item.index)
+	}
+}
+
+// next pops the first item that is due for renewal, otherwise it returns the duration until the next one is due.
+func (m *defaultLeaseManager) next() (*leaseItem, time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.queue) == 0 {
+		return nil, time.Hour
+	}
+
+	if wait := time.Until(m.queue[0].renewAt); wait > 0 {
+		return nil, wait
+	}
+
+	return heap.Pop(&m.queue).(*leaseItem), 0
+}
+
+// requeue an item that was popped but not renewed, unless it is no longer tracked.
+func (m *defaultLeaseManager) requeue(item *leaseItem) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.items[item.key] == item && item.index < 0 {
+		heap.Push(&m.queue, item)
+	}
+}
+
+// reschedule a renewed item, unless it was untracked or replaced while it was being renewed.
+// It returns false if the item is no longer tracked.
+func (m *defaultLeaseManager) reschedule(item *leaseItem, lease secretsv1alpha1.VaultSecretLease, renewedAt time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.items[item.key] != item {
+		return false
+	}
+
+	item.lease = lease
+	item.renewAt = renewedAt.Add(m.renewalHorizon(lease))
+	heap.Push(&m.queue, item)
+	m.wake()
+	return true
+}
+
+// release an item whose lease can no longer be renewed. It returns false if the item is no longer tracked.
+func (m *defaultLeaseManager) release(item *leaseItem) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.items[item.key] != item {
+		return false
+	}
+
+	delete(m.items, item.key)
+	m.metrics.queueDepth.Set(float64(len(m.items)))
+	return true
+}
+
+func (m *defaultLeaseManager) wake() {
+	select {
+	case m.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// renew the item's lease, rescheduling it on success. The handler is asked to rotate the secret whenever the
+// lease can no longer be renewed.
+func (m *defaultLeaseManager) renew(ctx context.Context, item *leaseItem) {
+	logger := log.FromContext(ctx).WithName("leaseManager").WithValues(
+		"key", item.key, "lease_id", item.lease.ID)
+
+	m.metrics.renewalLag.Observe(time.Since(item.renewAt).Seconds())
+
+	lease, err := m.doRenew(ctx, item)
+	m.metrics.incrementOperationCounter(metrics.OperationRenew, err)
+	if err != nil {
+		logger.Error(err, "Failed to renew lease")
+		if m.release(item) {
+			m.handler.OnRotationNeeded(ctx, item.key)
+		}
+		return
+	}
+
+	renewedAt := time.Now()
+	if err := m.handler.OnRenewal(ctx, item.key, *lease); err != nil {
+		logger.Error(err, "Failed to handle lease renewal")
+	}
+
+	if !lease.Renewable {
+		if m.release(item) {
+			m.handler.OnRotationNeeded(ctx, item.key)
+		}
+		return
+	}
+
+	m.reschedule(item, *lease, renewedAt)
+}
+
+func (m *defaultLeaseManager) doRenew(ctx context.Context, item *leaseItem) (*secretsv1alpha1.VaultSecretLease, error) {
+	c, err := m.handler.GetClient(ctx, item.key)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Write(ctx, "/sys/leases/renew", map[string]interface{}{
+		"lease_id":  item.lease.ID,
+		"increment": item.lease.LeaseDuration,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, fmt.Errorf("nil response from vault for lease %s", item.lease.ID)
+	}
+	if resp.LeaseID != item.lease.ID {
+		return nil, fmt.Errorf("lease ID changed after renewal, expected=%s, actual=%s", item.lease.ID, resp.LeaseID)
+	}
+
+	lease := &secretsv1alpha1.VaultSecretLease{
+		ID:            resp.LeaseID,
+		LeaseDuration: resp.LeaseDuration,
+		Renewable:     resp.Renewable,
+		RequestID:     resp.RequestID,
+	}
+	// the lease has reached its max TTL when the renewal is truncated, it cannot be extended any further.
+	if lease.LeaseDuration < item.lease.LeaseDuration {
+		lease.Renewable = false
+	}
+
+	return lease, nil
+}
+
+// renewalHorizon returns the duration after the lease's last renewal at which it should be renewed again.
+// It is between 80% and 90% of the lease's duration, so that renewals of leases with the same duration are spread
+// out over time. m.mu must be held.
+func (m *defaultLeaseManager) renewalHorizon(lease secretsv1alpha1.VaultSecretLease) time.Duration {
+	leaseDuration := time.Duration(lease.LeaseDuration) * time.Second
+	jitterMax := int64(0.1 * float64(leaseDuration))
+	if jitterMax <= 0 {
+		return minLeaseRenewalHorizon
+	}
+
+	return leaseDuration - time.Duration(jitterMax) - time.Duration(m.random.Int63n(jitterMax))
+}
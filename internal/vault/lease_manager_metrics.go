@@ -0,0 +1,74 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hashicorp/vault-secrets-operator/internal/metrics"
+)
+
+const (
+	subsystemLeaseManager = "lease_manager"
+)
+
+var (
+	metricsFQNLeaseManagerQueueDepth = prometheus.BuildFQName(
+		metrics.Namespace, subsystemLeaseManager, "queue_depth")
+	metricsFQNLeaseManagerRenewalLagSeconds = prometheus.BuildFQName(
+		metrics.Namespace, subsystemLeaseManager, "renewal_lag_seconds")
+	metricsFQNLeaseManagerOpsTotal = prometheus.BuildFQName(
+		metrics.Namespace, subsystemLeaseManager, metrics.NameOperationsTotal)
+	metricsFQNLeaseManagerOpsErrorsTotal = prometheus.BuildFQName(
+		metrics.Namespace, subsystemLeaseManager, metrics.NameOperationsErrorsTotal)
+)
+
+// leaseManagerMetrics holds the LeaseManager's Prometheus collectors.
+type leaseManagerMetrics struct {
+	queueDepth      prometheus.Gauge
+	renewalLag      prometheus.Histogram
+	operations      *prometheus.CounterVec
+	operationErrors *prometheus.CounterVec
+}
+
+func (m *leaseManagerMetrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.queueDepth,
+		m.renewalLag,
+		m.operations,
+		m.operationErrors,
+	}
+}
+
+func (m *leaseManagerMetrics) incrementOperationCounter(operation string, err error) {
+	if err != nil {
+		m.operationErrors.WithLabelValues(operation).Inc()
+	} else {
+		m.operations.WithLabelValues(operation).Inc()
+	}
+}
+
+func newLeaseManagerMetrics() *leaseManagerMetrics {
+	return &leaseManagerMetrics{
+		queueDepth: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: metricsFQNLeaseManagerQueueDepth,
+			Help: "Number of leases tracked for renewal.",
+		}),
+		renewalLag: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name: metricsFQNLeaseManagerRenewalLagSeconds,
+			Help: "Delay between the scheduled and the actual renewal time of a lease.",
+			Buckets: []float64{
+				0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1.0, 2.5, 5, 10, 30, 60, 120, 300,
+			},
+		}),
+		operations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: metricsFQNLeaseManagerOpsTotal,
+			Help: "Lease manager successful operations",
+		}, []string{metrics.LabelOperation}),
+		operationErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: metricsFQNLeaseManagerOpsErrorsTotal,
+			Help: "Lease manager operation errors",
+		}, []string{metrics.LabelOperation}),
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	ctrlclient "sigs.k8s.io/controller-runtime/pkg/client"
+
+	secretsv1alpha1 "github.com/hashicorp/vault-secrets-operator/api/v1alpha1"
+)
+
+type stubLeaseClient struct {
+	Client
+	resp     *api.Secret
+	err      error
+	mu       sync.Mutex
+	renewals []map[string]any
+}
+
+func (c *stubLeaseClient) Write(_ context.Context, _ string, data map[string]any) (*api.Secret, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.renewals = append(c.renewals, data)
+	return c.resp, c.err
+}
+
+type stubLeaseRenewalHandler struct {
+	client    Client
+	clientErr error
+	mu        sync.Mutex
+	renewed   []secretsv1alpha1.VaultSecretLease
+	rotations []ctrlclient.ObjectKey
+	renewedCh chan struct{}
+}
+
+func (h *stubLeaseRenewalHandler) GetClient(_ context.Context, _ ctrlclient.ObjectKey) (Client, error) {
+	return h.client, h.clientErr
+}
+
+func (h *stubLeaseRenewalHandler) OnRenewal(_ context.Context, _ ctrlclient.ObjectKey, lease secretsv1alpha1.VaultSecretLease) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.renewed = append(h.renewed, lease)
+	if h.renewedCh != nil {
+		h.renewedCh <- struct{}{}
+	}
+	return nil
+}
+
+func (h *stubLeaseRenewalHandler) OnRotationNeeded(_ context.Context, key ctrlclient.ObjectKey) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.rotations = append(h.rotations, key)
+}
+
+func Test_defaultLeaseManager_Track(t *testing.T) {
+	m, err := NewLeaseManager(&stubLeaseRenewalHandler{}, DefaultLeaseManagerConfig())
+	require.NoError(t, err)
+	lm := m.(*defaultLeaseManager)
+
+	now := time.Now()
+	keyA := ctrlclient.ObjectKey{Namespace: "default", Name: "a"}
+	keyB := ctrlclient.ObjectKey{Namespace: "default", Name: "b"}
+	keyC := ctrlclient.ObjectKey{Namespace: "default", Name: "c"}
+	lm.Track(keyA, secretsv1alpha1.VaultSecretLease{ID: "a", LeaseDuration: 3600, Renewable: true}, now)
+	lm.Track(keyB, secretsv1alpha1.VaultSecretLease{ID: "b", LeaseDuration: 60, Renewable: true}, now)
+	lm.Track(keyC, secretsv1alpha1.VaultSecretLease{ID: "c", LeaseDuration: 600, Renewable: true}, now)
+	assert.Equal(t, 3, lm.Len())
+	assert.Equal(t, keyB, lm.queue[0].key)
+
+	// replacing a lease reorders the queue
+	lm.Track(keyB, secretsv1alpha1.VaultSecretLease{ID: "b2", LeaseDuration: 7200, Renewable: true}, now)
+	assert.Equal(t, 3, lm.Len())
+	assert.Equal(t, keyC, lm.queue[0].key)
+
+	assert.True(t, lm.IsTracked(keyC))
+	lm.Untrack(keyC)
+	lm.Untrack(keyC)
+	assert.False(t, lm.IsTracked(keyC))
+	assert.Equal(t, 2, lm.Len())
+	assert.Equal(t, keyA, lm.queue[0].key)
+
+	for _, item := range lm.queue {
+		leaseDuration := time.Duration(item.lease.LeaseDuration) * time.Second
+		assert.True(t, item.renewAt.After(now.Add(leaseDuration*8/10)), "renewAt too early")
+		assert.True(t, item.renewAt.Before(now.Add(leaseDuration*9/10)), "renewAt too late")
+	}
+}
+
+func Test_defaultLeaseManager_renew(t *testing.T) {
+	key := ctrlclient.ObjectKey{Namespace: "default", Name: "vds"}
+	lease := secretsv1alpha1.VaultSecretLease{ID: "lease", LeaseDuration: 600, Renewable: true}
+
+	tests := []struct {
+		name          string
+		resp          *api.Secret
+		writeErr      error
+		clientErr     error
+		untrack       bool
+		wantRenewed   []secretsv1alpha1.VaultSecretLease
+		wantRotations int
+		wantTracked   bool
+	}{
+		{
+			name: "renewed",
+			resp: &api.Secret{LeaseID: "lease", LeaseDuration: 600, Renewable: true, RequestID: "req"},
+			wantRenewed: []secretsv1alpha1.VaultSecretLease{
+				{ID: "lease", LeaseDuration: 600, Renewable: true, RequestID: "req"},
+			},
+			wantTracked: true,
+		},
+		{
+			name: "truncated",
+			resp: &api.Secret{LeaseID: "lease", LeaseDuration: 30, Renewable: true, RequestID: "req"},
+			wantRenewed: []secretsv1alpha1.VaultSecretLease{
+				{ID: "lease", LeaseDuration: 30, Renewable: false, RequestID: "req"},
+			},
+			wantRotations: 1,
+		},
+		{
+			name:          "renewal-error",
+			writeErr:      errors.New("lease not found"),
+			wantRotations: 1,
+		},
+		{
+			name:          "lease-id-changed",
+			resp:          &api.Secret{LeaseID: "other", LeaseDuration: 600, Renewable: true},
+			wantRotations: 1,
+		},
+		{
+			name:          "client-error",
+			clientErr:     errors.New("no client"),
+			wantRotations: 1,
+		},
+		{
+			name:    "untracked-during-renewal",
+			resp:    &api.Secret{LeaseID: "lease", LeaseDuration: 30, Renewable: true},
+			untrack: true,
+			wantRenewed: []secretsv1alpha1.VaultSecretLease{
+				{ID: "lease", LeaseDuration: 30, Renewable: false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &stubLeaseClient{resp: tt.resp, err: tt.writeErr}
+			h := &stubLeaseRenewalHandler{client: c, clientErr: tt.clientErr}
+			m, err := NewLeaseManager(h, DefaultLeaseManagerConfig())
+			require.NoError(t, err)
+			lm := m.(*defaultLeaseManager)
+
+			lm.Track(key, lease, time.Now().Add(-time.Hour))
+			item, _ := lm.next()
+			require.NotNil(t, item)
+			if tt.untrack {
+				lm.Untrack(key)
+			}
+
+			lm.renew(context.Background(), item)
+			assert.Equal(t, tt.wantRenewed, h.renewed)
+			assert.Len(t, h.rotations, tt.wantRotations)
+			if tt.wantTracked {
+				assert.Equal(t, 1, lm.Len())
+				require.Len(t, lm.queue, 1)
+				assert.True(t, lm.queue[0].renewAt.After(time.Now()))
+			} else {
+				assert.Equal(t, 0, lm.Len())
+				assert.Len(t, lm.queue, 0)
+			}
+			if tt.clientErr == nil {
+				require.Len(t, c.renewals, 1)
+				assert.Equal(t, map[string]any{"lease_id": "lease", "increment": 600}, c.renewals[0])
+			}
+		})
+	}
+}
+
+func Test_defaultLeaseManager_Start(t *testing.T) {
+	c := &stubLeaseClient{resp: &api.Secret{LeaseID: "lease", LeaseDuration: 600, Renewable: true}}
+	h := &stubLeaseRenewalHandler{client: c, renewedCh: make(chan struct{}, 1)}
+	m, err := NewLeaseManager(h, DefaultLeaseManagerConfig())
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- m.Start(ctx)
+	}()
+
+	m.Track(ctrlclient.ObjectKey{Namespace: "default", Name: "vds"},
+		secretsv1alpha1.VaultSecretLease{ID: "lease", LeaseDuration: 600, Renewable: true},
+		time.Now().Add(-time.Hour))
+
+	select {
+	case <-h.renewedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the lease renewal")
+	}
+
+	cancel()
+	require.NoError(t, <-done)
+	assert.Equal(t, 1, m.Len())
+	assert.Empty(t, h.rotations)
+}
+
+func TestNewLeaseManager(t *testing.T) {
+	_, err := NewLeaseManager(nil, DefaultLeaseManagerConfig())
+	assert.EqualError(t, err, "a LeaseRenewalHandler is required")
+
+	_, err = NewLeaseManager(&stubLeaseRenewalHandler{}, &LeaseManagerConfig{RenewalsPerSecond: 1})
+	assert.EqualError(t, err, "invalid MaxConcurrentRenewals 0, must be greater than 0")
+
+	_, err = NewLeaseManager(&stubLeaseRenewalHandler{}, &LeaseManagerConfig{MaxConcurrentRenewals: 1})
+	assert.EqualError(t, err, "invalid RenewalsPerSecond 0, must be greater than 0")
+}
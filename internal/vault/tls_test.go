@@ -69,6 +69,43 @@ func generateCA() ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// generateClientCert returns a self-signed client certificate, and its
+// private key, both in PEM format.
+func generateClientCert() ([]byte, []byte, error) {
+	signer, key, err := privateKey()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sn, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber: sn,
+		Subject:      pkix.Name{CommonName: "Testing Client"},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		NotAfter:     time.Now().Add(1 * time.Hour),
+		NotBefore:    time.Now().Add(-1 * time.Minute),
+	}
+
+	bs, err := x509.CreateCertificate(
+		rand.Reader, &template, &template, signer.Public(), signer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var buf bytes.Buffer
+	err = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: bs})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), []byte(key), nil
+}
+
 // privateKey returns a new ECDSA-based private key. Both a crypto.Signer
 // and the key in PEM format are returned.
 func privateKey() (crypto.Signer, string, error) {
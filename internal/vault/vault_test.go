@@ -5,8 +5,10 @@ package vault
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/hashicorp/go-rootcerts"
@@ -118,3 +120,173 @@ func TestMakeVaultClient(t *testing.T) {
 		})
 	}
 }
+
+func TestMakeVaultClient_clientCert(t *testing.T) {
+	certPEM, keyPEM, err := generateClientCert()
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		data          map[string][]byte
+		secretName    string
+		expectedError string
+	}{
+		"valid": {
+			data: map[string][]byte{
+				corev1.TLSCertKey:       certPEM,
+				corev1.TLSPrivateKeyKey: keyPEM,
+			},
+		},
+		"missing key": {
+			data: map[string][]byte{
+				corev1.TLSCertKey: certPEM,
+			},
+			expectedError: "invalid client certificate in secret vault/client-cert: tls: failed to find any PEM data in key input",
+		},
+		"secret doesn't exist": {
+			secretName:    "missing",
+			expectedError: `secrets "missing" not found`,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fakeClient := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+				ObjectMeta: v1.ObjectMeta{
+					Name:      "client-cert",
+					Namespace: "vault",
+				},
+				Type: corev1.SecretTypeTLS,
+				Data: tc.data,
+			}).Build()
+
+			secretName := tc.secretName
+			if secretName == "" {
+				secretName = "client-cert"
+			}
+			vaultClient, err := MakeVaultClient(context.Background(), &ClientConfig{
+				ClientCertSecretRef: secretName,
+				K8sNamespace:        "vault",
+				Address:             "https://localhost:8200",
+			}, fakeClient)
+			if tc.expectedError != "" {
+				assert.EqualError(t, err, tc.expectedError)
+				assert.Nil(t, vaultClient)
+				return
+			}
+
+			require.NoError(t, err)
+			tlsConfig := vaultClient.CloneConfig().HttpClient.Transport.(*http.Transport).TLSClientConfig
+			require.Len(t, tlsConfig.Certificates, 1)
+
+			expected, err := tls.X509KeyPair(certPEM, keyPEM)
+			require.NoError(t, err)
+			assert.Equal(t, expected.Certificate, tlsConfig.Certificates[0].Certificate)
+		})
+	}
+}
+
+// newHealthTestServer returns an httptest.Server that responds to sys/health
+// with the provided health response.
+func newHealthTestServer(t *testing.T, health string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/sys/health" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(health))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestMakeVaultClient_fallbackAddresses(t *testing.T) {
+	healthy := newHealthTestServer(t, `{"initialized": true, "sealed": false, "standby": false}`)
+	perfStandby := newHealthTestServer(t, `{"initialized": true, "sealed": false, "standby": true, "performance_standby": true}`)
+	sealed := newHealthTestServer(t, `{"initialized": true, "sealed": true, "standby": false}`)
+	standby := newHealthTestServer(t, `{"initialized": true, "sealed": false, "standby": true}`)
+	unreachable := newHealthTestServer(t, "")
+	unreachable.Close()
+
+	tests := map[string]struct {
+		address           string
+		fallbackAddresses []string
+		want              string
+	}{
+		"primary-healthy": {
+			address:           healthy.URL,
+			fallbackAddresses: []string{sealed.URL},
+			want:              healthy.URL,
+		},
+		"primary-sealed": {
+			address:           sealed.URL,
+			fallbackAddresses: []string{healthy.URL},
+			want:              healthy.URL,
+		},
+		"primary-standby": {
+			address:           standby.URL,
+			fallbackAddresses: []string{perfStandby.URL, healthy.URL},
+			want:              perfStandby.URL,
+		},
+		"primary-unreachable": {
+			address:           unreachable.URL,
+			fallbackAddresses: []string{sealed.URL, healthy.URL},
+			want:              healthy.URL,
+		},
+		"none-healthy": {
+			address:           sealed.URL,
+			fallbackAddresses: []string{standby.URL},
+			want:              sealed.URL,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			vaultClient, err := MakeVaultClient(context.Background(), &ClientConfig{
+				Address:           tc.address,
+				FallbackAddresses: tc.fallbackAddresses,
+				VaultNamespace:    "ns1",
+			}, fake.NewClientBuilder().Build())
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, vaultClient.Address())
+			assert.Equal(t, "ns1", vaultClient.Headers().Get(consts.NamespaceHeaderName))
+		})
+	}
+}
+
+func TestMakeVaultClient_clientCertNamespace(t *testing.T) {
+	certPEM, keyPEM, err := generateClientCert()
+	require.NoError(t, err)
+
+	fakeClient := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "client-cert",
+			Namespace: "vso",
+		},
+		Type: corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       certPEM,
+			corev1.TLSPrivateKeyKey: keyPEM,
+		},
+	}).Build()
+
+	vaultClient, err := MakeVaultClient(context.Background(), &ClientConfig{
+		ClientCertSecretRef: "client-cert",
+		ClientCertNamespace: "vso",
+		K8sNamespace:        "tenant",
+		Address:             "https://localhost:8200",
+	}, fakeClient)
+	require.NoError(t, err)
+	tlsConfig := vaultClient.CloneConfig().HttpClient.Transport.(*http.Transport).TLSClientConfig
+	assert.Len(t, tlsConfig.Certificates, 1)
+
+	_, err = MakeVaultClient(context.Background(), &ClientConfig{
+		ClientCertSecretRef: "client-cert",
+		K8sNamespace:        "tenant",
+		Address:             "https://localhost:8200",
+	}, fakeClient)
+	assert.EqualError(t, err, `secrets "client-cert" not found`)
+}
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -54,6 +55,7 @@ func main() {
 	defaultPersistenceModel := persistenceModelNone
 	vdsOptions := controller.Options{}
 	cfc := vclient.DefaultCachingClientFactoryConfig()
+	lmc := vclient.DefaultLeaseManagerConfig()
 
 	var metricsAddr string
 	var enableLeaderElection bool
@@ -62,6 +64,8 @@ func main() {
 	var printVersion bool
 	var outputFormat string
 	var finalizerCleanup bool
+	var vaultConnectionHealthProbeInterval time.Duration
+	var vaultEventsEnabled bool
 	flag.BoolVar(&printVersion, "version", false, "Print the operator version information")
 	flag.StringVar(&outputFormat, "output", "", "Output format for the operator version information (yaml or json)")
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
@@ -71,12 +75,28 @@ func main() {
 			"Enabling this will ensure there is only one active controller manager.")
 	flag.IntVar(&cfc.ClientCacheSize, "client-cache-size", cfc.ClientCacheSize,
 		"Size of the in-memory LRU client cache.")
+	flag.BoolVar(&cfc.RevokeTokens, "client-cache-revoke-tokens", cfc.RevokeTokens,
+		"Revoke the Vault token of a client when it is evicted or pruned from the client cache.")
+	flag.Float64Var(&cfc.ReLoginFraction, "client-cache-relogin-fraction", cfc.ReLoginFraction,
+		"Fraction of a non-renewable Vault token's TTL after which the client will log in again. "+
+			"Must be greater than 0 and less than 1.")
 	flag.StringVar(&clientCachePersistenceModel, "client-cache-persistence-model", defaultPersistenceModel,
 		fmt.Sprintf(
 			"The type of client cache persistence model that should be employed."+
 				"choices=%v", []string{persistenceModelDirectUnencrypted, persistenceModelDirectEncrypted, persistenceModelNone}))
 	flag.IntVar(&vdsOptions.MaxConcurrentReconciles, "max-concurrent-reconciles-vds", 100,
 		"Maximum number of concurrent reconciles for the VaultDynamicSecrets controller.")
+	flag.IntVar(&lmc.MaxConcurrentRenewals, "lease-manager-max-concurrent-renewals", lmc.MaxConcurrentRenewals,
+		"Maximum number of concurrent lease renewals for dynamic secrets.")
+	flag.Float64Var(&lmc.RenewalsPerSecond, "lease-manager-renewals-per-second", lmc.RenewalsPerSecond,
+		"Maximum number of lease renewals per second for dynamic secrets.")
+	flag.IntVar(&lmc.RenewalBurst, "lease-manager-renewal-burst", lmc.RenewalBurst,
+		"Maximum number of lease renewals that may exceed the renewals per second at once.")
+	flag.DurationVar(&vaultConnectionHealthProbeInterval, "vault-connection-health-probe-interval", time.Minute,
+		"Interval at which the health of each VaultConnection's Vault server is probed. Set to 0 to disable periodic probing.")
+	flag.BoolVar(&vaultEventsEnabled, "vault-events-enabled", false,
+		"Sync VaultStaticSecrets on Vault's kv-v2 event notifications, in addition to polling. "+
+			"Requires Vault's events to be enabled.")
 	flag.BoolVar(&finalizerCleanup, "finalizer-cleanup", false, "Remove finalizers from all CRs in preparation for shutdown.")
 	opts := zap.Options{
 		Development: true,
@@ -148,6 +168,10 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "b0d477c0.hashicorp.com",
+		// the Manager's cache only holds the Secrets that were created by the Operator, all other
+		// Secrets, e.g. VaultConnection client certificates, are read from the API server.
+		NewCache:              controllers.NewManagerCache,
+		ClientDisableCacheFor: []client.Object{&corev1.Secret{}},
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -173,6 +197,7 @@ func main() {
 			metrics.NewBuildInfoGauge(versionInfo),
 		)
 		vclient.MustRegisterClientMetrics(cfc.MetricsRegistry)
+		lmc.MetricsRegistry = cfc.MetricsRegistry
 	}
 	var clientFactory vclient.CachingClientFactory
 	{
@@ -207,22 +232,65 @@ func main() {
 		}
 	}
 
-	if err = (&controllers.VaultStaticSecretReconciler{
+	// the VaultConnection client certificate Secrets are watched for rotation in a separate cache,
+	// that only holds the Secrets of type kubernetes.io/tls.
+	clientCertSecretsCache, err := controllers.NewClientCertSecretsCache(mgr)
+	if err != nil {
+		setupLog.Error(err, "Failed to setup the client certificate secrets cache")
+		os.Exit(1)
+	}
+	if err := mgr.Add(clientCertSecretsCache); err != nil {
+		setupLog.Error(err, "Unable to add the client certificate secrets cache")
+		os.Exit(1)
+	}
+	// the destination Secrets are watched for drift, and read when checking for drift, from the
+	// Manager's cache, which only holds the Secrets that were created by the Operator.
+	secretsCache := mgr.GetCache()
+
+	// the secret resources are indexed by their VaultAuth, so that they can be synced whenever
+	// their VaultAuth, or its VaultConnection, changes. The VaultConnections are indexed by their
+	// client certificate Secret, so that they can be reconciled whenever it is rotated.
+	if err := controllers.SetupFieldIndexes(ctx, mgr.GetFieldIndexer()); err != nil {
+		setupLog.Error(err, "Failed to setup the field indexes")
+		os.Exit(1)
+	}
+
+	hmacFunc := vclient.NewHMACFromSecretFunc(cfc.StorageConfig.HMACSecretObjKey)
+	validateMACFunc := vclient.NewMACValidateFromSecretFunc(cfc.StorageConfig.HMACSecretObjKey)
+	vssReconciler := &controllers.VaultStaticSecretReconciler{
 		Client:          mgr.GetClient(),
 		Scheme:          mgr.GetScheme(),
 		Recorder:        mgr.GetEventRecorderFor("VaultStaticSecret"),
-		HMACFunc:        vclient.NewHMACFromSecretFunc(cfc.StorageConfig.HMACSecretObjKey),
-		ValidateMACFunc: vclient.NewMACValidateFromSecretFunc(cfc.StorageConfig.HMACSecretObjKey),
+		HMACFunc:        hmacFunc,
+		ValidateMACFunc: validateMACFunc,
 		ClientFactory:   clientFactory,
-	}).SetupWithManager(mgr); err != nil {
+		SecretsCache:    secretsCache,
+	}
+	if vaultEventsEnabled {
+		eventWatcher, err := vclient.NewEventWatcher(mgr.GetClient(), clientFactory,
+			vssReconciler.OnEvent, vclient.DefaultEventWatcherConfig())
+		if err != nil {
+			setupLog.Error(err, "Failed to setup the event watcher")
+			os.Exit(1)
+		}
+		if err := mgr.Add(eventWatcher); err != nil {
+			setupLog.Error(err, "Unable to add the event watcher")
+			os.Exit(1)
+		}
+		vssReconciler.EventWatcher = eventWatcher
+	}
+	if err = vssReconciler.SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "Unable to create controller", "controller", "VaultStaticSecret")
 		os.Exit(1)
 	}
 	if err = (&controllers.VaultPKISecretReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		ClientFactory: clientFactory,
-		Recorder:      mgr.GetEventRecorderFor("VaultPKISecret"),
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		ClientFactory:   clientFactory,
+		Recorder:        mgr.GetEventRecorderFor("VaultPKISecret"),
+		HMACFunc:        hmacFunc,
+		ValidateMACFunc: validateMACFunc,
+		SecretsCache:    secretsCache,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "Unable to create controller", "controller", "VaultPKISecret")
 		os.Exit(1)
@@ -237,23 +305,53 @@ func main() {
 		os.Exit(1)
 	}
 	if err = (&controllers.VaultConnectionReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		Recorder:      mgr.GetEventRecorderFor("VaultConnection"),
-		ClientFactory: clientFactory,
+		Client:                 mgr.GetClient(),
+		Scheme:                 mgr.GetScheme(),
+		Recorder:               mgr.GetEventRecorderFor("VaultConnection"),
+		ClientFactory:          clientFactory,
+		HealthProbeInterval:    vaultConnectionHealthProbeInterval,
+		ClientCertSecretsCache: clientCertSecretsCache,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "Unable to create controller", "controller", "VaultConnection")
 		os.Exit(1)
 	}
-	if err = (&controllers.VaultDynamicSecretReconciler{
-		Client:        mgr.GetClient(),
-		Scheme:        mgr.GetScheme(),
-		Recorder:      mgr.GetEventRecorderFor("VaultDynamicSecret"),
-		ClientFactory: clientFactory,
-	}).SetupWithManager(mgr, vdsOptions); err != nil {
+	vdsReconciler := &controllers.VaultDynamicSecretReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		Recorder:        mgr.GetEventRecorderFor("VaultDynamicSecret"),
+		ClientFactory:   clientFactory,
+		HMACFunc:        hmacFunc,
+		ValidateMACFunc: validateMACFunc,
+		SecretsCache:    secretsCache,
+	}
+	{
+		leaseManager, err := vclient.NewLeaseManager(vdsReconciler, lmc)
+		if err != nil {
+			setupLog.Error(err, "Failed to setup the lease manager")
+			os.Exit(1)
+		}
+		if err := mgr.Add(leaseManager); err != nil {
+			setupLog.Error(err, "Unable to add the lease manager")
+			os.Exit(1)
+		}
+		vdsReconciler.LeaseManager = leaseManager
+	}
+	if err = vdsReconciler.SetupWithManager(mgr, vdsOptions); err != nil {
 		setupLog.Error(err, "Unable to create controller", "controller", "VaultDynamicSecret")
 		os.Exit(1)
 	}
+	if err = (&controllers.VaultSecretBundleReconciler{
+		Client:          mgr.GetClient(),
+		Scheme:          mgr.GetScheme(),
+		ClientFactory:   clientFactory,
+		Recorder:        mgr.GetEventRecorderFor("VaultSecretBundle"),
+		HMACFunc:        hmacFunc,
+		ValidateMACFunc: validateMACFunc,
+		SecretsCache:    secretsCache,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "Unable to create controller", "controller", "VaultSecretBundle")
+		os.Exit(1)
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -268,6 +366,11 @@ func main() {
 	setupLog.Info("Starting manager",
 		"clientCachePersistenceModel", clientCachePersistenceModel,
 		"clientCacheSize", cfc.ClientCacheSize,
+		"clientCacheRevokeTokens", cfc.RevokeTokens,
+		"clientCacheReLoginFraction", cfc.ReLoginFraction,
+		"leaseManagerMaxConcurrentRenewals", lmc.MaxConcurrentRenewals,
+		"leaseManagerRenewalsPerSecond", lmc.RenewalsPerSecond,
+		"vaultEventsEnabled", vaultEventsEnabled,
 	)
 
 	mgr.GetCache()
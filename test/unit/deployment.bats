@@ -130,6 +130,30 @@ load _helpers
     [ "${actual}" = "true" ]
 }
 
+@test "controller/Deployment: clientCache revokeTokens can be set" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/deployment.yaml  \
+      --set 'controller.manager.clientCache.revokeTokens=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[1].args | select(documentIndex == 1)' | tee /dev/stderr)
+
+   local actual=$(echo "$object" | yq 'contains(["--client-cache-revoke-tokens"])' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+}
+
+@test "controller/Deployment: clientCache reLoginFraction can be set" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/deployment.yaml  \
+      --set 'controller.manager.clientCache.reLoginFraction=0.5' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[1].args | select(documentIndex == 1)' | tee /dev/stderr)
+
+   local actual=$(echo "$object" | yq 'contains(["--client-cache-relogin-fraction=0.5"])' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+}
+
 #--------------------------------------------------------------------
 # maxConcurrentReconciles
 
@@ -157,3 +181,87 @@ load _helpers
 }
 
 
+
+#--------------------------------------------------------------------
+# vaultConnectionHealthProbeInterval
+
+@test "controller/Deployment: vaultConnectionHealthProbeInterval not set by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/deployment.yaml  \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[1].args | select(documentIndex == 1)' | tee /dev/stderr)
+
+   local actual=$(echo "$object" | yq 'contains(["--vault-connection-health-probe-interval"])' | tee /dev/stderr)
+    [ "${actual}" = "false" ]
+}
+
+@test "controller/Deployment: vaultConnectionHealthProbeInterval can be set" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/deployment.yaml  \
+      --set 'controller.manager.vaultConnectionHealthProbeInterval=30s' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[1].args | select(documentIndex == 1)' | tee /dev/stderr)
+
+   local actual=$(echo "$object" | yq 'contains(["--vault-connection-health-probe-interval=30s"])' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# leaseManager
+
+@test "controller/Deployment: leaseManager not set by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/deployment.yaml  \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[1].args | select(documentIndex == 1)' | tee /dev/stderr)
+
+   local actual=$(echo "$object" | yq 'map(select(. == "--lease-manager-*")) | length' | tee /dev/stderr)
+    [ "${actual}" = "0" ]
+}
+
+@test "controller/Deployment: leaseManager can be set" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/deployment.yaml  \
+      --set 'controller.manager.leaseManager.maxConcurrentRenewals=20' \
+      --set 'controller.manager.leaseManager.renewalsPerSecond=5' \
+      --set 'controller.manager.leaseManager.renewalBurst=10' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[1].args | select(documentIndex == 1)' | tee /dev/stderr)
+
+   local actual=$(echo "$object" | yq 'contains(["--lease-manager-max-concurrent-renewals=20"])' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+   actual=$(echo "$object" | yq 'contains(["--lease-manager-renewals-per-second=5"])' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+   actual=$(echo "$object" | yq 'contains(["--lease-manager-renewal-burst=10"])' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+}
+
+#--------------------------------------------------------------------
+# vaultEvents
+
+@test "controller/Deployment: vaultEvents not enabled by default" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/deployment.yaml  \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[1].args | select(documentIndex == 1)' | tee /dev/stderr)
+
+   local actual=$(echo "$object" | yq 'contains(["--vault-events-enabled"])' | tee /dev/stderr)
+    [ "${actual}" = "false" ]
+}
+
+@test "controller/Deployment: vaultEvents can be enabled" {
+  cd `chart_dir`
+  local object=$(helm template \
+      -s templates/deployment.yaml  \
+      --set 'controller.manager.vaultEvents.enabled=true' \
+      . | tee /dev/stderr |
+      yq '.spec.template.spec.containers[1].args | select(documentIndex == 1)' | tee /dev/stderr)
+
+   local actual=$(echo "$object" | yq 'contains(["--vault-events-enabled"])' | tee /dev/stderr)
+    [ "${actual}" = "true" ]
+}